@@ -7,16 +7,24 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
+	"google.golang.org/grpc"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 	"wallet/internal/metrics"
+	model "wallet/internal/model/handler"
 	"wallet/internal/repository/cache"
 	"wallet/internal/repository/postgres"
 	"wallet/internal/rest"
+	"wallet/internal/rpc"
+	"wallet/internal/rpc/walletpb"
 	"wallet/internal/services"
 
 	"github.com/joho/godotenv"
@@ -42,7 +50,7 @@ func main() {
 	defer pool.Close()
 
 	initMigrations(pool)
-	repo := postgres.New(pool)
+	repo := postgres.New(pool, postgres.WithRetryPolicy(retryPolicy()))
 
 	lru, err := lru2.New(1000) // can be replaced by other cache implementations
 	if err != nil {
@@ -50,34 +58,183 @@ func main() {
 	}
 	cache := cache.New(lru)
 
-	walletService := services.NewWalletService(repo, cache, logger)
-	walletHandler := rest.NewWalletHandler(walletService)
+	var serviceOpts []services.Option
+	if order := os.Getenv("WALLET_SPENDING_ORDER"); order != "" {
+		serviceOpts = append(serviceOpts, services.WithSpendingOrder(strings.Split(order, ",")))
+	}
+	if order := os.Getenv("WALLET_WITHDRAWAL_ORDER"); order != "" {
+		serviceOpts = append(serviceOpts, services.WithWithdrawalOrder(strings.Split(order, ",")))
+	}
+	if path := os.Getenv("FEE_SCHEDULE_FILE"); path != "" {
+		fees, err := services.LoadFeeSchedule(path)
+		if err != nil {
+			panic(err)
+		}
+		serviceOpts = append(serviceOpts, services.WithFeeSchedule(fees))
+	}
+	if path := os.Getenv("FX_RATES_FILE"); path != "" {
+		rates, err := services.NewFileRateProvider(path)
+		if err != nil {
+			panic(err)
+		}
+		serviceOpts = append(serviceOpts, services.WithRateProvider(rates, envDuration("FX_QUOTE_TTL", 30*time.Second)))
+	}
+	if path := os.Getenv("INTEREST_PRODUCTS_FILE"); path != "" {
+		products, err := services.LoadInterestProducts(path)
+		if err != nil {
+			panic(err)
+		}
+		serviceOpts = append(serviceOpts, services.WithInterestProducts(products))
+	}
+	var batcher *services.DepositBatcher
+	if window := os.Getenv("HOT_WALLET_BATCH_WINDOW"); window != "" {
+		batcher = depositBatcher(repo, window)
+		serviceOpts = append(serviceOpts, services.WithDepositBatcher(batcher))
+	}
+
+	eventHub := services.NewEventHub(postgres.NewListener(pool), logger,
+		envInt("EVENTS_MAX_STREAMS", 1000), envInt("EVENTS_MAX_STREAMS_PER_WALLET", 10))
+	serviceOpts = append(serviceOpts, services.WithEventHub(eventHub))
+
+	walletService := services.NewWalletService(repo, cache, logger, serviceOpts...)
+	maxAmount := int64(envInt("MAX_OPERATION_AMOUNT", int(model.DefaultMaxAmount)))
+	walletHandler := rest.NewWalletHandler(walletService,
+		rest.WithEventStreams(envDuration("EVENTS_HEARTBEAT_INTERVAL", 15*time.Second), envDuration("EVENTS_MAX_STREAM_DURATION", time.Hour)),
+		rest.WithMaxAmount(maxAmount))
+
+	auditLog := services.NewAuditLog(repo, logger)
 
 	mux := http.NewServeMux()
 
 	initMetrics(mux)
 
-	mux.Handle("/api/v1/wallet", metrics.MetricsMiddleware(http.HandlerFunc(walletHandler.WalletOperation), "WalletOperation"))
-	mux.Handle("/api/v1/wallets/", metrics.MetricsMiddleware(http.HandlerFunc(walletHandler.GetBalance), "GetBalance"))
+	rest.Register(mux, walletHandler.Routes(), auditLog, adminTokens())
 
 	server := &http.Server{
 		Addr:    os.Getenv("SERVER_ADDRESS"),
-		Handler: mux,
+		Handler: rest.WithRequestID(mux),
 	}
+	server.RegisterOnShutdown(walletHandler.StopStreams)
 
-	// listen to OS signals and gracefully shutdown HTTP server
+	// the gRPC API shares the wallet service and audit log with the HTTP API
+	rpcServer := rpc.NewServer(walletService, rpc.WithMaxAmount(maxAmount))
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			metrics.UnaryServerInterceptor,
+			rpc.AuditInterceptor(auditLog, walletpb.WalletService_Deposit_FullMethodName, walletpb.WalletService_Withdraw_FullMethodName),
+		),
+		grpc.StreamInterceptor(metrics.StreamServerInterceptor),
+	)
+	walletpb.RegisterWalletServiceServer(grpcServer, rpcServer)
+
+	// background jobs run until shutdown
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		eventHub.Run(workersCtx)
+	}()
+
+	if batcher != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			runEvery(workersCtx, envDuration("HOT_WALLET_RELOAD_INTERVAL", time.Minute), func(ctx context.Context) {
+				hot, err := repo.HotWallets(ctx)
+				if err != nil {
+					logger.Error("Error reloading hot wallets", "error", err)
+					return
+				}
+				batcher.SetHot(hot)
+			})
+		}()
+	}
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		runEvery(workersCtx, envDuration("EXPIRY_SWEEP_INTERVAL", time.Minute), func(ctx context.Context) {
+			_, _ = walletService.ExpireDue(ctx) // errors are logged by the service
+		})
+	}()
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		runEvery(workersCtx, envDuration("SCHEDULE_POLL_INTERVAL", 10*time.Second), func(ctx context.Context) {
+			_, _ = walletService.RunDueSchedules(ctx) // errors are logged by the service
+		})
+	}()
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		runEvery(workersCtx, envDuration("INTEREST_ACCRUAL_INTERVAL", time.Hour), func(ctx context.Context) {
+			// accrue up to yesterday, the last complete day; wallets catch up on missed days
+			_, _ = walletService.AccrueInterest(ctx, time.Now().UTC().AddDate(0, 0, -1)) // errors are logged by the service
+		})
+	}()
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		runEvery(workersCtx, envDuration("SNAPSHOT_INTERVAL", time.Hour), func(ctx context.Context) {
+			_, _ = walletService.TakeSnapshot(ctx) // errors are logged by the service
+		})
+	}()
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		runEvery(workersCtx, envDuration("RECONCILIATION_INTERVAL", time.Hour), func(ctx context.Context) {
+			_, _ = walletService.Reconcile(ctx) // discrepancies and errors are logged by the service
+		})
+	}()
+
+	if addr := os.Getenv("GRPC_ADDRESS"); addr != "" {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			panic(err)
+		}
+
+		go func() {
+			logger.Info("Starting gRPC server", "address", addr)
+			if err := grpcServer.Serve(lis); err != nil {
+				logger.Error("failed to start gRPC server", "error", err)
+			}
+		}()
+	}
+
+	// listen to OS signals and gracefully shutdown HTTP and gRPC servers
 	stopped := make(chan struct{})
 	go func() {
 		sigint := make(chan os.Signal, 1)
 		signal.Notify(sigint, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 		<-sigint
 
+		stopWorkers()
+		workers.Wait()
+
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		if err := server.Shutdown(ctx); err != nil {
-			logger.Error("HTTP Server Shutdown Error", "error", err)
-		}
+		var servers sync.WaitGroup
+		servers.Add(2)
+		go func() {
+			defer servers.Done()
+			if err := server.Shutdown(ctx); err != nil {
+				logger.Error("HTTP Server Shutdown Error", "error", err)
+			}
+		}()
+		go func() {
+			defer servers.Done()
+			rpcServer.Stop()
+			stopGRPC(ctx, grpcServer)
+		}()
+		servers.Wait()
+
 		close(stopped)
 	}()
 
@@ -91,6 +248,21 @@ func main() {
 	fmt.Println("Server exited properly")
 }
 
+// stopGRPC stops the server gracefully, cancelling the calls still running when ctx is done.
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
+
 func initMetrics(mux *http.ServeMux) {
 	metrics.Register()
 
@@ -132,3 +304,102 @@ func setupLogger(env string) *slog.Logger {
 
 	return log
 }
+
+// depositBatcher enables write batching for wallets flagged as hot. The set of hot
+// wallets is read at startup and reloaded every HOT_WALLET_RELOAD_INTERVAL.
+func depositBatcher(repo *postgres.Storage, window string) *services.DepositBatcher {
+	d, err := time.ParseDuration(window)
+	if err != nil {
+		panic("invalid HOT_WALLET_BATCH_WINDOW: " + err.Error())
+	}
+
+	hot, err := repo.HotWallets(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	return services.NewDepositBatcher(repo, d, hot)
+}
+
+// retryPolicy reads the transaction retry budget from TX_RETRY_* variables, falling back to defaults.
+func retryPolicy() postgres.RetryPolicy {
+	policy := postgres.DefaultRetryPolicy
+
+	if v := os.Getenv("TX_RETRY_MAX_ATTEMPTS"); v != "" {
+		attempts, err := strconv.Atoi(v)
+		if err != nil {
+			panic("invalid TX_RETRY_MAX_ATTEMPTS: " + err.Error())
+		}
+		policy.MaxAttempts = attempts
+	}
+
+	policy.BaseDelay = envDuration("TX_RETRY_BASE_DELAY", policy.BaseDelay)
+	policy.MaxDelay = envDuration("TX_RETRY_MAX_DELAY", policy.MaxDelay)
+
+	return policy
+}
+
+// adminTokens reads admin bearer tokens from ADMIN_TOKENS, a comma separated list of
+// principal:token pairs. Admin endpoints reject every request when it is not set.
+func adminTokens() map[string]string {
+	tokens := make(map[string]string)
+
+	for _, pair := range strings.Split(os.Getenv("ADMIN_TOKENS"), ",") {
+		if pair == "" {
+			continue
+		}
+
+		principal, token, ok := strings.Cut(pair, ":")
+		if !ok || principal == "" || token == "" {
+			panic("invalid ADMIN_TOKENS: expected principal:token pairs")
+		}
+		tokens[principal] = token
+	}
+
+	return tokens
+}
+
+// envInt reads an integer variable, falling back to def when it is not set.
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		panic("invalid " + name + ": " + err.Error())
+	}
+
+	return n
+}
+
+// envDuration reads a duration variable, falling back to def when it is not set.
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		panic("invalid " + name + ": " + err.Error())
+	}
+
+	return d
+}
+
+// runEvery calls fn every interval until ctx is done.
+func runEvery(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(ctx)
+		}
+	}
+}
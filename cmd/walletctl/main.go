@@ -0,0 +1,739 @@
+// Command walletctl administers wallets through the service layer, straight on the database.
+//
+// Usage:
+//
+//	walletctl [-dsn dsn] [-as principal] [-o json|table] <command> [flags] [args]
+//
+// Commands:
+//
+//	create       [-id uuid] [-owner id] [-currency code] [-meta key=value]...
+//	balance      <walletId>
+//	history      [-limit n] [-before operationId] [-meta key:value]... <walletId>
+//	freeze       <walletId>
+//	unfreeze     <walletId>
+//	adjust       [-bucket name] -amount n -reason text <walletId>
+//	adjustments  [-status PENDING|APPROVED|REJECTED] [-limit n]
+//	migrate      [-dir dir] [up|down|status|version]
+//	verify-audit
+//
+// adjust only proposes an adjustment; it is applied when an admin other than the proposer
+// approves it through the HTTP admin API. walletctl cannot approve or reject requests: the
+// -as principal is not authenticated, so it must not be able to act as the checker.
+// Mutating commands are recorded in the audit log under the -as principal with their
+// outcome; a command whose record cannot be written fails. The running service drops its
+// cached balances on the balance events the database publishes, so changes made here are
+// visible to it right away.
+//
+// walletctl exits with 0 on success, 2 on a command line mistake and 1 on any other error.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	model "wallet/internal/model/handler"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/cache"
+	"wallet/internal/repository/postgres"
+	"wallet/internal/services"
+
+	"github.com/google/uuid"
+	lru2 "github.com/hashicorp/golang-lru"
+	"github.com/jackc/pgx/v5/pgxpool"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
+
+const (
+	outputJSON  = "json"
+	outputTable = "table"
+)
+
+// Limits shared with the HTTP API.
+const (
+	maxReasonLength         = 500
+	defaultOperationsLimit  = 100
+	maxOperationsLimit      = 1000
+	defaultAdjustmentsLimit = 100
+	maxAdjustmentsLimit     = 1000
+)
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+var errUsage = usageError{errors.New("usage: walletctl [-dsn dsn] [-as principal] [-o json|table] " +
+	"create|balance|history|freeze|unfreeze|adjust|adjustments|migrate|verify-audit [flags] [args]")}
+
+// walletService is the part of services.WalletService walletctl uses.
+type walletService interface {
+	CreateWallet(ctx context.Context, w wallet.Wallet) (wallet.Wallet, error)
+	GetBalance(ctx context.Context, walletID uuid.UUID) (wallet.Balance, error)
+	Operations(ctx context.Context, walletID uuid.UUID, filter wallet.OperationFilter) ([]wallet.OperationRecord, error)
+	SetStatus(ctx context.Context, walletID uuid.UUID, status wallet.Status) error
+	ProposeAdjustment(ctx context.Context, adj wallet.Adjustment) (wallet.AdjustmentRequest, error)
+	AdjustmentRequests(ctx context.Context, status wallet.AdjustmentStatus, limit int) ([]wallet.AdjustmentRequest, error)
+}
+
+// auditLog is the part of services.AuditLog walletctl uses.
+type auditLog interface {
+	Record(ctx context.Context, rec wallet.AuditRecord) error
+	Verify(ctx context.Context) (wallet.AuditVerification, error)
+}
+
+// connector opens the service layer on the database at dsn; close releases the connections.
+type connector func(ctx context.Context, dsn string) (svc walletService, audit auditLog, close func(), err error)
+
+type command struct {
+	run   func(ctx context.Context, ctl *walletctl, args []string) error
+	audit string // audit is the operation recorded for mutating commands, named after the API route
+}
+
+var commands = map[string]command{
+	"create":       {run: create, audit: "CreateWallet"},
+	"balance":      {run: balance},
+	"history":      {run: history},
+	"freeze":       {run: setStatus("freeze", wallet.StatusFrozen), audit: "FreezeWallet"},
+	"unfreeze":     {run: setStatus("unfreeze", wallet.StatusActive), audit: "UnfreezeWallet"},
+	"adjust":       {run: adjust, audit: "ProposeAdjustment"},
+	"adjustments":  {run: adjustments},
+	"migrate":      {run: migrate},
+	"verify-audit": {run: verifyAudit},
+}
+
+type walletctl struct {
+	dsn       string
+	principal string
+	output    string
+	out       io.Writer
+	errOut    io.Writer
+	connect   connector
+
+	svc   walletService
+	audit auditLog
+	close func()
+	rec   wallet.AuditRecord // rec is the audit record target, filled in by mutating commands
+}
+
+// usageError is a mistake in the command line.
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.err.Error()
+}
+
+func (e usageError) Unwrap() error {
+	return e.err
+}
+
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := run(ctx, os.Args[1:], os.Stdout, os.Stderr, connect)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, "walletctl:", err)
+	}
+	stop()
+	os.Exit(exitCode(err))
+}
+
+// exitCode is 0 for success and help, 2 for usage errors and 1 for anything else.
+func exitCode(err error) int {
+	var usage usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usage):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func run(ctx context.Context, args []string, out, errOut io.Writer, connect connector) error {
+	fs := newFlagSet("walletctl", errOut)
+	dsn := fs.String("dsn", os.Getenv("DB_DSN"), "database connection string")
+	principal := fs.String("as", envOr("WALLET_ADMIN", os.Getenv("USER")), "admin recorded as the author of changes")
+	output := fs.String("o", outputTable, "output format, json or table")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if *output != outputJSON && *output != outputTable {
+		return usagef("unknown output format %q", *output)
+	}
+
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		return errUsage
+	}
+	if cmd.audit != "" && strings.TrimSpace(*principal) == "" {
+		return usagef("%s needs -as or WALLET_ADMIN", fs.Arg(0))
+	}
+
+	ctl := &walletctl{
+		dsn:       *dsn,
+		principal: strings.TrimSpace(*principal),
+		output:    *output,
+		out:       out,
+		errOut:    errOut,
+		connect:   connect,
+	}
+	defer ctl.shutdown()
+
+	err := cmd.run(ctx, ctl, fs.Args()[1:])
+
+	// commands connect once their arguments are valid, so only attempts that reached the
+	// service are audited. The process ends here and cannot retry a record, so a change
+	// that is not audited fails the command.
+	if cmd.audit != "" && ctl.audit != nil {
+		rec := ctl.rec
+		rec.Principal = ctl.principal
+		rec.RemoteAddr = "walletctl"
+		rec.RequestID = uuid.NewString()
+		rec.Operation = cmd.audit
+		rec.Outcome = wallet.AuditSucceeded
+		if err != nil {
+			rec.Outcome = wallet.AuditFailed
+		}
+		if recErr := ctl.audit.Record(context.WithoutCancel(ctx), rec); recErr != nil {
+			err = errors.Join(err, fmt.Errorf("%s is not audited: %w", fs.Arg(0), recErr))
+		}
+	}
+
+	return err
+}
+
+// connect wires the service layer the way the service does, without its event hub. The
+// cache only lives as long as the command.
+func connect(ctx context.Context, dsn string) (walletService, auditLog, func(), error) {
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, nil, nil, fmt.Errorf("unable to ping database: %w", err)
+	}
+
+	lru, err := lru2.New(100)
+	if err != nil {
+		pool.Close()
+		return nil, nil, nil, err
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	repo := postgres.New(pool)
+	return services.NewWalletService(repo, cache.New(lru), logger), services.NewAuditLog(repo, logger), pool.Close, nil
+}
+
+// open connects to the service layer on first use.
+func (ctl *walletctl) open(ctx context.Context) error {
+	if ctl.svc != nil {
+		return nil
+	}
+	if ctl.dsn == "" {
+		return usagef("walletctl needs -dsn or DB_DSN")
+	}
+
+	svc, audit, closeFn, err := ctl.connect(ctx, ctl.dsn)
+	if err != nil {
+		return err
+	}
+	ctl.svc, ctl.audit, ctl.close = svc, audit, closeFn
+	return nil
+}
+
+func (ctl *walletctl) shutdown() {
+	if ctl.close != nil {
+		ctl.close()
+	}
+}
+
+func create(ctx context.Context, ctl *walletctl, args []string) error {
+	fs := newFlagSet("create", ctl.errOut)
+	id := fs.String("id", "", "wallet id, random when empty")
+	owner := fs.String("owner", "", "owner id")
+	currency := fs.String("currency", "USD", "ISO 4217 currency code")
+	metadata := pairs{sep: "="}
+	fs.Var(&metadata, "meta", "metadata key=value, repeatable")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return usagef("usage: walletctl create [-id uuid] [-owner id] [-currency code] [-meta key=value]...")
+	}
+
+	w := wallet.Wallet{Currency: *currency, Metadata: metadata.m}
+	if *id != "" {
+		walletID, err := uuid.Parse(*id)
+		if err != nil {
+			return usagef("invalid wallet id: %w", err)
+		}
+		w.ID = walletID
+	}
+	if *owner != "" {
+		w.OwnerID = owner
+	}
+	if !currencyCode.MatchString(w.Currency) {
+		return usagef("invalid currency %q", w.Currency)
+	}
+	if err := model.ValidateMetadata(w.Metadata); err != nil {
+		return usageError{err}
+	}
+
+	if err := ctl.open(ctx); err != nil {
+		return err
+	}
+	if w.ID != uuid.Nil {
+		ctl.rec.WalletID = &w.ID
+	}
+
+	created, err := ctl.svc.CreateWallet(ctx, w)
+	if err != nil {
+		return err
+	}
+	ctl.rec.WalletID = &created.ID
+
+	resp := walletResponse(created)
+	return ctl.print(resp, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "WALLET ID\tOWNER\tSTATUS\tCURRENCY\tBALANCE\tCREATED")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", resp.WalletID, deref(resp.OwnerID), resp.Status,
+			resp.Currency, resp.Balance, resp.CreatedAt.Format(time.RFC3339))
+	})
+}
+
+func balance(ctx context.Context, ctl *walletctl, args []string) error {
+	walletID, err := walletArg("balance", args)
+	if err != nil {
+		return err
+	}
+
+	if err := ctl.open(ctx); err != nil {
+		return err
+	}
+
+	bal, err := ctl.svc.GetBalance(ctx, walletID)
+	if err != nil {
+		return err
+	}
+
+	resp := model.WalletOperationResponse{WalletID: walletID, Balance: bal.Total, Buckets: bal.Buckets, Metadata: bal.Metadata}
+	return ctl.print(resp, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "BUCKET\tBALANCE")
+		buckets := make([]string, 0, len(resp.Buckets))
+		for name := range resp.Buckets {
+			buckets = append(buckets, name)
+		}
+		sort.Strings(buckets)
+		for _, name := range buckets {
+			fmt.Fprintf(tw, "%s\t%d\n", name, resp.Buckets[name])
+		}
+		fmt.Fprintf(tw, "total\t%d\n", resp.Balance)
+	})
+}
+
+func history(ctx context.Context, ctl *walletctl, args []string) error {
+	fs := newFlagSet("history", ctl.errOut)
+	limit := fs.Int("limit", defaultOperationsLimit, "maximum number of operations")
+	before := fs.String("before", "", "continue after the operation with this id")
+	metadata := pairs{sep: ":"}
+	fs.Var(&metadata, "meta", "metadata key:value filter, repeatable")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	walletID, err := walletArg("history", fs.Args())
+	if err != nil {
+		return err
+	}
+
+	filter := wallet.OperationFilter{Metadata: metadata.m, Limit: *limit}
+	if filter.Limit <= 0 || filter.Limit > maxOperationsLimit {
+		return usagef("-limit must be between 1 and %d", maxOperationsLimit)
+	}
+	if *before != "" {
+		id, err := uuid.Parse(*before)
+		if err != nil {
+			return usagef("invalid -before operation id: %w", err)
+		}
+		filter.Before = &id
+	}
+
+	if err := ctl.open(ctx); err != nil {
+		return err
+	}
+
+	ops, err := ctl.svc.Operations(ctx, walletID, filter)
+	if err != nil {
+		return err
+	}
+
+	resp := make([]model.OperationResponse, 0, len(ops))
+	for _, op := range ops {
+		resp = append(resp, model.OperationResponse{
+			OperationID:   op.ID,
+			WalletID:      op.WalletID,
+			OperationType: model.OperationType(op.Type),
+			Amount:        op.Amount,
+			Fee:           op.Fee,
+			Metadata:      op.Metadata,
+			CreatedAt:     op.CreatedAt,
+		})
+	}
+
+	return ctl.print(resp, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "OPERATION ID\tTYPE\tAMOUNT\tFEE\tCREATED\tMETADATA")
+		for _, op := range resp {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\n", op.OperationID, op.OperationType, op.Amount, op.Fee,
+				op.CreatedAt.Format(time.RFC3339), formatMetadata(op.Metadata))
+		}
+	})
+}
+
+func setStatus(action string, status wallet.Status) func(ctx context.Context, ctl *walletctl, args []string) error {
+	return func(ctx context.Context, ctl *walletctl, args []string) error {
+		walletID, err := walletArg(action, args)
+		if err != nil {
+			return err
+		}
+
+		if err := ctl.open(ctx); err != nil {
+			return err
+		}
+		ctl.rec.WalletID = &walletID
+
+		if err := ctl.svc.SetStatus(ctx, walletID, status); err != nil {
+			return err
+		}
+
+		resp := struct {
+			WalletID uuid.UUID `json:"walletId"`
+			Status   string    `json:"status"`
+		}{walletID, string(status)}
+		return ctl.print(resp, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "WALLET ID\tSTATUS")
+			fmt.Fprintf(tw, "%s\t%s\n", resp.WalletID, resp.Status)
+		})
+	}
+}
+
+func adjust(ctx context.Context, ctl *walletctl, args []string) error {
+	fs := newFlagSet("adjust", ctl.errOut)
+	bucket := fs.String("bucket", wallet.BucketMain, "bucket to adjust")
+	amount := fs.Int64("amount", 0, "amount to add, negative to take off")
+	reason := fs.String("reason", "", "reason recorded with the adjustment")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	walletID, err := walletArg("adjust", fs.Args())
+	if err != nil {
+		return err
+	}
+
+	adj := wallet.Adjustment{
+		WalletID:  walletID,
+		Bucket:    *bucket,
+		Amount:    *amount,
+		Reason:    strings.TrimSpace(*reason),
+		CreatedBy: ctl.principal,
+	}
+	if adj.Amount == 0 {
+		return usagef("adjust needs a non-zero -amount")
+	}
+	if !model.BucketName.MatchString(adj.Bucket) {
+		return usagef("invalid bucket %q", adj.Bucket)
+	}
+	if adj.Reason == "" || len(adj.Reason) > maxReasonLength {
+		return usagef("adjust needs a -reason of at most %d bytes", maxReasonLength)
+	}
+
+	if err := ctl.open(ctx); err != nil {
+		return err
+	}
+	ctl.rec.WalletID, ctl.rec.Amount = &walletID, &adj.Amount
+
+	proposed, err := ctl.svc.ProposeAdjustment(ctx, adj)
+	if err != nil {
+		return err
+	}
+	ctl.rec.Resource = proposed.ID.String()
+
+	resp := adjustmentResponse(proposed)
+	return ctl.print(resp, adjustmentTable(resp))
+}
+
+func adjustments(ctx context.Context, ctl *walletctl, args []string) error {
+	fs := newFlagSet("adjustments", ctl.errOut)
+	status := fs.String("status", "", "PENDING, APPROVED or REJECTED, any when empty")
+	limit := fs.Int("limit", defaultAdjustmentsLimit, "maximum number of requests")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return usagef("usage: walletctl adjustments [-status PENDING|APPROVED|REJECTED] [-limit n]")
+	}
+
+	s := wallet.AdjustmentStatus(strings.ToUpper(*status))
+	switch s {
+	case "", wallet.AdjustmentPending, wallet.AdjustmentApproved, wallet.AdjustmentRejected:
+	default:
+		return usagef("invalid -status %q", *status)
+	}
+	if *limit <= 0 || *limit > maxAdjustmentsLimit {
+		return usagef("-limit must be between 1 and %d", maxAdjustmentsLimit)
+	}
+
+	if err := ctl.open(ctx); err != nil {
+		return err
+	}
+
+	reqs, err := ctl.svc.AdjustmentRequests(ctx, s, *limit)
+	if err != nil {
+		return err
+	}
+
+	resp := make([]model.AdjustmentRequestResponse, 0, len(reqs))
+	for _, req := range reqs {
+		resp = append(resp, adjustmentResponse(req))
+	}
+	return ctl.print(resp, adjustmentTable(resp...))
+}
+
+func adjustmentTable(reqs ...model.AdjustmentRequestResponse) func(tw *tabwriter.Writer) {
+	return func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "REQUEST ID\tWALLET ID\tBUCKET\tAMOUNT\tSTATUS\tREQUESTED BY\tDECIDED BY\tBALANCE\tREASON")
+		for _, r := range reqs {
+			balance := ""
+			if r.Balance != nil {
+				balance = strconv.FormatInt(*r.Balance, 10)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", r.RequestID, r.WalletID, r.Bucket, r.Amount,
+				r.Status, r.RequestedBy, r.DecidedBy, balance, r.Reason)
+		}
+	}
+}
+
+// migrate runs goose against the database, the same migrations the service applies on start.
+func migrate(ctx context.Context, ctl *walletctl, args []string) error {
+	fs := newFlagSet("migrate", ctl.errOut)
+	dir := fs.String("dir", "./migrations", "migrations directory")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if ctl.dsn == "" {
+		return usagef("migrate needs -dsn or DB_DSN")
+	}
+
+	cmd := "up"
+	if fs.NArg() > 0 {
+		cmd = fs.Arg(0)
+	}
+
+	db, err := sql.Open("pgx", ctl.dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := goose.SetDialect("postgres"); err != nil {
+		return err
+	}
+	// goose progress would break the JSON output
+	if ctl.output == outputJSON {
+		goose.SetLogger(goose.NopLogger())
+	} else {
+		goose.SetLogger(gooseLogger{ctl.out})
+	}
+
+	return goose.RunContext(ctx, cmd, db, *dir, fs.Args()[min(1, fs.NArg()):]...)
+}
+
+// verifyAudit checks the hash chain of the audit log. It fails when the chain is broken;
+// otherwise compare the reported head with the one recorded last time to detect removed records.
+func verifyAudit(ctx context.Context, ctl *walletctl, args []string) error {
+	if len(args) != 0 {
+		return usagef("usage: walletctl verify-audit")
+	}
+
+	if err := ctl.open(ctx); err != nil {
+		return err
+	}
+
+	result, err := ctl.audit.Verify(ctx)
+	if err != nil {
+		return err
+	}
+
+	resp := struct {
+		Records  int64  `json:"records"`
+		LastSeq  int64  `json:"lastSeq"`
+		LastHash string `json:"lastHash"`
+		BrokenAt *int64 `json:"brokenAt,omitempty"`
+		Problem  string `json:"problem,omitempty"`
+	}{result.Records, result.LastSeq, result.LastHash, result.BrokenAt, result.Problem}
+	if err := ctl.print(resp, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "RECORDS\tLAST SEQ\tLAST HASH")
+		fmt.Fprintf(tw, "%d\t%d\t%s\n", resp.Records, resp.LastSeq, resp.LastHash)
+	}); err != nil {
+		return err
+	}
+
+	if result.BrokenAt != nil {
+		return fmt.Errorf("audit log is broken at record %d: %s", *result.BrokenAt, result.Problem)
+	}
+	return nil
+}
+
+// print writes v as indented JSON or, for the table output, as the rows table writes.
+func (ctl *walletctl) print(v any, table func(tw *tabwriter.Writer)) error {
+	if ctl.output == outputJSON {
+		enc := json.NewEncoder(ctl.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(ctl.out, 0, 0, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+func walletResponse(w wallet.Wallet) model.WalletResponse {
+	return model.WalletResponse{
+		WalletID:  w.ID,
+		OwnerID:   w.OwnerID,
+		Status:    string(w.Status),
+		Currency:  w.Currency,
+		Balance:   w.Balance,
+		Metadata:  w.Metadata,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func adjustmentResponse(req wallet.AdjustmentRequest) model.AdjustmentRequestResponse {
+	return model.AdjustmentRequestResponse{
+		RequestID:   req.ID,
+		WalletID:    req.WalletID,
+		Bucket:      req.Bucket,
+		Amount:      req.Amount,
+		Reason:      req.Reason,
+		Status:      string(req.Status),
+		RequestedBy: req.CreatedBy,
+		RequestedAt: req.CreatedAt,
+		DecidedBy:   req.DecidedBy,
+		DecidedAt:   req.DecidedAt,
+		Note:        req.Note,
+	}
+}
+
+func newFlagSet(name string, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	return fs
+}
+
+// parse parses args, reporting bad flags as usage errors.
+func parse(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return usageError{err}
+}
+
+func walletArg(cmd string, args []string) (uuid.UUID, error) {
+	if len(args) != 1 {
+		return uuid.Nil, usagef("usage: walletctl %s <walletId>", cmd)
+	}
+
+	walletID, err := uuid.Parse(args[0])
+	if err != nil {
+		return uuid.Nil, usagef("invalid wallet id: %w", err)
+	}
+	return walletID, nil
+}
+
+func formatMetadata(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+m[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// pairs is a repeatable key<sep>value flag.
+type pairs struct {
+	sep string
+	m   map[string]string
+	raw []string
+}
+
+func (p *pairs) String() string {
+	return strings.Join(p.raw, ",")
+}
+
+func (p *pairs) Set(v string) error {
+	key, value, ok := strings.Cut(v, p.sep)
+	if !ok || key == "" {
+		return fmt.Errorf("expected key%svalue, got %q", p.sep, v)
+	}
+
+	if p.m == nil {
+		p.m = map[string]string{}
+	}
+	p.m[key] = value
+	p.raw = append(p.raw, v)
+	return nil
+}
+
+// gooseLogger prints goose progress to the command output.
+type gooseLogger struct {
+	w io.Writer
+}
+
+func (l gooseLogger) Fatalf(format string, v ...any) {
+	fmt.Fprintf(l.w, format, v...)
+	os.Exit(1)
+}
+
+func (l gooseLogger) Printf(format string, v ...any) {
+	fmt.Fprintf(l.w, format, v...)
+}
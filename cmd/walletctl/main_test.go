@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	walletID  = uuid.MustParse("c8b43e22-3cc0-4647-b18b-53fba78d6fed")
+	requestID = uuid.MustParse("5f0c6c4e-2c1f-4b59-9d1a-2f0a7f0f1e3b")
+)
+
+// fakeService records the calls it gets and answers them with err or canned results.
+type fakeService struct {
+	calls []string
+	err   error
+}
+
+func (f *fakeService) call(format string, args ...any) error {
+	f.calls = append(f.calls, fmt.Sprintf(format, args...))
+	return f.err
+}
+
+func (f *fakeService) CreateWallet(_ context.Context, w wallet.Wallet) (wallet.Wallet, error) {
+	w.ID, w.Status = walletID, wallet.StatusActive
+	return w, f.call("CreateWallet %s %v", w.Currency, w.Metadata)
+}
+
+func (f *fakeService) GetBalance(_ context.Context, id uuid.UUID) (wallet.Balance, error) {
+	return wallet.Balance{Total: 700, Buckets: map[string]int64{"main": 500, "bonus": 200}}, f.call("GetBalance %s", id)
+}
+
+func (f *fakeService) Operations(_ context.Context, id uuid.UUID, filter wallet.OperationFilter) ([]wallet.OperationRecord, error) {
+	return nil, f.call("Operations %s %d %v", id, filter.Limit, filter.Metadata)
+}
+
+func (f *fakeService) SetStatus(_ context.Context, id uuid.UUID, status wallet.Status) error {
+	return f.call("SetStatus %s %s", id, status)
+}
+
+func (f *fakeService) ProposeAdjustment(_ context.Context, adj wallet.Adjustment) (wallet.AdjustmentRequest, error) {
+	adj.ID = requestID
+	return wallet.AdjustmentRequest{Adjustment: adj, Status: wallet.AdjustmentPending},
+		f.call("ProposeAdjustment %s %s %d %q %s", adj.WalletID, adj.Bucket, adj.Amount, adj.Reason, adj.CreatedBy)
+}
+
+func (f *fakeService) AdjustmentRequests(_ context.Context, status wallet.AdjustmentStatus, limit int) ([]wallet.AdjustmentRequest, error) {
+	return nil, f.call("AdjustmentRequests %s %d", status, limit)
+}
+
+type fakeAudit struct {
+	records      []wallet.AuditRecord
+	verification wallet.AuditVerification
+	err          error
+}
+
+func (f *fakeAudit) Record(_ context.Context, rec wallet.AuditRecord) error {
+	f.records = append(f.records, rec)
+	return f.err
+}
+
+func (f *fakeAudit) Verify(context.Context) (wallet.AuditVerification, error) {
+	return f.verification, nil
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	brokenAt := int64(3)
+
+	tests := []struct {
+		name         string
+		args         []string
+		serviceErr   error
+		auditErr     error
+		verification wallet.AuditVerification
+		wantCode     int
+		wantCalls    []string
+		wantOutput   string
+		wantAudit    *wallet.AuditRecord // wantAudit is compared without its request id
+	}{
+		{
+			name:     "no command",
+			wantCode: 2,
+		},
+		{
+			name:     "unknown command",
+			args:     []string{"-dsn", "db", "drop"},
+			wantCode: 2,
+		},
+		{
+			name:     "help",
+			args:     []string{"-h"},
+			wantCode: 0,
+		},
+		{
+			name:     "unknown flag",
+			args:     []string{"-dsn", "db", "-x", "balance", walletID.String()},
+			wantCode: 2,
+		},
+		{
+			name:     "unknown output format",
+			args:     []string{"-dsn", "db", "-o", "yaml", "balance", walletID.String()},
+			wantCode: 2,
+		},
+		{
+			name:     "missing dsn",
+			args:     []string{"-dsn", "", "balance", walletID.String()},
+			wantCode: 2,
+		},
+		{
+			name:       "balance",
+			args:       []string{"-dsn", "db", "balance", walletID.String()},
+			wantCode:   0,
+			wantCalls:  []string{"GetBalance " + walletID.String()},
+			wantOutput: "BUCKET  BALANCE\nbonus   200\nmain    500\ntotal   700\n",
+		},
+		{
+			name:     "balance without wallet id",
+			args:     []string{"-dsn", "db", "balance"},
+			wantCode: 2,
+		},
+		{
+			name:     "balance with invalid wallet id",
+			args:     []string{"-dsn", "db", "balance", "w-1"},
+			wantCode: 2,
+		},
+		{
+			name:       "balance of missing wallet",
+			args:       []string{"-dsn", "db", "balance", walletID.String()},
+			serviceErr: wallet.ErrWalletNotFound,
+			wantCode:   1,
+			wantCalls:  []string{"GetBalance " + walletID.String()},
+		},
+		{
+			name:      "history defaults",
+			args:      []string{"-dsn", "db", "history", "-meta", "orderId:o-1", walletID.String()},
+			wantCode:  0,
+			wantCalls: []string{"Operations " + walletID.String() + " 100 map[orderId:o-1]"},
+		},
+		{
+			name:     "history limit too large",
+			args:     []string{"-dsn", "db", "history", "-limit", "1001", walletID.String()},
+			wantCode: 2,
+		},
+		{
+			name:      "create",
+			args:      []string{"-dsn", "db", "-as", "alice", "create", "-currency", "EUR", "-meta", "tier=gold"},
+			wantCode:  0,
+			wantCalls: []string{"CreateWallet EUR map[tier:gold]"},
+			wantAudit: &wallet.AuditRecord{Principal: "alice", RemoteAddr: "walletctl", Operation: "CreateWallet",
+				WalletID: &walletID, Outcome: wallet.AuditSucceeded},
+		},
+		{
+			name:      "create without audit",
+			args:      []string{"-dsn", "db", "-as", "alice", "create", "-currency", "EUR"},
+			auditErr:  wallet.ErrAuditUnavailable,
+			wantCode:  1,
+			wantCalls: []string{"CreateWallet EUR map[]"},
+			wantAudit: &wallet.AuditRecord{Principal: "alice", RemoteAddr: "walletctl", Operation: "CreateWallet",
+				WalletID: &walletID, Outcome: wallet.AuditSucceeded},
+		},
+		{
+			name:     "create with invalid currency",
+			args:     []string{"-dsn", "db", "-as", "alice", "create", "-currency", "euro"},
+			wantCode: 2,
+		},
+		{
+			name:     "create without principal",
+			args:     []string{"-dsn", "db", "-as", " ", "create"},
+			wantCode: 2,
+		},
+		{
+			name:       "freeze",
+			args:       []string{"-dsn", "db", "-as", "alice", "-o", "json", "freeze", walletID.String()},
+			wantCode:   0,
+			wantCalls:  []string{"SetStatus " + walletID.String() + " FROZEN"},
+			wantOutput: "{\n  \"walletId\": \"" + walletID.String() + "\",\n  \"status\": \"FROZEN\"\n}\n",
+			wantAudit: &wallet.AuditRecord{Principal: "alice", RemoteAddr: "walletctl", Operation: "FreezeWallet",
+				WalletID: &walletID, Outcome: wallet.AuditSucceeded},
+		},
+		{
+			name:       "unfreeze missing wallet",
+			args:       []string{"-dsn", "db", "-as", "alice", "unfreeze", walletID.String()},
+			serviceErr: wallet.ErrWalletNotFound,
+			wantCode:   1,
+			wantCalls:  []string{"SetStatus " + walletID.String() + " ACTIVE"},
+			wantAudit: &wallet.AuditRecord{Principal: "alice", RemoteAddr: "walletctl", Operation: "UnfreezeWallet",
+				WalletID: &walletID, Outcome: wallet.AuditFailed},
+		},
+		{
+			name:      "adjust",
+			args:      []string{"-dsn", "db", "-as", "alice", "adjust", "-amount", "-300", "-reason", " duplicate ", walletID.String()},
+			wantCode:  0,
+			wantCalls: []string{"ProposeAdjustment " + walletID.String() + ` main -300 "duplicate" alice`},
+			wantAudit: &wallet.AuditRecord{Principal: "alice", RemoteAddr: "walletctl", Operation: "ProposeAdjustment",
+				WalletID: &walletID, Resource: requestID.String(), Amount: ptr(int64(-300)), Outcome: wallet.AuditSucceeded},
+		},
+		{
+			name:     "adjust without reason",
+			args:     []string{"-dsn", "db", "-as", "alice", "adjust", "-amount", "-300", walletID.String()},
+			wantCode: 2,
+		},
+		{
+			name:     "adjust with invalid bucket",
+			args:     []string{"-dsn", "db", "-as", "alice", "adjust", "-bucket", "Main", "-amount", "5", "-reason", "r", walletID.String()},
+			wantCode: 2,
+		},
+		{
+			name:      "adjustments",
+			args:      []string{"-dsn", "db", "adjustments", "-status", "pending"},
+			wantCode:  0,
+			wantCalls: []string{"AdjustmentRequests PENDING 100"},
+		},
+		{
+			name:     "adjustments with invalid status",
+			args:     []string{"-dsn", "db", "adjustments", "-status", "DONE"},
+			wantCode: 2,
+		},
+		{
+			name:     "approve is not available",
+			args:     []string{"-dsn", "db", "-as", "bob", "approve", requestID.String()},
+			wantCode: 2,
+		},
+		{
+			name:     "reject is not available",
+			args:     []string{"-dsn", "db", "-as", "bob", "reject", requestID.String()},
+			wantCode: 2,
+		},
+		{
+			name:         "verify-audit",
+			args:         []string{"-dsn", "db", "verify-audit"},
+			verification: wallet.AuditVerification{Records: 2, LastSeq: 2, LastHash: "ab"},
+			wantCode:     0,
+			wantOutput:   "RECORDS  LAST SEQ  LAST HASH\n2        2         ab\n",
+		},
+		{
+			name:         "verify-audit of a broken chain",
+			args:         []string{"-dsn", "db", "verify-audit"},
+			verification: wallet.AuditVerification{Records: 3, BrokenAt: &brokenAt, Problem: "hash mismatch"},
+			wantCode:     1,
+		},
+		{
+			name:     "migrate without dsn",
+			args:     []string{"-dsn", "", "migrate", "status"},
+			wantCode: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &fakeService{err: tt.serviceErr}
+			audit := &fakeAudit{verification: tt.verification, err: tt.auditErr}
+			connect := func(_ context.Context, dsn string) (walletService, auditLog, func(), error) {
+				require.Equal(t, "db", dsn)
+				return svc, audit, func() {}, nil
+			}
+
+			var out bytes.Buffer
+			err := run(context.Background(), tt.args, &out, io.Discard, connect)
+
+			require.Equal(t, tt.wantCode, exitCode(err), "error: %v", err)
+			if tt.serviceErr != nil {
+				require.True(t, errors.Is(err, tt.serviceErr))
+			}
+			if tt.auditErr != nil {
+				require.True(t, errors.Is(err, tt.auditErr))
+			}
+			require.Equal(t, tt.wantCalls, svc.calls)
+			if tt.wantOutput != "" {
+				require.Equal(t, tt.wantOutput, out.String())
+			}
+
+			if tt.wantAudit == nil {
+				require.Empty(t, audit.records)
+				return
+			}
+			require.Len(t, audit.records, 1)
+			rec := audit.records[0]
+			require.NotEmpty(t, rec.RequestID)
+			rec.RequestID = ""
+			require.Equal(t, *tt.wantAudit, rec)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
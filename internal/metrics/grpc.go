@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"path"
+	"time"
+)
+
+// grpcMethod is the method label of gRPC calls, which are named by their RPC in the handler label.
+const grpcMethod = "GRPC"
+
+// UnaryServerInterceptor counts and times unary gRPC calls like MetricsMiddleware does for
+// HTTP requests, with the gRPC status code as the status.
+func UnaryServerInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observeGRPC(info.FullMethod, start, err)
+	return resp, err
+}
+
+// StreamServerInterceptor counts and times streaming gRPC calls, for the lifetime of the stream.
+func StreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observeGRPC(info.FullMethod, start, err)
+	return err
+}
+
+func observeGRPC(fullMethod string, start time.Time, err error) {
+	name := path.Base(fullMethod)
+
+	RequestCounter.WithLabelValues(name, grpcMethod, status.Code(err).String()).Inc()
+	RequestDuration.WithLabelValues(name, grpcMethod).Observe(time.Since(start).Seconds())
+}
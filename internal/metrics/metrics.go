@@ -23,11 +23,53 @@ var (
 		},
 		[]string{"handler", "method"},
 	)
+
+	TxRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_service_tx_retries_total",
+			Help: "Total number of retried database transactions",
+		},
+		[]string{"reason"},
+	)
+
+	TxRetriesExhausted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "wallet_service_tx_retries_exhausted_total",
+			Help: "Total number of database transactions that failed after exhausting the retry budget",
+		},
+		[]string{"reason"},
+	)
+
+	ReconciliationMismatchedWallets = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "wallet_service_reconciliation_mismatched_wallets",
+			Help: "Number of wallets whose balance did not match their journal at the last reconciliation",
+		},
+	)
+
+	ReconciliationLastRun = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "wallet_service_reconciliation_last_run_timestamp_seconds",
+			Help: "Unix time of the last completed reconciliation",
+		},
+	)
+
+	AuditFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "wallet_service_audit_failures_total",
+			Help: "Total number of mutating requests that could not be written to the audit log",
+		},
+	)
 )
 
 func Register() {
 	prometheus.MustRegister(RequestCounter)
 	prometheus.MustRegister(RequestDuration)
+	prometheus.MustRegister(TxRetries)
+	prometheus.MustRegister(TxRetriesExhausted)
+	prometheus.MustRegister(ReconciliationMismatchedWallets)
+	prometheus.MustRegister(ReconciliationLastRun)
+	prometheus.MustRegister(AuditFailures)
 }
 
 func Handler() http.Handler {
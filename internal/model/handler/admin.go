@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DiscrepancyResponse struct {
+	WalletID uuid.UUID `json:"walletId"`
+	Bucket   string    `json:"bucket,omitempty"` // Bucket is empty when the wallet total does not match
+	Balance  int64     `json:"balance"`          // Balance is the stored balance
+	Journal  int64     `json:"journal"`          // Journal is the sum of journal entries
+}
+
+type ReconciliationResponse struct {
+	CheckedAt         time.Time             `json:"checkedAt"`
+	MismatchedWallets int                   `json:"mismatchedWallets"`
+	Discrepancies     []DiscrepancyResponse `json:"discrepancies"`
+}
+
+// ErrInvalidReason is returned for adjustments without a reason.
+var ErrInvalidReason = errors.New("invalid reason")
+
+// CreateWalletRequest opens a wallet. WalletID is random and Currency is USD when empty.
+type CreateWalletRequest struct {
+	WalletID uuid.UUID         `json:"walletId,omitempty"`
+	OwnerID  *string           `json:"ownerId,omitempty"`
+	Currency string            `json:"currency,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// AdjustmentRequest corrects a wallet bucket by a positive or negative amount.
+type AdjustmentRequest struct {
+	Bucket string `json:"bucket,omitempty"` // Bucket is "main" when empty
+	Amount int64  `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+// AdjustmentRequestResponse is a proposed adjustment. Balance is set once an approval applies it.
+type AdjustmentRequestResponse struct {
+	RequestID   uuid.UUID  `json:"requestId"`
+	WalletID    uuid.UUID  `json:"walletId"`
+	Bucket      string     `json:"bucket"`
+	Amount      int64      `json:"amount"`
+	Reason      string     `json:"reason"`
+	Status      string     `json:"status"`
+	RequestedBy string     `json:"requestedBy"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	DecidedBy   string     `json:"decidedBy,omitempty"`
+	DecidedAt   *time.Time `json:"decidedAt,omitempty"`
+	Note        string     `json:"note,omitempty"`
+	Balance     *int64     `json:"balance,omitempty"` // Balance of the wallet after the adjustment
+}
+
+// AdjustmentDecisionRequest approves or rejects an adjustment request.
+type AdjustmentDecisionRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// AdjustmentEventResponse is a step in the audit trail of an adjustment request.
+type AdjustmentEventResponse struct {
+	Action    string    `json:"action"`
+	Principal string    `json:"principal"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AdjustmentDetailResponse is an adjustment request with its audit trail, oldest step first.
+type AdjustmentDetailResponse struct {
+	AdjustmentRequestResponse
+	Events []AdjustmentEventResponse `json:"events"`
+}
@@ -0,0 +1,44 @@
+package handler
+
+import "errors"
+
+var ErrInvalidOperationType = errors.New("invalid operation type")
+
+// Problem is an RFC 7807 problem details body. Code is a stable identifier of the error that
+// clients can branch on; Title and Detail are for humans and may change.
+type Problem struct {
+	Type      string           `json:"type"`
+	Title     string           `json:"title"`
+	Status    int              `json:"status"`
+	Detail    string           `json:"detail,omitempty"`
+	Instance  string           `json:"instance,omitempty"` // Instance is the request path
+	Code      string           `json:"code"`
+	RequestID string           `json:"requestId,omitempty"`
+	Errors    []FieldViolation `json:"errors,omitempty"` // Errors lists the invalid request fields
+}
+
+// FieldViolation is an invalid field of a request, named by its JSON name or query parameter.
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// FieldError ties a validation error to the request field it was found in.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+// Field reports err as a violation of the named field.
+func Field(name string, err error) error {
+	return &FieldError{Field: name, Err: err}
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
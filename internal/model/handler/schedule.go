@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+type ScheduleRequest struct {
+	OperationType OperationType `json:"operationType"`
+	WalletID      uuid.UUID     `json:"walletId"`
+	ToWalletID    *uuid.UUID    `json:"toWalletId,omitempty"` // ToWalletID is required for TRANSFER only
+	Amount        int64         `json:"amount"`
+	RunAt         *time.Time    `json:"runAt,omitempty"` // RunAt makes a one-off schedule
+	Cron          string        `json:"cron,omitempty"`  // Cron makes a recurring schedule
+}
+
+type ScheduleResponse struct {
+	ScheduleID    uuid.UUID     `json:"scheduleId"`
+	OperationType OperationType `json:"operationType"`
+	WalletID      uuid.UUID     `json:"walletId"`
+	ToWalletID    *uuid.UUID    `json:"toWalletId,omitempty"`
+	Amount        int64         `json:"amount"`
+	Cron          string        `json:"cron,omitempty"`
+	NextRunAt     *time.Time    `json:"nextRunAt,omitempty"`
+	Status        string        `json:"status"`
+	CreatedAt     time.Time     `json:"createdAt"`
+}
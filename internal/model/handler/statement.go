@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statement formats accepted by the format query parameter.
+const (
+	StatementJSON   = "json"
+	StatementNDJSON = "ndjson"
+	StatementCSV    = "csv"
+)
+
+// StatementLineResponse is a journal entry with the wallet balance right after it.
+type StatementLineResponse struct {
+	Record    string    `json:"record,omitempty"` // Record is "entry", set in NDJSON statements only
+	EntryID   int64     `json:"entryId"`
+	Type      string    `json:"type"`
+	Bucket    string    `json:"bucket"`
+	Amount    int64     `json:"amount"` // Amount is negative for debits
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// StatementBalanceResponse is the opening or closing record of an NDJSON statement.
+type StatementBalanceResponse struct {
+	Record   string    `json:"record"` // Record is "opening" or "closing"
+	WalletID uuid.UUID `json:"walletId"`
+	At       time.Time `json:"at"`
+	Balance  int64     `json:"balance"`
+}
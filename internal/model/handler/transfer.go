@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCurrency = errors.New("invalid currency")
+
+type QuoteRequest struct {
+	FromCurrency string `json:"fromCurrency"`
+	ToCurrency   string `json:"toCurrency"`
+}
+
+type QuoteResponse struct {
+	QuoteID      uuid.UUID `json:"quoteId"`
+	FromCurrency string    `json:"fromCurrency"`
+	ToCurrency   string    `json:"toCurrency"`
+	Rate         string    `json:"rate"` // Rate is a decimal string, the price of one unit of FromCurrency in ToCurrency
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+type TransferRequest struct {
+	FromWalletID uuid.UUID  `json:"fromWalletId"`
+	ToWalletID   uuid.UUID  `json:"toWalletId"`
+	Amount       int64      `json:"amount"`            // Amount is debited from the source wallet, in its currency
+	QuoteID      *uuid.UUID `json:"quoteId,omitempty"` // QuoteID is required when wallet currencies differ
+}
+
+type TransferResponse struct {
+	TransferID   uuid.UUID  `json:"transferId"`
+	FromWalletID uuid.UUID  `json:"fromWalletId"`
+	ToWalletID   uuid.UUID  `json:"toWalletId"`
+	FromCurrency string     `json:"fromCurrency"`
+	ToCurrency   string     `json:"toCurrency"`
+	QuoteID      *uuid.UUID `json:"quoteId,omitempty"`
+	Rate         string     `json:"rate"`
+	DebitAmount  int64      `json:"debitAmount"`
+	CreditAmount int64      `json:"creditAmount"`
+	Fee          int64      `json:"fee"`
+}
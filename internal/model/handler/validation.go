@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Metadata limits keep the stored documents small and indexable.
+const (
+	maxMetadataPairs       = 50
+	maxMetadataKeyLength   = 64
+	maxMetadataValueLength = 512
+)
+
+// DefaultMaxAmount bounds the amount of a single deposit or withdrawal.
+const DefaultMaxAmount int64 = 1_000_000_000_000
+
+// BucketName limits bucket names to short lowercase identifiers.
+var BucketName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// ValidateMetadata checks metadata sent by clients against the metadata limits.
+func ValidateMetadata(metadata map[string]string) error {
+	if len(metadata) > maxMetadataPairs {
+		return fmt.Errorf("%w: more than %d keys", ErrInvalidMetadata, maxMetadataPairs)
+	}
+
+	for k, v := range metadata {
+		if k == "" || len(k) > maxMetadataKeyLength {
+			return fmt.Errorf("%w: keys must be 1 to %d bytes long", ErrInvalidMetadata, maxMetadataKeyLength)
+		}
+		if len(v) > maxMetadataValueLength {
+			return fmt.Errorf("%w: value of %q is longer than %d bytes", ErrInvalidMetadata, k, maxMetadataValueLength)
+		}
+	}
+
+	return nil
+}
@@ -1,12 +1,24 @@
 package handler
 
-import "github.com/google/uuid"
+import (
+	"errors"
+	"time"
 
-import "errors"
+	"github.com/google/uuid"
+)
 
 var (
 	ErrInvalidRequest = errors.New("invalid request")
 	ErrInvalidAmount  = errors.New("invalid amount")
+	ErrInvalidBucket  = errors.New("invalid bucket")
+	ErrInvalidExpiry  = errors.New("invalid expiry")
+
+	ErrInvalidMetadata = errors.New("invalid metadata")
+	ErrInvalidWalletID = errors.New("invalid wallet id")
+
+	ErrUnknownField         = errors.New("unknown field")
+	ErrUnsupportedMediaType = errors.New("content type must be application/json")
+	ErrRequestTooLarge      = errors.New("request body is too large")
 )
 
 type OperationType string
@@ -14,15 +26,77 @@ type OperationType string
 const (
 	OperationDeposit  OperationType = "DEPOSIT"
 	OperationWithdraw OperationType = "WITHDRAW"
+	OperationTransfer OperationType = "TRANSFER"
 )
 
 type WalletOperationRequest struct {
-	WalletID      uuid.UUID     `json:"walletId"`
-	OperationType OperationType `json:"operationType"`
-	Amount        int64         `json:"amount"`
+	WalletID      uuid.UUID         `json:"walletId"`
+	OperationType OperationType     `json:"operationType"`
+	Amount        int64             `json:"amount"`
+	Bucket        string            `json:"bucket,omitempty"`    // Bucket is the deposit target, "main" when empty
+	ExpiresAt     *time.Time        `json:"expiresAt,omitempty"` // ExpiresAt makes the unspent part of a deposit expire
+	Metadata      map[string]string `json:"metadata,omitempty"`  // Metadata is stored with the operation
+}
+
+// WalletOperationResult is returned for a committed deposit or withdrawal.
+type WalletOperationResult struct {
+	WalletID      uuid.UUID         `json:"walletId"`
+	TransactionID uuid.UUID         `json:"transactionId"` // TransactionID is the operationId in the wallet history
+	OperationType OperationType     `json:"operationType"`
+	Amount        int64             `json:"amount"`
+	Fee           int64             `json:"fee"`     // Fee charged on top of the amount
+	Balance       int64             `json:"balance"` // Balance of the wallet after the operation and its fee
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	CreatedAt     time.Time         `json:"createdAt"`
 }
 
 type WalletOperationResponse struct {
-	WalletID uuid.UUID `json:"walletId"`
-	Balance  int64     `json:"balance"`
+	WalletID uuid.UUID         `json:"walletId"`
+	Balance  int64             `json:"balance"`
+	Buckets  map[string]int64  `json:"buckets,omitempty"`
+	At       *time.Time        `json:"at,omitempty"`       // At is set for historical balances
+	Metadata map[string]string `json:"metadata,omitempty"` // Metadata of the wallet, set for current balances
+}
+
+// MetadataRequest replaces the metadata of a wallet.
+type MetadataRequest struct {
+	Metadata map[string]string `json:"metadata"`
+}
+
+// OperationResponse is an operation from the wallet history.
+type OperationResponse struct {
+	OperationID   uuid.UUID         `json:"operationId"`
+	WalletID      uuid.UUID         `json:"walletId"`
+	OperationType OperationType     `json:"operationType"`
+	Amount        int64             `json:"amount"`
+	Fee           int64             `json:"fee"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	CreatedAt     time.Time         `json:"createdAt"`
+}
+
+// WalletResponse is a wallet as listed.
+type WalletResponse struct {
+	WalletID  uuid.UUID         `json:"walletId"`
+	OwnerID   *string           `json:"ownerId,omitempty"`
+	Status    string            `json:"status"`
+	Currency  string            `json:"currency"`
+	Balance   int64             `json:"balance"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+// WalletListResponse is a page of wallets. NextCursor continues the listing, it is empty on the last page.
+type WalletListResponse struct {
+	Wallets    []WalletResponse `json:"wallets"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+// BalanceEventResponse is the data of a balance event stream message. Delta and CreatedAt are
+// set for changes, not for the current balance sent when a stream starts.
+type BalanceEventResponse struct {
+	WalletID  uuid.UUID  `json:"walletId"`
+	Version   int64      `json:"version"`
+	Balance   int64      `json:"balance"`
+	Delta     int64      `json:"delta,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
 }
@@ -1 +1,68 @@
 package repo
+
+import (
+	"context"
+	"math/big"
+	"time"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// Tx is the set of wallet storage operations bound to a single transaction.
+type Tx interface {
+	// Deposit credits the bucket and returns updated wallet balance
+	Deposit(ctx context.Context, walletID uuid.UUID, credit wallet.Credit) (int64, error)
+	// CheckVersion locks the wallet for update and fails with ErrVersionMismatch unless it is at version
+	CheckVersion(ctx context.Context, walletID uuid.UUID, version int64) error
+	// Withdraw debits the buckets in order, in that order, and returns updated wallet balance
+	Withdraw(ctx context.Context, walletID uuid.UUID, amount int64, order []string) (int64, error)
+	// DepositBatch applies all amounts with one balance update and returns the balance after each of them
+	DepositBatch(ctx context.Context, walletID uuid.UUID, bucket string, amounts []int64) ([]int64, error)
+	// ExpireLots takes up to limit expired credits off their wallets
+	ExpireLots(ctx context.Context, limit int) ([]wallet.Expiry, error)
+	// ChargeFee moves the fee to the fee-income wallet and returns updated wallet balance
+	ChargeFee(ctx context.Context, walletID, feeWalletID uuid.UUID, fee int64, order []string) (int64, error)
+	// Tier returns the pricing tier of the wallet
+	Tier(ctx context.Context, walletID uuid.UUID) (string, error)
+	// LockWallets locks the wallets for update and returns their currencies
+	LockWallets(ctx context.Context, walletIDs ...uuid.UUID) (map[uuid.UUID]string, error)
+	// UseQuote marks an unexpired quote as used and returns it
+	UseQuote(ctx context.Context, quoteID uuid.UUID) (wallet.Quote, error)
+	// Transfer moves funds between wallets and returns updated source wallet balance
+	Transfer(ctx context.Context, transfer wallet.Transfer, order []string) (int64, error)
+	// NextDueSchedule locks the schedule that is due the longest, nil when none is due
+	NextDueSchedule(ctx context.Context) (*wallet.Schedule, error)
+	// RecordRun stores the outcome of an occurrence, false when it was already recorded
+	RecordRun(ctx context.Context, run wallet.ScheduleRun) (bool, error)
+	// AdvanceSchedule moves the schedule from runAt to the next occurrence, completing it when next is nil
+	AdvanceSchedule(ctx context.Context, scheduleID uuid.UUID, runAt time.Time, next *time.Time) error
+	// BucketBalanceAt returns the bucket balance from journal entries made before at
+	BucketBalanceAt(ctx context.Context, walletID uuid.UUID, bucket string, at time.Time) (int64, error)
+	// AccruedInterest returns the exact interest accrued on days in [from, to)
+	AccruedInterest(ctx context.Context, walletID uuid.UUID, from, to time.Time) (*big.Rat, error)
+	// RecordAccrual stores the accrual for a day, false when the day was already accrued
+	RecordAccrual(ctx context.Context, accrual wallet.Accrual) (bool, error)
+	// PostInterest credits interest from the funding wallet, journaled at the given time
+	PostInterest(ctx context.Context, walletID, fundingWalletID uuid.UUID, amount int64, at time.Time) error
+	// RecordOperation stores a deposit or withdrawal in the wallet history
+	RecordOperation(ctx context.Context, op wallet.OperationRecord) error
+	// Adjust applies a manual correction to a wallet bucket and returns updated wallet balance
+	Adjust(ctx context.Context, adj wallet.Adjustment) (int64, error)
+	// ProposeAdjustment stores a pending adjustment request
+	ProposeAdjustment(ctx context.Context, req wallet.AdjustmentRequest) error
+	// DecideAdjustment approves or rejects a pending request decided by someone other than its proposer
+	DecideAdjustment(ctx context.Context, requestID uuid.UUID, status wallet.AdjustmentStatus, principal, note string) (wallet.AdjustmentRequest, error)
+	// RecordAdjustmentEvent appends a step to the audit trail of an adjustment request
+	RecordAdjustmentEvent(ctx context.Context, event wallet.AdjustmentEvent) error
+}
+
+// TxFunc is a unit of work. Returning an error rolls the whole transaction back.
+type TxFunc func(ctx context.Context, tx Tx) error
+
+// UnitOfWork runs a TxFunc inside a transaction, committing it when fn succeeds.
+// Implementations may run fn more than once when the transaction has to be retried,
+// so fn must not have side effects outside of tx.
+type UnitOfWork interface {
+	WithinTx(ctx context.Context, fn TxFunc) error
+}
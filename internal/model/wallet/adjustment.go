@@ -0,0 +1,66 @@
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAdjustmentNotFound  = errors.New("adjustment request not found")
+	ErrAdjustmentDecided   = errors.New("adjustment request is already decided")
+	ErrAdjustmentSelfCheck = errors.New("adjustment request must be decided by another admin")
+)
+
+const (
+	OperationAdjust Operation = "ADJUSTMENT"
+	EntryAdjustment EntryType = "ADJUSTMENT"
+)
+
+// Adjustment is a manual correction of a wallet bucket, positive or negative, made by an
+// operator. Adjustments apply to frozen wallets too.
+type Adjustment struct {
+	ID        uuid.UUID
+	WalletID  uuid.UUID
+	Bucket    string
+	Amount    int64 // Amount is negative for debits
+	Reason    string
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+type AdjustmentStatus string
+
+const (
+	AdjustmentPending  AdjustmentStatus = "PENDING"
+	AdjustmentApproved AdjustmentStatus = "APPROVED"
+	AdjustmentRejected AdjustmentStatus = "REJECTED"
+)
+
+// AdjustmentRequest is an adjustment proposed by CreatedBy. It is applied, under the same ID,
+// only once another admin approves it.
+type AdjustmentRequest struct {
+	Adjustment
+	Status    AdjustmentStatus
+	DecidedBy string     // DecidedBy is empty while the request is pending
+	DecidedAt *time.Time // DecidedAt is nil while the request is pending
+	Note      string     // Note is left by the admin deciding the request
+}
+
+type AdjustmentAction string
+
+const (
+	AdjustmentPropose AdjustmentAction = "PROPOSE"
+	AdjustmentApprove AdjustmentAction = "APPROVE"
+	AdjustmentReject  AdjustmentAction = "REJECT"
+)
+
+// AdjustmentEvent is a step in the life of an adjustment request, kept for audit.
+type AdjustmentEvent struct {
+	RequestID uuid.UUID
+	Action    AdjustmentAction
+	Principal string
+	Note      string
+	CreatedAt time.Time
+}
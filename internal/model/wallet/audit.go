@@ -0,0 +1,54 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrAuditUnavailable = errors.New("audit log is unavailable, try again later")
+
+// AuditOutcome tells whether an audited request did what it asked for.
+type AuditOutcome string
+
+const (
+	AuditSucceeded AuditOutcome = "SUCCEEDED"
+	AuditFailed    AuditOutcome = "FAILED"
+)
+
+// AuditRecord is an entry of the audit log of mutating requests. Each record carries the
+// hash of the previous one, so changing, inserting or removing a record breaks the chain.
+type AuditRecord struct {
+	Seq        int64 // Seq numbers records from 1 without gaps
+	CreatedAt  time.Time
+	Principal  string // Principal is the authenticated admin, empty for public endpoints
+	RemoteAddr string
+	RequestID  string
+	Operation  string
+	WalletID   *uuid.UUID // WalletID is the wallet the request targets, when known
+	Resource   string     // Resource is the id of another target such as a schedule or an adjustment request
+	Amount     *int64
+	Status     int          // Status is the HTTP status or the gRPC code, 0 for walletctl
+	Outcome    AuditOutcome // Outcome is empty for records written before it was recorded
+	PrevHash   string
+	Hash       string
+}
+
+// AuditRecorder is the audit log as seen by the HTTP and gRPC APIs.
+type AuditRecorder interface {
+	// Record appends rec to the audit log; records it cannot write are kept and written later
+	Record(ctx context.Context, rec AuditRecord) error
+	// Flush writes the kept records, it fails while the audit log cannot be written
+	Flush(ctx context.Context) error
+}
+
+// AuditVerification is the result of checking the audit log chain.
+type AuditVerification struct {
+	Records  int64  // Records is the number of records checked
+	LastSeq  int64  // LastSeq and LastHash identify the head of the chain
+	LastHash string // when it is intact; keep them to detect removed tail records
+	BrokenAt *int64 // BrokenAt is the first record that does not match the chain
+	Problem  string
+}
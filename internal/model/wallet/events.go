@@ -0,0 +1,21 @@
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrTooManyWatchers is returned when the limit of open balance event streams is reached.
+var ErrTooManyWatchers = errors.New("too many balance event streams")
+
+// BalanceEvent is a change of a wallet balance. Versions number the changes of a wallet
+// in commit order, starting at 1.
+type BalanceEvent struct {
+	WalletID  uuid.UUID `json:"walletId"`
+	Version   int64     `json:"version"`
+	Balance   int64     `json:"balance"`
+	Delta     int64     `json:"delta"`
+	CreatedAt time.Time `json:"createdAt"`
+}
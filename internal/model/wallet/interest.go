@@ -0,0 +1,30 @@
+package wallet
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EntryInterest     EntryType = "INTEREST"
+	OperationInterest Operation = "INTEREST" // OperationInterest records credited interest in the wallet history
+)
+
+// InterestAccount is a wallet enrolled in an interest product.
+type InterestAccount struct {
+	WalletID    uuid.UUID
+	Product     string
+	LastAccrued *time.Time // LastAccrued is the last day the wallet accrued, nil before its first accrual
+}
+
+// Accrual is the interest earned by a wallet for one day.
+type Accrual struct {
+	WalletID uuid.UUID
+	Date     time.Time // Date is the UTC day the interest was earned on
+	Product  string
+	Balance  int64    // Balance is the end-of-day balance of the main bucket
+	Amount   *big.Rat // Amount is the exact, unrounded interest for the day
+	Posted   int64    // Posted is the rounded interest credited with this accrual
+}
@@ -0,0 +1,51 @@
+package wallet
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusActive Status = "ACTIVE"
+	StatusFrozen Status = "FROZEN"
+)
+
+// Wallet is a wallet as listed, without its bucket breakdown.
+type Wallet struct {
+	ID        uuid.UUID
+	OwnerID   *string
+	Status    Status
+	Currency  string
+	Balance   int64
+	Metadata  Metadata
+	CreatedAt time.Time
+}
+
+// WalletCursor is the position of a wallet in the listing order, oldest first.
+type WalletCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// WalletFilter selects wallets to list. Nil fields do not filter.
+type WalletFilter struct {
+	OwnerID       *string
+	Status        *Status
+	Currency      *string
+	MinBalance    *int64
+	MaxBalance    *int64
+	Metadata      Metadata // Metadata matches wallets having all of its pairs
+	CreatedFrom   *time.Time
+	CreatedBefore *time.Time
+	After         *WalletCursor // After continues the listing after this wallet
+	Limit         int
+}
+
+// WalletPage is a page of listed wallets. Next is set when more wallets may follow.
+type WalletPage struct {
+	Wallets []Wallet
+	Next    *WalletCursor
+}
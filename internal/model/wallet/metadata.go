@@ -0,0 +1,31 @@
+package wallet
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Metadata holds external references attached to wallets and operations, e.g. a customer
+// id, a merchant id or labels.
+type Metadata map[string]string
+
+// OperationRecord is an operation that changed the wallet balance, kept as wallet history:
+// deposits and withdrawals as they were requested, transfers, fees, expiry, interest and
+// adjustments.
+type OperationRecord struct {
+	ID        uuid.UUID
+	WalletID  uuid.UUID
+	Type      Operation
+	Amount    int64
+	Fee       int64
+	Metadata  Metadata
+	CreatedAt time.Time
+}
+
+// OperationFilter selects wallet history, newest first.
+type OperationFilter struct {
+	Metadata Metadata   // Metadata matches operations having all of its pairs
+	Before   *uuid.UUID // Before continues after the operation with this id
+	Limit    int
+}
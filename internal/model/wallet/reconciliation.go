@@ -0,0 +1,22 @@
+package wallet
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Discrepancy is a stored balance that does not match the sum of its journal entries.
+type Discrepancy struct {
+	WalletID uuid.UUID
+	Bucket   string // Bucket is empty when the wallet total does not match
+	Balance  int64  // Balance is the stored balance
+	Journal  int64  // Journal is the sum of journal entries
+}
+
+// Reconciliation is the outcome of checking every wallet against its journal.
+type Reconciliation struct {
+	CheckedAt         time.Time
+	MismatchedWallets int
+	Discrepancies     []Discrepancy
+}
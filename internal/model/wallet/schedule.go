@@ -0,0 +1,51 @@
+package wallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrScheduleNotFound  = errors.New("schedule not found")
+	ErrScheduleNotActive = errors.New("schedule is not active")
+	ErrInvalidRecurrence = errors.New("invalid recurrence")
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleActive    ScheduleStatus = "ACTIVE"
+	ScheduleCompleted ScheduleStatus = "COMPLETED"
+	ScheduleCancelled ScheduleStatus = "CANCELLED"
+)
+
+// Schedule is a future-dated operation, run once at NextRunAt or on every occurrence of Recurrence.
+type Schedule struct {
+	ID         uuid.UUID
+	Operation  Operation
+	WalletID   uuid.UUID  // WalletID is credited by deposits and debited by withdrawals and transfers
+	ToWalletID *uuid.UUID // ToWalletID is the transfer destination, nil for other operations
+	Amount     int64
+	Recurrence string     // Recurrence is a cron expression, empty for one-off schedules
+	NextRunAt  *time.Time // NextRunAt is nil once the schedule is completed or cancelled
+	Status     ScheduleStatus
+	CreatedAt  time.Time
+}
+
+type RunStatus string
+
+const (
+	RunSucceeded RunStatus = "SUCCEEDED"
+	RunFailed    RunStatus = "FAILED"
+)
+
+// ScheduleRun records the outcome of one occurrence of a schedule. An occurrence is
+// identified by its planned time, so it is executed at most once.
+type ScheduleRun struct {
+	ScheduleID uuid.UUID
+	RunAt      time.Time
+	Status     RunStatus
+	Error      string
+}
@@ -0,0 +1,26 @@
+package wallet
+
+import "time"
+
+// Entry is a journal entry, a single change of a wallet bucket balance.
+type Entry struct {
+	ID        int64
+	Type      EntryType
+	Bucket    string
+	Amount    int64 // Amount is negative for debits
+	CreatedAt time.Time
+}
+
+// StatementLine is a journal entry together with the wallet balance right after it.
+type StatementLine struct {
+	Entry
+	Balance int64
+}
+
+// StatementWriter receives a statement as it is read: the opening balance, every line
+// and the closing balance, in that order.
+type StatementWriter interface {
+	Opening(balance int64) error
+	Line(line StatementLine) error
+	Closing(balance int64) error
+}
@@ -0,0 +1,55 @@
+package wallet
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSameWallet       = errors.New("cannot transfer to the same wallet")
+	ErrQuoteRequired    = errors.New("quote is required to transfer between currencies")
+	ErrQuoteNotFound    = errors.New("quote not found")
+	ErrQuoteExpired     = errors.New("quote expired or already used")
+	ErrCurrencyMismatch = errors.New("quote does not match wallet currencies")
+	ErrRateNotFound     = errors.New("exchange rate not available")
+	ErrAmountTooSmall   = errors.New("amount is too small to convert")
+)
+
+const OperationTransfer Operation = "TRANSFER"
+
+// The two sides of a transfer in the wallet history; fee rules use OperationTransfer.
+const (
+	OperationTransferOut Operation = "TRANSFER_OUT"
+	OperationTransferIn  Operation = "TRANSFER_IN"
+)
+
+const (
+	EntryTransferOut EntryType = "TRANSFER_OUT"
+	EntryTransferIn  EntryType = "TRANSFER_IN"
+)
+
+// Quote locks an exchange rate for a limited time. A quote can be used once.
+type Quote struct {
+	ID           uuid.UUID
+	FromCurrency string
+	ToCurrency   string
+	Rate         *big.Rat // Rate is the price of one unit of FromCurrency in ToCurrency
+	ExpiresAt    time.Time
+}
+
+// Transfer moves funds between two wallets, converting them when currencies differ.
+type Transfer struct {
+	ID           uuid.UUID
+	FromWalletID uuid.UUID
+	ToWalletID   uuid.UUID
+	FromCurrency string
+	ToCurrency   string
+	QuoteID      *uuid.UUID // QuoteID is the quote the rate was locked with, nil for same-currency transfers
+	Rate         *big.Rat
+	DebitAmount  int64 // DebitAmount is taken from the source wallet, in FromCurrency
+	CreditAmount int64 // CreditAmount is added to the destination wallet, in ToCurrency
+	Fee          int64 // Fee is charged to the source wallet on top of DebitAmount
+}
@@ -1,8 +1,73 @@
 package wallet
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 var (
 	ErrWalletNotFound = errors.New("wallet not found")
 	ErrNotEnoughMoney = errors.New("not enough money")
+	ErrWalletFrozen   = errors.New("wallet is frozen")
+	ErrWalletExists   = errors.New("wallet already exists")
+
+	ErrVersionMismatch = errors.New("wallet changed since it was read")
+
+	ErrTooManyConflicts = errors.New("too many concurrent updates, try again later")
 )
+
+// Operation names a kind of wallet operation, e.g. for fee rules.
+type Operation string
+
+const (
+	OperationDeposit  Operation = "DEPOSIT"
+	OperationWithdraw Operation = "WITHDRAW"
+	OperationExpire   Operation = "EXPIRE" // OperationExpire records expired credit in the wallet history
+	OperationFee      Operation = "FEE"    // OperationFee records a fee in the history of the fee-income wallet
+)
+
+// Receipt describes the outcome of a committed operation.
+type Receipt struct {
+	TransactionID uuid.UUID // TransactionID is the id of the operation in the wallet history
+	Balance       int64     // Balance of the wallet after the operation and its fee
+	Fee           int64     // Fee charged on top of the operation amount
+	CreatedAt     time.Time
+}
+
+// EntryType classifies journal entries written for every balance change.
+type EntryType string
+
+const (
+	EntryOpening  EntryType = "OPENING"
+	EntryDeposit  EntryType = "DEPOSIT"
+	EntryWithdraw EntryType = "WITHDRAW"
+	EntryExpire   EntryType = "EXPIRE"
+	EntryFee      EntryType = "FEE"
+)
+
+// BucketMain holds real money. Deposits that do not name a bucket go here.
+const BucketMain = "main"
+
+// Balance is the wallet total together with its per-bucket breakdown.
+type Balance struct {
+	Total    int64
+	Buckets  map[string]int64
+	Metadata Metadata // Metadata of the wallet, set for current balances only
+	Version  int64    // Version counts the balance and metadata changes of the wallet, set for current balances only
+}
+
+// Credit describes funds deposited into a wallet bucket.
+type Credit struct {
+	Bucket    string
+	Amount    int64
+	ExpiresAt *time.Time // ExpiresAt makes the unspent part of the credit expire at the deadline
+}
+
+// Expiry is the unspent part of an expiring credit that was taken off a wallet.
+type Expiry struct {
+	WalletID uuid.UUID
+	Bucket   string
+	Amount   int64
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"wallet/internal/model/wallet"
 )
 
 type LRUCache interface {
@@ -21,17 +22,17 @@ func New(c LRUCache) *Cache {
 	}
 }
 
-func (c *Cache) Get(_ context.Context, key string) (int64, bool) {
+func (c *Cache) Get(_ context.Context, key string) (wallet.Balance, bool) {
 	val, ok := c.cache.Get(key)
 	if ok {
-		return val.(int64), true
+		return val.(wallet.Balance), true
 	}
 
-	return 0, false
+	return wallet.Balance{}, false
 }
 
-func (c *Cache) Set(_ context.Context, key string, amount int64) {
-	c.cache.Add(key, amount)
+func (c *Cache) Set(_ context.Context, key string, balance wallet.Balance) {
+	c.cache.Add(key, balance)
 }
 
 func (c *Cache) Delete(_ context.Context, key string) {
@@ -3,18 +3,19 @@ package cache_test
 import (
 	"github.com/stretchr/testify/require"
 	"testing"
+	"wallet/internal/model/wallet"
 	"wallet/internal/repository/cache"
 )
 
 type MockLRUCache struct {
-	data map[string]int64
+	data map[string]wallet.Balance
 }
 
 func (m *MockLRUCache) Add(key interface{}, value interface{}) (evicted bool) {
 	if m.data == nil {
-		m.data = make(map[string]int64)
+		m.data = make(map[string]wallet.Balance)
 	}
-	m.data[key.(string)] = value.(int64)
+	m.data[key.(string)] = value.(wallet.Balance)
 	return false
 }
 
@@ -37,15 +38,17 @@ func TestCache(t *testing.T) {
 	mockCache := &MockLRUCache{}
 	cache := cache.New(mockCache)
 
-	cache.Set(t.Context(), "wallet1", 1000)
+	expected := wallet.Balance{Total: 1000, Buckets: map[string]int64{wallet.BucketMain: 1000}}
+
+	cache.Set(t.Context(), "wallet1", expected)
 	value, ok := mockCache.Get("wallet1")
 	require.True(t, ok)
-	require.Equal(t, int64(1000), value)
+	require.Equal(t, expected, value)
 
 	// Тест для метода Get
 	balance, ok := cache.Get(t.Context(), "wallet1")
 	require.True(t, ok)
-	require.Equal(t, int64(1000), balance)
+	require.Equal(t, expected, balance)
 
 	// Тест для метода Delete
 	cache.Delete(t.Context(), "wallet1")
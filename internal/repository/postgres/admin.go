@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"wallet/internal/model/wallet"
+)
+
+const sqlStateUniqueViolation = "23505"
+
+// CreateWallet stores a new active wallet and returns it as stored, or ErrWalletExists
+// when a wallet with its id exists already.
+func (s *Storage) CreateWallet(ctx context.Context, w wallet.Wallet) (wallet.Wallet, error) {
+	query := `
+		INSERT INTO wallets (id, owner_id, currency, metadata)
+		VALUES ($1, $2, $3, COALESCE($4::JSONB, '{}'))
+		RETURNING id, owner_id, status, currency, balance, metadata, created_at;
+		`
+
+	var created wallet.Wallet
+	err := s.db.QueryRow(ctx, query, w.ID, w.OwnerID, w.Currency, w.Metadata).
+		Scan(&created.ID, &created.OwnerID, &created.Status, &created.Currency, &created.Balance, &created.Metadata, &created.CreatedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == sqlStateUniqueViolation {
+		return wallet.Wallet{}, wallet.ErrWalletExists
+	}
+	if err != nil {
+		return wallet.Wallet{}, err
+	}
+
+	return created, nil
+}
+
+// SetStatus freezes or unfreezes the wallet.
+func (s *Storage) SetStatus(ctx context.Context, walletID uuid.UUID, status wallet.Status) error {
+	query := `
+		UPDATE wallets
+		SET status = $2
+		WHERE id = $1;
+		`
+
+	tag, err := s.db.Exec(ctx, query, walletID, status)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return wallet.ErrWalletNotFound
+	}
+
+	return nil
+}
+
+// Adjust applies the adjustment to the wallet bucket, journals it as an ADJUSTMENT entry and
+// records it. It returns the updated wallet balance, or ErrNotEnoughMoney when a negative
+// adjustment would take the bucket below zero. A negative adjustment spends the expiring
+// credits of the bucket first, the same way a withdrawal does, so that they are not taken
+// off the wallet a second time when they expire; credits that already expired cannot be
+// adjusted away.
+func (s *Storage) Adjust(ctx context.Context, tx pgx.Tx, adj wallet.Adjustment) (int64, error) {
+	balance, err := s.updateBalance(ctx, tx, adj.WalletID, adj.Amount)
+	if err != nil {
+		return 0, err
+	}
+
+	if adj.Amount < 0 {
+		_, lots, err := s.planDebits(ctx, tx, adj.WalletID, -adj.Amount, []string{adj.Bucket}, nil)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, lot := range lots {
+			if err := s.debitLot(ctx, tx, lot.id, lot.amount); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	query := `
+		INSERT INTO wallet_buckets (wallet_id, name, balance)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (wallet_id, name) DO UPDATE
+		SET balance = wallet_buckets.balance + EXCLUDED.balance
+		RETURNING balance;
+		`
+
+	var bucketBalance int64
+	if err := tx.QueryRow(ctx, query, adj.WalletID, adj.Bucket, adj.Amount).Scan(&bucketBalance); err != nil {
+		return 0, err
+	}
+	if bucketBalance < 0 {
+		return 0, wallet.ErrNotEnoughMoney
+	}
+
+	if err := s.addEntry(ctx, tx, adj.WalletID, adj.Bucket, wallet.EntryAdjustment, adj.Amount); err != nil {
+		return 0, err
+	}
+
+	query = `
+		INSERT INTO adjustments (id, wallet_id, bucket, amount, reason, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6);
+		`
+
+	if _, err := tx.Exec(ctx, query, adj.ID, adj.WalletID, adj.Bucket, adj.Amount, adj.Reason, adj.CreatedBy); err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,249 @@
+package postgres_test
+
+import (
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+func TestStorage_CreateWallet(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	createdAt := time.Now()
+	w := wallet.Wallet{ID: walletID, OwnerID: ptr("u-1"), Currency: "USD", Metadata: wallet.Metadata{"tier": "gold"}}
+
+	tests := []struct {
+		name          string
+		insertErr     error
+		expected      wallet.Wallet
+		expectedError error
+	}{
+		{
+			name: "wallet created",
+			expected: wallet.Wallet{
+				ID:        walletID,
+				OwnerID:   w.OwnerID,
+				Status:    wallet.StatusActive,
+				Currency:  "USD",
+				Metadata:  w.Metadata,
+				CreatedAt: createdAt,
+			},
+		},
+		{
+			name:          "wallet exists",
+			insertErr:     &pgconn.PgError{Code: "23505"},
+			expectedError: wallet.ErrWalletExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			insert := mockPool.ExpectQuery(regexp.QuoteMeta(`INSERT INTO wallets (id, owner_id, currency, metadata)`)).
+				WithArgs(walletID, w.OwnerID, "USD", w.Metadata)
+			if tt.insertErr != nil {
+				insert.WillReturnError(tt.insertErr)
+			} else {
+				insert.WillReturnRows(pgxmock.NewRows([]string{"id", "owner_id", "status", "currency", "balance", "metadata", "created_at"}).
+					AddRow(walletID, w.OwnerID, wallet.StatusActive, "USD", int64(0), w.Metadata, createdAt))
+			}
+
+			created, err := storage.CreateWallet(t.Context(), w)
+			require.ErrorIs(t, err, tt.expectedError)
+			require.Equal(t, tt.expected, created)
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStorage_SetStatus(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+
+	tests := []struct {
+		name          string
+		rowsAffected  int64
+		expectedError error
+	}{
+		{
+			name:         "wallet frozen",
+			rowsAffected: 1,
+		},
+		{
+			name:          "wallet not found",
+			rowsAffected:  0,
+			expectedError: wallet.ErrWalletNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE wallets`)).
+				WithArgs(walletID, wallet.StatusFrozen).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.rowsAffected))
+
+			err = storage.SetStatus(t.Context(), walletID, wallet.StatusFrozen)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStorage_Adjust(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+
+	tests := []struct {
+		name            string
+		amount          int64
+		buckets         map[string]int64 // buckets and lots are locked by debit adjustments only
+		lots            []lotRow
+		expectedLots    []lotChange
+		bucketBalance   int64
+		expectedError   error
+		expectedBalance int64
+	}{
+		{
+			name:            "credit adjustment",
+			amount:          250,
+			bucketBalance:   250,
+			expectedBalance: 1250,
+		},
+		{
+			name:            "debit adjustment",
+			amount:          -100,
+			buckets:         map[string]int64{"bonus": 100},
+			bucketBalance:   0,
+			expectedBalance: 900,
+		},
+		{
+			// the spent credit is not taken off the wallet again when it expires
+			name:            "debit adjustment spends expiring credit",
+			amount:          -100,
+			buckets:         map[string]int64{"bonus": 150, wallet.BucketMain: 500},
+			lots:            []lotRow{{id: 1, bucket: "bonus", remaining: 60}, {id: 2, bucket: wallet.BucketMain, remaining: 500}},
+			expectedLots:    []lotChange{{1, 60}},
+			bucketBalance:   50,
+			expectedBalance: 900,
+		},
+		{
+			name:          "bucket goes negative",
+			amount:        -100,
+			buckets:       map[string]int64{"bonus": 80},
+			expectedError: wallet.ErrNotEnoughMoney,
+		},
+		{
+			name:          "expired credit cannot be adjusted away",
+			amount:        -100,
+			buckets:       map[string]int64{"bonus": 100},
+			lots:          []lotRow{{id: 1, bucket: "bonus", remaining: 100, expired: true}},
+			expectedError: wallet.ErrNotEnoughMoney,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			mockPool.ExpectBegin()
+			mockTx, err := mockPool.Begin(ctx)
+			require.NoError(t, err)
+
+			adj := wallet.Adjustment{
+				ID:        uuid.New(),
+				WalletID:  walletID,
+				Bucket:    "bonus",
+				Amount:    tt.amount,
+				Reason:    "goodwill",
+				CreatedBy: "ops",
+			}
+
+			mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+				WithArgs(tt.amount, walletID).
+				WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(1000 + tt.amount))
+
+			if tt.amount < 0 {
+				rows := pgxmock.NewRows([]string{"name", "balance"})
+				for name, balance := range tt.buckets {
+					rows.AddRow(name, balance)
+				}
+				mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_buckets`)).
+					WithArgs(walletID).
+					WillReturnRows(rows)
+
+				lots := pgxmock.NewRows([]string{"id", "bucket", "remaining", "expired"})
+				for _, l := range tt.lots {
+					lots.AddRow(l.id, l.bucket, l.remaining, l.expired)
+				}
+				mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_lots`)).
+					WithArgs(walletID).
+					WillReturnRows(lots)
+
+				for _, l := range tt.expectedLots {
+					mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE wallet_lots`)).
+						WithArgs(l.amount, l.id).
+						WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				}
+			}
+
+			if tt.expectedError == nil {
+				mockPool.ExpectQuery(regexp.QuoteMeta(`INSERT INTO wallet_buckets`)).
+					WithArgs(walletID, "bonus", tt.amount).
+					WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(tt.bucketBalance))
+				mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_entries`)).
+					WithArgs(walletID, "bonus", wallet.EntryAdjustment, tt.amount).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO adjustments`)).
+					WithArgs(adj.ID, walletID, "bonus", tt.amount, "goodwill", "ops").
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			}
+
+			balance, err := storage.Adjust(ctx, mockTx, adj)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.expectedBalance, balance)
+			}
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
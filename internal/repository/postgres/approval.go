@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"wallet/internal/model/wallet"
+)
+
+const adjustmentRequestColumns = `id, wallet_id, bucket, amount, reason, requested_by, requested_at, status,
+	COALESCE(decided_by, ''), decided_at, note`
+
+// ProposeAdjustment stores a pending adjustment request.
+func (s *Storage) ProposeAdjustment(ctx context.Context, tx pgx.Tx, req wallet.AdjustmentRequest) error {
+	query := `
+		INSERT INTO adjustment_requests (id, wallet_id, bucket, amount, reason, requested_by, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
+		`
+
+	_, err := tx.Exec(ctx, query, req.ID, req.WalletID, req.Bucket, req.Amount, req.Reason, req.CreatedBy, wallet.AdjustmentPending)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == sqlStateForeignKeyViolation {
+		return wallet.ErrWalletNotFound
+	}
+
+	return err
+}
+
+// DecideAdjustment moves a pending request to status on behalf of principal and returns it.
+// The proposer of a request cannot decide it.
+func (s *Storage) DecideAdjustment(ctx context.Context, tx pgx.Tx, requestID uuid.UUID, status wallet.AdjustmentStatus, principal, note string) (wallet.AdjustmentRequest, error) {
+	query := `
+		UPDATE adjustment_requests
+		SET status = $2, decided_by = $3, decided_at = now(), note = $4
+		WHERE id = $1 AND status = $5 AND requested_by <> $3
+		RETURNING ` + adjustmentRequestColumns + `;
+		`
+
+	rows, err := tx.Query(ctx, query, requestID, status, principal, note, wallet.AdjustmentPending)
+	if err != nil {
+		return wallet.AdjustmentRequest{}, err
+	}
+
+	req, err := pgx.CollectOneRow(rows, scanAdjustmentRequest)
+	if err == nil {
+		return req, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return wallet.AdjustmentRequest{}, err
+	}
+
+	var current wallet.AdjustmentStatus
+	err = tx.QueryRow(ctx, `SELECT status FROM adjustment_requests WHERE id = $1;`, requestID).Scan(&current)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return wallet.AdjustmentRequest{}, wallet.ErrAdjustmentNotFound
+	case err != nil:
+		return wallet.AdjustmentRequest{}, err
+	case current != wallet.AdjustmentPending:
+		return wallet.AdjustmentRequest{}, wallet.ErrAdjustmentDecided
+	default:
+		return wallet.AdjustmentRequest{}, wallet.ErrAdjustmentSelfCheck
+	}
+}
+
+// RecordAdjustmentEvent appends a step to the audit trail of an adjustment request.
+func (s *Storage) RecordAdjustmentEvent(ctx context.Context, tx pgx.Tx, event wallet.AdjustmentEvent) error {
+	query := `
+		INSERT INTO adjustment_events (request_id, action, principal, note)
+		VALUES ($1, $2, $3, $4);
+		`
+
+	_, err := tx.Exec(ctx, query, event.RequestID, event.Action, event.Principal, event.Note)
+	return err
+}
+
+// AdjustmentRequests returns up to limit adjustment requests, newest first. An empty status
+// selects requests in any status.
+func (s *Storage) AdjustmentRequests(ctx context.Context, status wallet.AdjustmentStatus, limit int) ([]wallet.AdjustmentRequest, error) {
+	query := `
+		SELECT ` + adjustmentRequestColumns + `
+		FROM adjustment_requests
+		WHERE $1 = '' OR status = $1
+		ORDER BY requested_at DESC, id
+		LIMIT $2;
+		`
+
+	rows, err := s.db.Query(ctx, query, status, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanAdjustmentRequest)
+}
+
+// AdjustmentRequest returns the adjustment request with its audit trail, oldest step first.
+func (s *Storage) AdjustmentRequest(ctx context.Context, requestID uuid.UUID) (wallet.AdjustmentRequest, []wallet.AdjustmentEvent, error) {
+	query := `
+		SELECT ` + adjustmentRequestColumns + `
+		FROM adjustment_requests
+		WHERE id = $1;
+		`
+
+	rows, err := s.db.Query(ctx, query, requestID)
+	if err != nil {
+		return wallet.AdjustmentRequest{}, nil, err
+	}
+
+	req, err := pgx.CollectOneRow(rows, scanAdjustmentRequest)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return wallet.AdjustmentRequest{}, nil, wallet.ErrAdjustmentNotFound
+		}
+		return wallet.AdjustmentRequest{}, nil, err
+	}
+
+	query = `
+		SELECT request_id, action, principal, note, created_at
+		FROM adjustment_events
+		WHERE request_id = $1
+		ORDER BY id;
+		`
+
+	rows, err = s.db.Query(ctx, query, requestID)
+	if err != nil {
+		return wallet.AdjustmentRequest{}, nil, err
+	}
+
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (wallet.AdjustmentEvent, error) {
+		var e wallet.AdjustmentEvent
+		err := row.Scan(&e.RequestID, &e.Action, &e.Principal, &e.Note, &e.CreatedAt)
+		return e, err
+	})
+	if err != nil {
+		return wallet.AdjustmentRequest{}, nil, err
+	}
+
+	return req, events, nil
+}
+
+func scanAdjustmentRequest(row pgx.CollectableRow) (wallet.AdjustmentRequest, error) {
+	var req wallet.AdjustmentRequest
+	err := row.Scan(&req.ID, &req.WalletID, &req.Bucket, &req.Amount, &req.Reason, &req.CreatedBy, &req.CreatedAt,
+		&req.Status, &req.DecidedBy, &req.DecidedAt, &req.Note)
+	return req, err
+}
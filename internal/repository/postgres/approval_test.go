@@ -0,0 +1,164 @@
+package postgres_test
+
+import (
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+var adjustmentRequestRow = []string{"id", "wallet_id", "bucket", "amount", "reason", "requested_by", "requested_at",
+	"status", "decided_by", "decided_at", "note"}
+
+func TestStorage_DecideAdjustment(t *testing.T) {
+	t.Parallel()
+
+	requestID := uuid.New()
+	walletID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		decided       bool
+		currentStatus *wallet.AdjustmentStatus
+		expectedError error
+	}{
+		{
+			name:    "request approved",
+			decided: true,
+		},
+		{
+			name:          "request not found",
+			expectedError: wallet.ErrAdjustmentNotFound,
+		},
+		{
+			name:          "request already decided",
+			currentStatus: ptr(wallet.AdjustmentRejected),
+			expectedError: wallet.ErrAdjustmentDecided,
+		},
+		{
+			name:          "proposer decides",
+			currentStatus: ptr(wallet.AdjustmentPending),
+			expectedError: wallet.ErrAdjustmentSelfCheck,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			mockPool.ExpectBegin()
+			mockTx, err := mockPool.Begin(ctx)
+			require.NoError(t, err)
+
+			rows := pgxmock.NewRows(adjustmentRequestRow)
+			if tt.decided {
+				rows.AddRow(requestID, walletID, wallet.BucketMain, int64(-30), "duplicate deposit", "alice", now,
+					wallet.AdjustmentApproved, "bob", &now, "checked")
+			}
+			mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE adjustment_requests`)).
+				WithArgs(requestID, wallet.AdjustmentApproved, "bob", "checked", wallet.AdjustmentPending).
+				WillReturnRows(rows)
+
+			if !tt.decided {
+				status := mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT status FROM adjustment_requests`)).
+					WithArgs(requestID)
+				if tt.currentStatus != nil {
+					status.WillReturnRows(pgxmock.NewRows([]string{"status"}).AddRow(*tt.currentStatus))
+				} else {
+					status.WillReturnError(pgx.ErrNoRows)
+				}
+			}
+
+			req, err := storage.DecideAdjustment(ctx, mockTx, requestID, wallet.AdjustmentApproved, "bob", "checked")
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, wallet.AdjustmentRequest{
+					Adjustment: wallet.Adjustment{
+						ID:        requestID,
+						WalletID:  walletID,
+						Bucket:    wallet.BucketMain,
+						Amount:    -30,
+						Reason:    "duplicate deposit",
+						CreatedBy: "alice",
+						CreatedAt: now,
+					},
+					Status:    wallet.AdjustmentApproved,
+					DecidedBy: "bob",
+					DecidedAt: &now,
+					Note:      "checked",
+				}, req)
+			}
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStorage_AdjustmentRequest(t *testing.T) {
+	t.Parallel()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	requestID := uuid.New()
+	now := time.Now()
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM adjustment_requests`)).
+		WithArgs(requestID).
+		WillReturnRows(pgxmock.NewRows(adjustmentRequestRow).
+			AddRow(requestID, uuid.New(), wallet.BucketMain, int64(500), "goodwill", "alice", now,
+				wallet.AdjustmentPending, "", (*time.Time)(nil), ""))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM adjustment_events`)).
+		WithArgs(requestID).
+		WillReturnRows(pgxmock.NewRows([]string{"request_id", "action", "principal", "note", "created_at"}).
+			AddRow(requestID, wallet.AdjustmentPropose, "alice", "goodwill", now))
+
+	req, events, err := storage.AdjustmentRequest(t.Context(), requestID)
+	require.NoError(t, err)
+	require.Equal(t, wallet.AdjustmentPending, req.Status)
+	require.Nil(t, req.DecidedAt)
+	require.Equal(t, []wallet.AdjustmentEvent{
+		{RequestID: requestID, Action: wallet.AdjustmentPropose, Principal: "alice", Note: "goodwill", CreatedAt: now},
+	}, events)
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestStorage_AdjustmentRequests(t *testing.T) {
+	t.Parallel()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`WHERE $1 = '' OR status = $1`)).
+		WithArgs(wallet.AdjustmentPending, 100).
+		WillReturnRows(pgxmock.NewRows(adjustmentRequestRow).
+			AddRow(uuid.New(), uuid.New(), wallet.BucketMain, int64(500), "goodwill", "alice", time.Now(),
+				wallet.AdjustmentPending, "", (*time.Time)(nil), ""))
+
+	reqs, err := storage.AdjustmentRequests(t.Context(), wallet.AdjustmentPending, 100)
+	require.NoError(t, err)
+	require.Len(t, reqs, 1)
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
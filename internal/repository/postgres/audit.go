@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"wallet/internal/model/wallet"
+)
+
+// auditLockKey is the advisory lock serializing appends to the audit log chain.
+const auditLockKey = 7_331_001
+
+// AppendAudit appends the record chain builds from the sequence number and hash of the last
+// record, zero and empty when the log is empty. Appends hold an advisory lock and run at read
+// committed, so the last record is read after the lock is taken and concurrent appends always
+// chain to each other.
+func (s *Storage) AppendAudit(ctx context.Context, chain func(lastSeq int64, lastHash string) wallet.AuditRecord) error {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1);`, auditLockKey); err != nil {
+		return err
+	}
+
+	query := `
+		SELECT seq, hash
+		FROM audit_log
+		ORDER BY seq DESC
+		LIMIT 1;
+		`
+
+	var (
+		seq  int64
+		hash string
+	)
+	err = tx.QueryRow(ctx, query).Scan(&seq, &hash)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	rec := chain(seq, hash)
+
+	query = `
+		INSERT INTO audit_log (seq, created_at, principal, remote_addr, request_id, operation, wallet_id,
+			resource, amount, status, outcome, prev_hash, hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13);
+		`
+
+	_, err = tx.Exec(ctx, query, rec.Seq, rec.CreatedAt, rec.Principal, rec.RemoteAddr, rec.RequestID, rec.Operation,
+		rec.WalletID, rec.Resource, rec.Amount, rec.Status, rec.Outcome, rec.PrevHash, rec.Hash)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AuditRecords returns up to limit audit records following afterSeq, in sequence order.
+func (s *Storage) AuditRecords(ctx context.Context, afterSeq int64, limit int) ([]wallet.AuditRecord, error) {
+	query := `
+		SELECT seq, created_at, principal, remote_addr, request_id, operation, wallet_id,
+			resource, amount, status, outcome, prev_hash, hash
+		FROM audit_log
+		WHERE seq > $1
+		ORDER BY seq
+		LIMIT $2;
+		`
+
+	rows, err := s.db.Query(ctx, query, afterSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (wallet.AuditRecord, error) {
+		var rec wallet.AuditRecord
+		err := row.Scan(&rec.Seq, &rec.CreatedAt, &rec.Principal, &rec.RemoteAddr, &rec.RequestID, &rec.Operation,
+			&rec.WalletID, &rec.Resource, &rec.Amount, &rec.Status, &rec.Outcome, &rec.PrevHash, &rec.Hash)
+		return rec, err
+	})
+}
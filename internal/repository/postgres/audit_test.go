@@ -0,0 +1,125 @@
+package postgres_test
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+func TestStorage_AppendAudit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		empty        bool
+		insertErr    error
+		expectedSeq  int64
+		expectedHash string
+	}{
+		{
+			name:         "log with records",
+			expectedSeq:  42,
+			expectedHash: "abc",
+		},
+		{
+			name:  "empty log",
+			empty: true,
+		},
+		{
+			name:         "insert failure",
+			insertErr:    errors.New("duplicate key"),
+			expectedSeq:  42,
+			expectedHash: "abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			// read committed, so that the last record is read after the lock is taken
+			mockPool.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+			mockPool.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1);`)).
+				WithArgs(pgxmock.AnyArg()).
+				WillReturnResult(pgxmock.NewResult("SELECT", 1))
+			last := mockPool.ExpectQuery(regexp.QuoteMeta(`FROM audit_log`))
+			if tt.empty {
+				last.WillReturnError(pgx.ErrNoRows)
+			} else {
+				last.WillReturnRows(pgxmock.NewRows([]string{"seq", "hash"}).AddRow(tt.expectedSeq, tt.expectedHash))
+			}
+
+			rec := wallet.AuditRecord{Seq: tt.expectedSeq + 1, Operation: "FreezeWallet", Status: 204, Outcome: wallet.AuditSucceeded, PrevHash: "prev", Hash: "next"}
+			insert := mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+				WithArgs(rec.Seq, rec.CreatedAt, rec.Principal, rec.RemoteAddr, rec.RequestID, rec.Operation,
+					rec.WalletID, rec.Resource, rec.Amount, rec.Status, rec.Outcome, rec.PrevHash, rec.Hash)
+			if tt.insertErr != nil {
+				insert.WillReturnError(tt.insertErr)
+				mockPool.ExpectRollback()
+			} else {
+				insert.WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				mockPool.ExpectCommit()
+			}
+
+			err = storage.AppendAudit(t.Context(), func(seq int64, hash string) wallet.AuditRecord {
+				require.Equal(t, tt.expectedSeq, seq)
+				require.Equal(t, tt.expectedHash, hash)
+				return rec
+			})
+			require.ErrorIs(t, err, tt.insertErr)
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStorage_AuditRecords(t *testing.T) {
+	t.Parallel()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	walletID := uuid.New()
+	now := time.Now()
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM audit_log`)).
+		WithArgs(int64(10), 2).
+		WillReturnRows(pgxmock.NewRows([]string{"seq", "created_at", "principal", "remote_addr", "request_id",
+			"operation", "wallet_id", "resource", "amount", "status", "outcome", "prev_hash", "hash"}).
+			AddRow(int64(11), now, "", "10.0.0.1:5000", "req-1", "WalletOperation", &walletID, "", ptr(int64(100)),
+				200, wallet.AuditSucceeded, "h10", "h11").
+			AddRow(int64(12), now, "alice", "10.0.0.2:5000", "req-2", "ApproveAdjustment", (*uuid.UUID)(nil),
+				"7c9e6679-7425-40de-944b-e07fc1f90ae7", (*int64)(nil), 200, wallet.AuditOutcome(""), "h11", "h12"))
+
+	recs, err := storage.AuditRecords(t.Context(), 10, 2)
+	require.NoError(t, err)
+	require.Equal(t, []wallet.AuditRecord{
+		{
+			Seq: 11, CreatedAt: now, RemoteAddr: "10.0.0.1:5000", RequestID: "req-1", Operation: "WalletOperation",
+			WalletID: &walletID, Amount: ptr(int64(100)), Status: 200, Outcome: wallet.AuditSucceeded, PrevHash: "h10", Hash: "h11",
+		},
+		{
+			Seq: 12, CreatedAt: now, Principal: "alice", RemoteAddr: "10.0.0.2:5000", RequestID: "req-2",
+			Operation: "ApproveAdjustment", Resource: "7c9e6679-7425-40de-944b-e07fc1f90ae7", Status: 200,
+			PrevHash: "h11", Hash: "h12",
+		},
+	}, recs)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"wallet/internal/model/wallet"
+)
+
+// eventsChannel is notified with every balance event when its transaction commits.
+const eventsChannel = "wallet_events"
+
+// BalanceEvents returns up to limit balance events of the wallet following afterVersion, oldest first.
+func (s *Storage) BalanceEvents(ctx context.Context, walletID uuid.UUID, afterVersion int64, limit int) ([]wallet.BalanceEvent, error) {
+	query := `
+		SELECT wallet_id, version, balance, delta, created_at
+		FROM wallet_events
+		WHERE wallet_id = $1 AND version > $2
+		ORDER BY version
+		LIMIT $3;
+		`
+
+	rows, err := s.db.Query(ctx, query, walletID, afterVersion, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowToStructByPos[wallet.BalanceEvent])
+}
+
+// Listener receives balance events on a connection of its own.
+type Listener struct {
+	pool *pgxpool.Pool
+}
+
+func NewListener(pool *pgxpool.Pool) *Listener {
+	return &Listener{pool: pool}
+}
+
+// Listen calls fn with every balance event committed while it runs. It holds a pool
+// connection until ctx is done or the connection fails.
+func (l *Listener) Listen(ctx context.Context, fn func(wallet.BalanceEvent)) error {
+	pooled, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// the connection stays subscribed, so it is closed instead of returned to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+eventsChannel); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var ev wallet.BalanceEvent
+		if err := json.Unmarshal([]byte(n.Payload), &ev); err != nil {
+			return fmt.Errorf("invalid balance event %q: %w", n.Payload, err)
+		}
+		fn(ev)
+	}
+}
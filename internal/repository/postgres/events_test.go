@@ -0,0 +1,40 @@
+package postgres_test
+
+import (
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+func TestStorage_BalanceEvents(t *testing.T) {
+	t.Parallel()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	walletID := uuid.New()
+	now := time.Now()
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_events`)).
+		WithArgs(walletID, int64(3), 100).
+		WillReturnRows(pgxmock.NewRows([]string{"wallet_id", "version", "balance", "delta", "created_at"}).
+			AddRow(walletID, int64(4), int64(150), int64(50), now).
+			AddRow(walletID, int64(5), int64(120), int64(-30), now))
+
+	events, err := storage.BalanceEvents(t.Context(), walletID, 3, 100)
+	require.NoError(t, err)
+	require.Equal(t, []wallet.BalanceEvent{
+		{WalletID: walletID, Version: 4, Balance: 150, Delta: 50, CreatedAt: now},
+		{WalletID: walletID, Version: 5, Balance: 120, Delta: -30, CreatedAt: now},
+	}, events)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"time"
+	"wallet/internal/model/wallet"
+)
+
+// querier is satisfied by both the pool and a transaction.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// BalanceAt returns the wallet balance made of journal entries before at. It starts from
+// the latest snapshot taken at or before at, so only entries made since are summed.
+func (s *Storage) BalanceAt(ctx context.Context, walletID uuid.UUID, at time.Time) (wallet.Balance, error) {
+	return balanceAt(ctx, s.db, walletID, at)
+}
+
+func balanceAt(ctx context.Context, q querier, walletID uuid.UUID, at time.Time) (wallet.Balance, error) {
+	query := `
+		WITH snapshot AS (
+			SELECT bucket, balance, taken_at
+			FROM wallet_balance_snapshots
+			WHERE wallet_id = $1 AND taken_at = (
+				SELECT max(taken_at)
+				FROM wallet_balance_snapshots
+				WHERE wallet_id = $1 AND taken_at <= $2
+			)
+		)
+		SELECT bucket, SUM(amount)::BIGINT
+		FROM (
+			SELECT bucket, balance AS amount
+			FROM snapshot
+			UNION ALL
+			SELECT bucket, amount
+			FROM wallet_entries
+			WHERE wallet_id = $1
+				AND created_at < $2
+				AND created_at >= COALESCE((SELECT max(taken_at) FROM snapshot), '-infinity')
+		) changes
+		GROUP BY bucket;
+		`
+
+	rows, err := q.Query(ctx, query, walletID, at)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+	defer rows.Close()
+
+	balance := wallet.Balance{Buckets: make(map[string]int64)}
+	for rows.Next() {
+		var (
+			bucket string
+			amount int64
+		)
+		if err := rows.Scan(&bucket, &amount); err != nil {
+			return wallet.Balance{}, err
+		}
+
+		balance.Buckets[bucket] = amount
+		balance.Total += amount
+	}
+	if err := rows.Err(); err != nil {
+		return wallet.Balance{}, err
+	}
+
+	if len(balance.Buckets) == 0 {
+		// no history yet, tell an empty wallet from a missing one
+		var exists bool
+		if err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM wallets WHERE id = $1);`, walletID).Scan(&exists); err != nil {
+			return wallet.Balance{}, err
+		}
+		if !exists {
+			return wallet.Balance{}, wallet.ErrWalletNotFound
+		}
+	}
+
+	return balance, nil
+}
+
+// Statement calls opening with the wallet balance at from, then entry with every journal entry
+// made in [from, to), oldest first. Both are read from one snapshot, so the opening balance plus
+// the entries always add up. Rows are read as entry consumes them, so a slow consumer keeps the
+// read-only transaction open.
+func (s *Storage) Statement(ctx context.Context, walletID uuid.UUID, from, to time.Time, opening func(balance int64) error, entry func(e wallet.Entry) error) error {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // read-only, nothing to commit
+
+	balance, err := balanceAt(ctx, tx, walletID, from)
+	if err != nil {
+		return err
+	}
+
+	if err := opening(balance.Total); err != nil {
+		return err
+	}
+
+	query := `
+		SELECT id, type, bucket, amount, created_at
+		FROM wallet_entries
+		WHERE wallet_id = $1 AND created_at >= $2 AND created_at < $3
+		ORDER BY created_at, id;
+		`
+
+	rows, err := tx.Query(ctx, query, walletID, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e wallet.Entry
+		if err := rows.Scan(&e.ID, &e.Type, &e.Bucket, &e.Amount, &e.CreatedAt); err != nil {
+			return err
+		}
+
+		if err := entry(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// TakeSnapshot stores the bucket balances at the given time of every wallet with journal
+// entries since the previous snapshot, and returns the number of rows stored. Taking the
+// same snapshot again does nothing.
+//
+// Snapshots must not be taken later than the start of the current UTC day. Interest is the
+// only backdated entry: it is dated at the start of the day following the accrued one, and
+// PostInterest carries it into the snapshots taken after that when accrual catches up.
+func (s *Storage) TakeSnapshot(ctx context.Context, at time.Time) (int64, error) {
+	query := `
+		WITH since AS (
+			SELECT COALESCE(max(taken_at), '-infinity') AS taken_at
+			FROM wallet_balance_snapshots
+			WHERE taken_at < $1
+		),
+		changed AS (
+			SELECT DISTINCT wallet_id
+			FROM wallet_entries
+			WHERE created_at >= (SELECT taken_at FROM since) AND created_at < $1
+		),
+		previous AS (
+			SELECT s.wallet_id, s.bucket, s.balance
+			FROM wallet_balance_snapshots s
+			JOIN (
+				SELECT wallet_id, max(taken_at) AS taken_at
+				FROM wallet_balance_snapshots
+				WHERE wallet_id IN (SELECT wallet_id FROM changed) AND taken_at < $1
+				GROUP BY wallet_id
+			) latest ON latest.wallet_id = s.wallet_id AND latest.taken_at = s.taken_at
+		)
+		INSERT INTO wallet_balance_snapshots (wallet_id, taken_at, bucket, balance)
+		SELECT wallet_id, $1, bucket, SUM(amount)
+		FROM (
+			SELECT wallet_id, bucket, balance AS amount
+			FROM previous
+			UNION ALL
+			SELECT e.wallet_id, e.bucket, e.amount
+			FROM wallet_entries e
+			JOIN changed c ON c.wallet_id = e.wallet_id
+			WHERE e.created_at >= (SELECT taken_at FROM since) AND e.created_at < $1
+		) changes
+		GROUP BY wallet_id, bucket
+		ON CONFLICT (wallet_id, taken_at, bucket) DO NOTHING;
+		`
+
+	tag, err := s.db.Exec(ctx, query, at)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
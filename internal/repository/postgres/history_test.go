@@ -0,0 +1,191 @@
+package postgres_test
+
+import (
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+func TestStorage_BalanceAt(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	at := time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		rows            *pgxmock.Rows
+		exists          *bool
+		expectedBalance wallet.Balance
+		expectedError   error
+	}{
+		{
+			name: "balance with buckets",
+			rows: pgxmock.NewRows([]string{"bucket", "sum"}).
+				AddRow(wallet.BucketMain, int64(800)).
+				AddRow("bonus", int64(200)),
+			expectedBalance: wallet.Balance{Total: 1000, Buckets: map[string]int64{wallet.BucketMain: 800, "bonus": 200}},
+		},
+		{
+			name:            "no history yet",
+			rows:            pgxmock.NewRows([]string{"bucket", "sum"}),
+			exists:          ptr(true),
+			expectedBalance: wallet.Balance{Buckets: map[string]int64{}},
+		},
+		{
+			name:          "wallet not found",
+			rows:          pgxmock.NewRows([]string{"bucket", "sum"}),
+			exists:        ptr(false),
+			expectedError: wallet.ErrWalletNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_balance_snapshots`)).
+				WithArgs(walletID, at).
+				WillReturnRows(tt.rows)
+			if tt.exists != nil {
+				mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+					WithArgs(walletID).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(*tt.exists))
+			}
+
+			balance, err := storage.BalanceAt(t.Context(), walletID, at)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.expectedBalance, balance)
+			}
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStorage_TakeSnapshot(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_balance_snapshots`)).
+		WithArgs(at).
+		WillReturnResult(pgxmock.NewResult("INSERT", 3))
+
+	rows, err := storage.TakeSnapshot(t.Context(), at)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), rows)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestStorage_Statement(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		openingRows     *pgxmock.Rows
+		exists          *bool
+		expectedOpening *int64
+		expectedEntries []wallet.Entry
+		expectedError   error
+	}{
+		{
+			name:            "opening balance and entries",
+			openingRows:     pgxmock.NewRows([]string{"bucket", "sum"}).AddRow(wallet.BucketMain, int64(500)),
+			expectedOpening: ptr(int64(500)),
+			expectedEntries: []wallet.Entry{
+				{ID: 7, Type: wallet.EntryDeposit, Bucket: wallet.BucketMain, Amount: 100, CreatedAt: createdAt},
+				{ID: 8, Type: wallet.EntryWithdraw, Bucket: wallet.BucketMain, Amount: -40, CreatedAt: createdAt},
+			},
+		},
+		{
+			name:          "wallet not found",
+			openingRows:   pgxmock.NewRows([]string{"bucket", "sum"}),
+			exists:        ptr(false),
+			expectedError: wallet.ErrWalletNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			mockPool.ExpectBeginTx(pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+			mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_balance_snapshots`)).
+				WithArgs(walletID, from).
+				WillReturnRows(tt.openingRows)
+			if tt.exists != nil {
+				mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+					WithArgs(walletID).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(*tt.exists))
+			}
+			if tt.expectedError == nil {
+				rows := pgxmock.NewRows([]string{"id", "type", "bucket", "amount", "created_at"})
+				for _, e := range tt.expectedEntries {
+					rows.AddRow(e.ID, e.Type, e.Bucket, e.Amount, e.CreatedAt)
+				}
+				mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_entries`)).
+					WithArgs(walletID, from, to).
+					WillReturnRows(rows)
+			}
+			mockPool.ExpectRollback()
+
+			var (
+				opening *int64
+				entries []wallet.Entry
+			)
+			err = storage.Statement(t.Context(), walletID, from, to,
+				func(balance int64) error {
+					opening = &balance
+					return nil
+				},
+				func(e wallet.Entry) error {
+					entries = append(entries, e)
+					return nil
+				},
+			)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectedOpening, opening)
+			require.Equal(t, tt.expectedEntries, entries)
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
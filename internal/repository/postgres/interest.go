@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"math/big"
+	"time"
+	"wallet/internal/model/wallet"
+)
+
+// InterestAccounts returns wallets enrolled in an interest product with their last accrual day.
+func (s *Storage) InterestAccounts(ctx context.Context) ([]wallet.InterestAccount, error) {
+	query := `
+		SELECT w.id, w.interest_product, MAX(a.accrual_date)
+		FROM wallets w
+		LEFT JOIN interest_accruals a ON a.wallet_id = w.id
+		WHERE w.interest_product IS NOT NULL
+		GROUP BY w.id
+		ORDER BY w.id;
+		`
+
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (wallet.InterestAccount, error) {
+		var a wallet.InterestAccount
+		err := row.Scan(&a.WalletID, &a.Product, &a.LastAccrued)
+		return a, err
+	})
+}
+
+// BucketBalanceAt sums the bucket's journal entries made before at.
+func (s *Storage) BucketBalanceAt(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, bucket string, at time.Time) (int64, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM wallet_entries
+		WHERE wallet_id = $1 AND bucket = $2 AND created_at < $3;
+		`
+
+	var balance int64
+	err := tx.QueryRow(ctx, query, walletID, bucket, at).Scan(&balance)
+	return balance, err
+}
+
+// AccruedInterest sums the exact interest accrued by the wallet on days in [from, to).
+func (s *Storage) AccruedInterest(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, from, to time.Time) (*big.Rat, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)::TEXT
+		FROM interest_accruals
+		WHERE wallet_id = $1 AND accrual_date >= $2 AND accrual_date < $3;
+		`
+
+	var sum string
+	if err := tx.QueryRow(ctx, query, walletID, from, to).Scan(&sum); err != nil {
+		return nil, err
+	}
+
+	amount, ok := new(big.Rat).SetString(sum)
+	if !ok {
+		return nil, fmt.Errorf("invalid accrued interest %q", sum)
+	}
+
+	return amount, nil
+}
+
+// RecordAccrual stores the accrual. It returns false when the wallet already accrued for the day.
+func (s *Storage) RecordAccrual(ctx context.Context, tx pgx.Tx, a wallet.Accrual) (bool, error) {
+	query := `
+		INSERT INTO interest_accruals (wallet_id, accrual_date, product, balance, amount, posted_amount)
+		VALUES ($1, $2, $3, $4, $5::NUMERIC, $6)
+		ON CONFLICT (wallet_id, accrual_date) DO NOTHING;
+		`
+
+	tag, err := tx.Exec(ctx, query, a.WalletID, a.Date, a.Product, a.Balance, a.Amount.FloatString(decimalScale), a.Posted)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// PostInterest moves interest from the main bucket of the funding wallet to the main
+// bucket of the wallet. Both entries are journaled at the given time, which may lie before
+// balance snapshots already taken when accrual catches up on missed days; those snapshots
+// are corrected with the entries. The funding wallet cannot go negative: interest it cannot
+// pay fails with wallet.ErrNotEnoughMoney.
+func (s *Storage) PostInterest(ctx context.Context, tx pgx.Tx, walletID, fundingWalletID uuid.UUID, amount int64, at time.Time) error {
+	funds, err := s.updateBalance(ctx, tx, fundingWalletID, -amount)
+	if err != nil {
+		if errors.Is(err, wallet.ErrWalletNotFound) {
+			return fmt.Errorf("funding wallet %s does not exist", fundingWalletID)
+		}
+		return err
+	}
+
+	if funds < 0 {
+		return fmt.Errorf("%w: funding wallet %s", wallet.ErrNotEnoughMoney, fundingWalletID)
+	}
+
+	if err := s.updateBucket(ctx, tx, fundingWalletID, wallet.BucketMain, -amount); err != nil {
+		return err
+	}
+
+	if err := s.addEntryAt(ctx, tx, fundingWalletID, wallet.BucketMain, wallet.EntryInterest, -amount, at); err != nil {
+		return err
+	}
+
+	if err := s.carryIntoSnapshots(ctx, tx, fundingWalletID, wallet.BucketMain, -amount, at); err != nil {
+		return err
+	}
+
+	if _, err := s.updateBalance(ctx, tx, walletID, amount); err != nil {
+		return err
+	}
+
+	if err := s.updateBucket(ctx, tx, walletID, wallet.BucketMain, amount); err != nil {
+		return err
+	}
+
+	if err := s.addEntryAt(ctx, tx, walletID, wallet.BucketMain, wallet.EntryInterest, amount, at); err != nil {
+		return err
+	}
+
+	return s.carryIntoSnapshots(ctx, tx, walletID, wallet.BucketMain, amount, at)
+}
+
+// carryIntoSnapshots adds a balance change journaled at the given time to the wallet's
+// snapshots taken after it, which were computed without it. Snapshots the wallet has no
+// row for the bucket in get one.
+func (s *Storage) carryIntoSnapshots(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, bucket string, amount int64, at time.Time) error {
+	query := `
+		INSERT INTO wallet_balance_snapshots (wallet_id, taken_at, bucket, balance)
+		SELECT DISTINCT wallet_id, taken_at, $2, $3::BIGINT
+		FROM wallet_balance_snapshots
+		WHERE wallet_id = $1 AND taken_at > $4
+		ON CONFLICT (wallet_id, taken_at, bucket)
+			DO UPDATE SET balance = wallet_balance_snapshots.balance + EXCLUDED.balance;
+		`
+
+	_, err := tx.Exec(ctx, query, walletID, bucket, amount, at)
+	return err
+}
+
+// addEntryAt journals a balance change that takes effect at the given time.
+func (s *Storage) addEntryAt(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, bucket string, entryType wallet.EntryType, amount int64, at time.Time) error {
+	query := `
+		INSERT INTO wallet_entries (wallet_id, bucket, type, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5);
+		`
+
+	_, err := tx.Exec(ctx, query, walletID, bucket, entryType, amount, at)
+	return err
+}
@@ -0,0 +1,163 @@
+package postgres_test
+
+import (
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"regexp"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+func TestStorage_RecordAccrual(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	accrual := wallet.Accrual{
+		WalletID: uuid.New(),
+		Date:     time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+		Product:  "savings",
+		Balance:  1000,
+		Amount:   big.NewRat(1, 8),
+	}
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectBegin()
+	mockTx, err := mockPool.Begin(ctx)
+	require.NoError(t, err)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_entries`)).
+		WithArgs(accrual.WalletID, wallet.BucketMain, accrual.Date.AddDate(0, 0, 1)).
+		WillReturnRows(pgxmock.NewRows([]string{"sum"}).AddRow(int64(1000)))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM interest_accruals`)).
+		WithArgs(accrual.WalletID, accrual.Date.AddDate(0, 0, -9), accrual.Date).
+		WillReturnRows(pgxmock.NewRows([]string{"sum"}).AddRow("1.125000000000000000"))
+	for _, inserted := range []int64{1, 0} {
+		mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO interest_accruals`)).
+			WithArgs(accrual.WalletID, accrual.Date, "savings", int64(1000), "0.125000000000000000", int64(0)).
+			WillReturnResult(pgxmock.NewResult("INSERT", inserted))
+	}
+
+	balance, err := storage.BucketBalanceAt(ctx, mockTx, accrual.WalletID, wallet.BucketMain, accrual.Date.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), balance)
+
+	accrued, err := storage.AccruedInterest(ctx, mockTx, accrual.WalletID, accrual.Date.AddDate(0, 0, -9), accrual.Date)
+	require.NoError(t, err)
+	require.Zero(t, accrued.Cmp(big.NewRat(9, 8)))
+
+	recorded, err := storage.RecordAccrual(ctx, mockTx, accrual)
+	require.NoError(t, err)
+	require.True(t, recorded)
+
+	recorded, err = storage.RecordAccrual(ctx, mockTx, accrual)
+	require.NoError(t, err)
+	require.False(t, recorded)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestStorage_PostInterest(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	walletID := uuid.New()
+	fundingWalletID := uuid.New()
+	at := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectBegin()
+	mockTx, err := mockPool.Begin(ctx)
+	require.NoError(t, err)
+
+	for _, change := range []struct {
+		walletID uuid.UUID
+		amount   int64
+	}{{fundingWalletID, -42}, {walletID, 42}} {
+		mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+			WithArgs(change.amount, change.walletID).
+			WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(int64(1000)))
+		mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_buckets`)).
+			WithArgs(change.walletID, wallet.BucketMain, change.amount).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_entries`)).
+			WithArgs(change.walletID, wallet.BucketMain, wallet.EntryInterest, change.amount, at).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		// snapshots taken after the entry date include the entry
+		mockPool.ExpectExec(`(?s)INSERT INTO wallet_balance_snapshots .*taken_at > \$4.*balance \+ EXCLUDED\.balance`).
+			WithArgs(change.walletID, wallet.BucketMain, change.amount, at).
+			WillReturnResult(pgxmock.NewResult("INSERT", 2))
+	}
+
+	require.NoError(t, storage.PostInterest(ctx, mockTx, walletID, fundingWalletID, 42, at))
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestStorage_PostInterest_FundingShortfall(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	walletID := uuid.New()
+	fundingWalletID := uuid.New()
+	at := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectBegin()
+	mockTx, err := mockPool.Begin(ctx)
+	require.NoError(t, err)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+		WithArgs(int64(-42), fundingWalletID).
+		WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(int64(-2)))
+
+	err = storage.PostInterest(ctx, mockTx, walletID, fundingWalletID, 42, at)
+	require.ErrorIs(t, err, wallet.ErrNotEnoughMoney)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestStorage_InterestAccounts(t *testing.T) {
+	t.Parallel()
+
+	walletID, newWalletID := uuid.New(), uuid.New()
+	lastAccrued := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`LEFT JOIN interest_accruals`)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "interest_product", "max"}).
+			AddRow(walletID, "savings", &lastAccrued).
+			AddRow(newWalletID, "savings", nil))
+
+	accounts, err := storage.InterestAccounts(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []wallet.InterestAccount{
+		{WalletID: walletID, Product: "savings", LastAccrued: &lastAccrued},
+		{WalletID: newWalletID, Product: "savings"},
+	}, accounts)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
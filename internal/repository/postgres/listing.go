@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"time"
+	"wallet/internal/model/wallet"
+)
+
+// ListWallets returns up to filter.Limit wallets matching the filter, oldest first.
+func (s *Storage) ListWallets(ctx context.Context, filter wallet.WalletFilter) ([]wallet.Wallet, error) {
+	query := `
+		SELECT id, owner_id, status, currency, balance, metadata, created_at
+		FROM wallets
+		WHERE ($1::TEXT IS NULL OR owner_id = $1)
+			AND ($2::TEXT IS NULL OR status = $2)
+			AND ($3::TEXT IS NULL OR currency = $3)
+			AND ($4::BIGINT IS NULL OR balance >= $4)
+			AND ($5::BIGINT IS NULL OR balance <= $5)
+			AND metadata @> COALESCE($6::JSONB, '{}')
+			AND ($7::TIMESTAMPTZ IS NULL OR created_at >= $7)
+			AND ($8::TIMESTAMPTZ IS NULL OR created_at < $8)
+			AND ($9::TIMESTAMPTZ IS NULL OR (created_at, id) > ($9, $10::UUID))
+		ORDER BY created_at, id
+		LIMIT $11;
+		`
+
+	var (
+		afterCreatedAt *time.Time
+		afterID        *uuid.UUID
+	)
+	if filter.After != nil {
+		afterCreatedAt, afterID = &filter.After.CreatedAt, &filter.After.ID
+	}
+
+	rows, err := s.db.Query(ctx, query,
+		filter.OwnerID, filter.Status, filter.Currency, filter.MinBalance, filter.MaxBalance, filter.Metadata,
+		filter.CreatedFrom, filter.CreatedBefore, afterCreatedAt, afterID, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (wallet.Wallet, error) {
+		var w wallet.Wallet
+		err := row.Scan(&w.ID, &w.OwnerID, &w.Status, &w.Currency, &w.Balance, &w.Metadata, &w.CreatedAt)
+		return w, err
+	})
+}
@@ -0,0 +1,53 @@
+package postgres_test
+
+import (
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+func TestStorage_ListWallets(t *testing.T) {
+	t.Parallel()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	status := wallet.StatusActive
+	after := wallet.WalletCursor{CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), ID: uuid.New()}
+	filter := wallet.WalletFilter{
+		Status:     &status,
+		MinBalance: ptr(int64(100)),
+		Metadata:   wallet.Metadata{"customerId": "c-42"},
+		After:      &after,
+		Limit:      11,
+	}
+	listed := wallet.Wallet{
+		ID:        uuid.New(),
+		OwnerID:   ptr("owner-1"),
+		Status:    wallet.StatusActive,
+		Currency:  "USD",
+		Balance:   500,
+		Metadata:  wallet.Metadata{"customerId": "c-42"},
+		CreatedAt: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`ORDER BY created_at, id`)).
+		WithArgs((*string)(nil), &status, (*string)(nil), filter.MinBalance, (*int64)(nil), filter.Metadata,
+			(*time.Time)(nil), (*time.Time)(nil), &after.CreatedAt, &after.ID, 11).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "owner_id", "status", "currency", "balance", "metadata", "created_at"}).
+			AddRow(listed.ID, listed.OwnerID, listed.Status, listed.Currency, listed.Balance, listed.Metadata, listed.CreatedAt))
+
+	wallets, err := storage.ListWallets(t.Context(), filter)
+	require.NoError(t, err)
+	require.Equal(t, []wallet.Wallet{listed}, wallets)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
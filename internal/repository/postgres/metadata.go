@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"time"
+	"wallet/internal/model/wallet"
+)
+
+// SetMetadata replaces the metadata of the wallet. Metadata is part of the balance the
+// wallet version tags: the wallets trigger bumps the version of an actual change and
+// records it as a wallet event with a delta of 0.
+func (s *Storage) SetMetadata(ctx context.Context, walletID uuid.UUID, metadata wallet.Metadata) error {
+	query := `
+		UPDATE wallets
+		SET metadata = COALESCE($2::JSONB, '{}')
+		WHERE id = $1;
+		`
+
+	tag, err := s.db.Exec(ctx, query, walletID, metadata)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return wallet.ErrWalletNotFound
+	}
+
+	return nil
+}
+
+// RecordOperation stores the operation in the wallet history, created at op.CreatedAt or,
+// when it is not set, at the start of the transaction.
+func (s *Storage) RecordOperation(ctx context.Context, tx pgx.Tx, op wallet.OperationRecord) error {
+	query := `
+		INSERT INTO wallet_operations (id, wallet_id, type, amount, fee, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6::JSONB, '{}'), COALESCE($7, now()));
+		`
+
+	var createdAt *time.Time
+	if !op.CreatedAt.IsZero() {
+		createdAt = &op.CreatedAt
+	}
+
+	_, err := tx.Exec(ctx, query, op.ID, op.WalletID, op.Type, op.Amount, op.Fee, op.Metadata, createdAt)
+	return err
+}
+
+// Operations returns the wallet history matching the filter, newest first.
+func (s *Storage) Operations(ctx context.Context, walletID uuid.UUID, filter wallet.OperationFilter) ([]wallet.OperationRecord, error) {
+	query := `
+		SELECT id, wallet_id, type, amount, fee, metadata, created_at
+		FROM wallet_operations
+		WHERE wallet_id = $1
+			AND metadata @> COALESCE($2::JSONB, '{}')
+			AND ($3::UUID IS NULL OR (created_at, id) < (
+				SELECT created_at, id
+				FROM wallet_operations
+				WHERE id = $3
+			))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4;
+		`
+
+	rows, err := s.db.Query(ctx, query, walletID, filter.Metadata, filter.Before, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (wallet.OperationRecord, error) {
+		var op wallet.OperationRecord
+		err := row.Scan(&op.ID, &op.WalletID, &op.Type, &op.Amount, &op.Fee, &op.Metadata, &op.CreatedAt)
+		return op, err
+	})
+}
@@ -0,0 +1,126 @@
+package postgres_test
+
+import (
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+func TestStorage_SetMetadata(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	metadata := wallet.Metadata{"customerId": "c-42"}
+
+	tests := []struct {
+		name          string
+		rowsAffected  int64
+		expectedError error
+	}{
+		{
+			name:         "metadata replaced",
+			rowsAffected: 1,
+		},
+		{
+			name:          "wallet not found",
+			rowsAffected:  0,
+			expectedError: wallet.ErrWalletNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			// the wallets trigger bumps the version, the update must not bump it twice
+			mockPool.ExpectExec(regexp.QuoteMeta(`SET metadata = COALESCE($2::JSONB, '{}')
+		WHERE id = $1;`)).
+				WithArgs(walletID, metadata).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.rowsAffected))
+
+			err = storage.SetMetadata(t.Context(), walletID, metadata)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStorage_RecordOperation(t *testing.T) {
+	t.Parallel()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	op := wallet.OperationRecord{
+		ID:        uuid.New(),
+		WalletID:  uuid.New(),
+		Type:      wallet.OperationWithdraw,
+		Amount:    100,
+		Fee:       5,
+		Metadata:  wallet.Metadata{"merchantId": "m-7"},
+		CreatedAt: time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	mockPool.ExpectBegin()
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_operations`)).
+		WithArgs(op.ID, op.WalletID, op.Type, op.Amount, op.Fee, op.Metadata, &op.CreatedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	mockTx, err := mockPool.Begin(t.Context())
+	require.NoError(t, err)
+
+	require.NoError(t, storage.RecordOperation(t.Context(), mockTx, op))
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestStorage_Operations(t *testing.T) {
+	t.Parallel()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	walletID := uuid.New()
+	before := uuid.New()
+	filter := wallet.OperationFilter{Metadata: wallet.Metadata{"merchantId": "m-7"}, Before: &before, Limit: 10}
+	op := wallet.OperationRecord{
+		ID:        uuid.New(),
+		WalletID:  walletID,
+		Type:      wallet.OperationDeposit,
+		Amount:    100,
+		Metadata:  wallet.Metadata{"merchantId": "m-7", "label": "promo"},
+		CreatedAt: time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC),
+	}
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_operations`)).
+		WithArgs(walletID, filter.Metadata, filter.Before, filter.Limit).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "wallet_id", "type", "amount", "fee", "metadata", "created_at"}).
+			AddRow(op.ID, op.WalletID, op.Type, op.Amount, op.Fee, op.Metadata, op.CreatedAt))
+
+	ops, err := storage.Operations(t.Context(), walletID, filter)
+	require.NoError(t, err)
+	require.Equal(t, []wallet.OperationRecord{op}, ops)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -4,24 +4,42 @@ import (
 	"context"
 	"fmt"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PgxIface interface {
 	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
 }
 
 type Storage struct {
-	db PgxIface
+	db    PgxIface
+	retry RetryPolicy
+}
+
+type Option func(*Storage)
+
+// WithRetryPolicy overrides DefaultRetryPolicy used by WithinTx.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(s *Storage) {
+		s.retry = p
+	}
 }
 
 func (s *Storage) BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
 	return s.db.BeginTx(ctx, opts)
 }
 
-func New(db PgxIface) *Storage {
-	return &Storage{db: db}
+func New(db PgxIface, opts ...Option) *Storage {
+	s := &Storage{db: db, retry: DefaultRetryPolicy}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 func Init(dsn string) (*pgxpool.Pool, error) {
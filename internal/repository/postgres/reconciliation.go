@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"context"
+	"github.com/jackc/pgx/v5"
+	"wallet/internal/model/wallet"
+)
+
+// Discrepancies returns every wallet total and bucket balance that differs from the sum
+// of its journal entries. Both are checked within one statement, so they see the same data.
+func (s *Storage) Discrepancies(ctx context.Context) ([]wallet.Discrepancy, error) {
+	query := `
+		SELECT w.id, '', w.balance, COALESCE(e.total, 0)
+		FROM wallets w
+		LEFT JOIN (
+			SELECT wallet_id, SUM(amount)::BIGINT AS total
+			FROM wallet_entries
+			GROUP BY wallet_id
+		) e ON e.wallet_id = w.id
+		WHERE w.balance <> COALESCE(e.total, 0)
+		UNION ALL
+		SELECT b.wallet_id, b.name, b.balance, COALESCE(e.total, 0)
+		FROM wallet_buckets b
+		LEFT JOIN (
+			SELECT wallet_id, bucket, SUM(amount)::BIGINT AS total
+			FROM wallet_entries
+			GROUP BY wallet_id, bucket
+		) e ON e.wallet_id = b.wallet_id AND e.bucket = b.name
+		WHERE b.balance <> COALESCE(e.total, 0)
+		ORDER BY 1, 2;
+		`
+
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (wallet.Discrepancy, error) {
+		var d wallet.Discrepancy
+		err := row.Scan(&d.WalletID, &d.Bucket, &d.Balance, &d.Journal)
+		return d, err
+	})
+}
@@ -0,0 +1,37 @@
+package postgres_test
+
+import (
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+func TestStorage_Discrepancies(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`WHERE w.balance <> COALESCE(e.total, 0)`)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "bucket", "balance", "journal"}).
+			AddRow(walletID, "", int64(1000), int64(900)).
+			AddRow(walletID, wallet.BucketMain, int64(1000), int64(900)))
+
+	discrepancies, err := storage.Discrepancies(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []wallet.Discrepancy{
+		{WalletID: walletID, Balance: 1000, Journal: 900},
+		{WalletID: walletID, Bucket: wallet.BucketMain, Balance: 1000, Journal: 900},
+	}, discrepancies)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
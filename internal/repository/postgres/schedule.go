@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+	"wallet/internal/model/wallet"
+)
+
+const sqlStateForeignKeyViolation = "23503"
+
+const scheduleColumns = `id, operation_type, wallet_id, to_wallet_id, amount, recurrence, next_run_at, status, created_at`
+
+// CreateSchedule stores a new active schedule.
+func (s *Storage) CreateSchedule(ctx context.Context, sch wallet.Schedule) error {
+	query := `
+		INSERT INTO schedules (id, operation_type, wallet_id, to_wallet_id, amount, recurrence, next_run_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+		`
+
+	_, err := s.db.Exec(ctx, query, sch.ID, sch.Operation, sch.WalletID, sch.ToWalletID, sch.Amount,
+		sch.Recurrence, sch.NextRunAt, wallet.ScheduleActive)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == sqlStateForeignKeyViolation {
+		return wallet.ErrWalletNotFound
+	}
+
+	return err
+}
+
+// ListSchedules returns the schedules debiting or crediting the wallet, newest first.
+func (s *Storage) ListSchedules(ctx context.Context, walletID uuid.UUID) ([]wallet.Schedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM schedules
+		WHERE wallet_id = $1 OR to_wallet_id = $1
+		ORDER BY created_at DESC, id;
+		`
+
+	rows, err := s.db.Query(ctx, query, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanSchedule)
+}
+
+// CancelSchedule stops an active schedule. Occurrences already running are not affected.
+func (s *Storage) CancelSchedule(ctx context.Context, scheduleID uuid.UUID) error {
+	query := `
+		UPDATE schedules
+		SET status = $2, next_run_at = NULL
+		WHERE id = $1 AND status = $3;
+		`
+
+	tag, err := s.db.Exec(ctx, query, scheduleID, wallet.ScheduleCancelled, wallet.ScheduleActive)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 1 {
+		return nil
+	}
+
+	var exists bool
+	if err := s.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM schedules WHERE id = $1);`, scheduleID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return wallet.ErrScheduleNotActive
+	}
+
+	return wallet.ErrScheduleNotFound
+}
+
+// NextDueSchedule locks the active schedule that is due the longest and returns it, or nil
+// when nothing is due. Schedules locked by concurrent transactions are skipped.
+func (s *Storage) NextDueSchedule(ctx context.Context, tx pgx.Tx) (*wallet.Schedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM schedules
+		WHERE status = $1 AND next_run_at <= now()
+		ORDER BY next_run_at, id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED;
+		`
+
+	rows, err := tx.Query(ctx, query, wallet.ScheduleActive)
+	if err != nil {
+		return nil, err
+	}
+
+	sch, err := pgx.CollectOneRow(rows, scanSchedule)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &sch, nil
+}
+
+// RecordRun stores the outcome of an occurrence. It returns false when the occurrence
+// was already recorded, that is, when it has already run.
+func (s *Storage) RecordRun(ctx context.Context, tx pgx.Tx, run wallet.ScheduleRun) (bool, error) {
+	query := `
+		INSERT INTO schedule_runs (schedule_id, run_at, status, error)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (schedule_id, run_at) DO NOTHING;
+		`
+
+	tag, err := tx.Exec(ctx, query, run.ScheduleID, run.RunAt, run.Status, run.Error)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// AdvanceSchedule moves the schedule from the occurrence at runAt to next, completing it
+// when next is nil. It does nothing when the schedule has already moved past runAt.
+func (s *Storage) AdvanceSchedule(ctx context.Context, tx pgx.Tx, scheduleID uuid.UUID, runAt time.Time, next *time.Time) error {
+	query := `
+		UPDATE schedules
+		SET next_run_at = $3,
+			status = CASE WHEN $3::TIMESTAMPTZ IS NULL THEN $4 ELSE status END
+		WHERE id = $1 AND next_run_at = $2;
+		`
+
+	_, err := tx.Exec(ctx, query, scheduleID, runAt, next, wallet.ScheduleCompleted)
+	return err
+}
+
+func scanSchedule(row pgx.CollectableRow) (wallet.Schedule, error) {
+	var sch wallet.Schedule
+	err := row.Scan(&sch.ID, &sch.Operation, &sch.WalletID, &sch.ToWalletID, &sch.Amount,
+		&sch.Recurrence, &sch.NextRunAt, &sch.Status, &sch.CreatedAt)
+	return sch, err
+}
@@ -0,0 +1,188 @@
+package postgres_test
+
+import (
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+func TestStorage_CreateSchedule(t *testing.T) {
+	t.Parallel()
+
+	runAt := time.Now().Add(time.Hour)
+	sch := wallet.Schedule{
+		ID:        uuid.New(),
+		Operation: wallet.OperationDeposit,
+		WalletID:  uuid.New(),
+		Amount:    100,
+		NextRunAt: &runAt,
+	}
+
+	tests := []struct {
+		name          string
+		err           error
+		expectedError error
+	}{
+		{
+			name: "created",
+		},
+		{
+			name:          "wallet not found",
+			err:           &pgconn.PgError{Code: "23503"},
+			expectedError: wallet.ErrWalletNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			exec := mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO schedules`)).
+				WithArgs(sch.ID, wallet.OperationDeposit, sch.WalletID, sch.ToWalletID, int64(100), "", &runAt, wallet.ScheduleActive)
+			if tt.err != nil {
+				exec.WillReturnError(tt.err)
+			} else {
+				exec.WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			}
+
+			err = storage.CreateSchedule(t.Context(), sch)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStorage_CancelSchedule(t *testing.T) {
+	t.Parallel()
+
+	scheduleID := uuid.New()
+
+	tests := []struct {
+		name          string
+		rowsAffected  int64
+		exists        bool
+		expectedError error
+	}{
+		{
+			name:         "cancelled",
+			rowsAffected: 1,
+		},
+		{
+			name:          "already finished",
+			exists:        true,
+			expectedError: wallet.ErrScheduleNotActive,
+		},
+		{
+			name:          "not found",
+			expectedError: wallet.ErrScheduleNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE schedules`)).
+				WithArgs(scheduleID, wallet.ScheduleCancelled, wallet.ScheduleActive).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.rowsAffected))
+			if tt.rowsAffected == 0 {
+				mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+					WithArgs(scheduleID).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(tt.exists))
+			}
+
+			err = storage.CancelSchedule(t.Context(), scheduleID)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStorage_NextDueSchedule(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	walletID := uuid.New()
+	scheduleID := uuid.New()
+	runAt := time.Now().Add(-time.Minute)
+	createdAt := runAt.Add(-time.Hour)
+	next := runAt.Add(time.Hour)
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectBegin()
+	mockTx, err := mockPool.Begin(ctx)
+	require.NoError(t, err)
+
+	columns := []string{"id", "operation_type", "wallet_id", "to_wallet_id", "amount", "recurrence", "next_run_at", "status", "created_at"}
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE SKIP LOCKED`)).
+		WithArgs(wallet.ScheduleActive).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow(scheduleID, wallet.OperationWithdraw, walletID, nil, int64(100), "0 * * * *", &runAt, wallet.ScheduleActive, createdAt))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE SKIP LOCKED`)).
+		WithArgs(wallet.ScheduleActive).
+		WillReturnRows(pgxmock.NewRows(columns))
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO schedule_runs`)).
+		WithArgs(scheduleID, runAt, wallet.RunSucceeded, "").
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+	mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE schedules`)).
+		WithArgs(scheduleID, runAt, &next, wallet.ScheduleCompleted).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	sch, err := storage.NextDueSchedule(ctx, mockTx)
+	require.NoError(t, err)
+	require.Equal(t, &wallet.Schedule{
+		ID:         scheduleID,
+		Operation:  wallet.OperationWithdraw,
+		WalletID:   walletID,
+		Amount:     100,
+		Recurrence: "0 * * * *",
+		NextRunAt:  &runAt,
+		Status:     wallet.ScheduleActive,
+		CreatedAt:  createdAt,
+	}, sch)
+
+	sch, err = storage.NextDueSchedule(ctx, mockTx)
+	require.NoError(t, err)
+	require.Nil(t, sch)
+
+	recorded, err := storage.RecordRun(ctx, mockTx, wallet.ScheduleRun{ScheduleID: scheduleID, RunAt: runAt, Status: wallet.RunSucceeded})
+	require.NoError(t, err)
+	require.False(t, recorded)
+
+	require.NoError(t, storage.AdvanceSchedule(ctx, mockTx, scheduleID, runAt, &next))
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"math/big"
+	"wallet/internal/model/wallet"
+)
+
+// decimalScale is the number of decimal places rates and exact amounts are stored with.
+const decimalScale = 18
+
+// SaveQuote stores a quote so that a later transfer can use it.
+func (s *Storage) SaveQuote(ctx context.Context, q wallet.Quote) error {
+	query := `
+		INSERT INTO fx_quotes (id, from_currency, to_currency, rate, expires_at)
+		VALUES ($1, $2, $3, $4::NUMERIC, $5);
+		`
+
+	_, err := s.db.Exec(ctx, query, q.ID, q.FromCurrency, q.ToCurrency, q.Rate.FloatString(decimalScale), q.ExpiresAt)
+	return err
+}
+
+// LockWallets locks the wallets in id order, so that concurrent transfers between the same
+// wallets never deadlock, and returns their currencies.
+func (s *Storage) LockWallets(ctx context.Context, tx pgx.Tx, walletIDs ...uuid.UUID) (map[uuid.UUID]string, error) {
+	query := `
+		SELECT id, currency
+		FROM wallets
+		WHERE id = ANY($1)
+		ORDER BY id
+		FOR UPDATE;
+		`
+
+	rows, err := tx.Query(ctx, query, walletIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	currencies := make(map[uuid.UUID]string, len(walletIDs))
+	for rows.Next() {
+		var (
+			id       uuid.UUID
+			currency string
+		)
+		if err := rows.Scan(&id, &currency); err != nil {
+			return nil, err
+		}
+		currencies[id] = currency
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range walletIDs {
+		if _, ok := currencies[id]; !ok {
+			return nil, wallet.ErrWalletNotFound
+		}
+	}
+
+	return currencies, nil
+}
+
+// UseQuote marks the quote as used and returns it. A quote can only be used once and
+// only before it expires.
+func (s *Storage) UseQuote(ctx context.Context, tx pgx.Tx, quoteID uuid.UUID) (wallet.Quote, error) {
+	query := `
+		UPDATE fx_quotes
+		SET used_at = now()
+		WHERE id = $1 AND used_at IS NULL AND expires_at > now()
+		RETURNING from_currency, to_currency, rate::TEXT, expires_at;
+		`
+
+	q := wallet.Quote{ID: quoteID}
+	var rate string
+	err := tx.QueryRow(ctx, query, quoteID).Scan(&q.FromCurrency, &q.ToCurrency, &rate, &q.ExpiresAt)
+	if err == nil {
+		var ok bool
+		if q.Rate, ok = new(big.Rat).SetString(rate); !ok {
+			return wallet.Quote{}, fmt.Errorf("quote %s has invalid rate %q", quoteID, rate)
+		}
+		return q, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return wallet.Quote{}, err
+	}
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM fx_quotes WHERE id = $1);`, quoteID).Scan(&exists); err != nil {
+		return wallet.Quote{}, err
+	}
+	if exists {
+		return wallet.Quote{}, wallet.ErrQuoteExpired
+	}
+
+	return wallet.Quote{}, wallet.ErrQuoteNotFound
+}
+
+// Transfer debits the source wallet the same way Withdraw does, spending only the buckets in
+// order so that promotional credit cannot become cash in another wallet, credits the main
+// bucket of the destination wallet and records the transfer. It returns the source wallet
+// balance; a negative balance is returned as is and nothing else is written.
+func (s *Storage) Transfer(ctx context.Context, tx pgx.Tx, t wallet.Transfer, order []string) (int64, error) {
+	balance, err := s.debit(ctx, tx, t.FromWalletID, wallet.EntryTransferOut, t.DebitAmount, order, order)
+	if err != nil || balance < 0 {
+		return balance, err
+	}
+
+	if _, err := s.updateActiveBalance(ctx, tx, t.ToWalletID, t.CreditAmount); err != nil {
+		return 0, err
+	}
+
+	if err := s.updateBucket(ctx, tx, t.ToWalletID, wallet.BucketMain, t.CreditAmount); err != nil {
+		return 0, err
+	}
+
+	if err := s.addEntry(ctx, tx, t.ToWalletID, wallet.BucketMain, wallet.EntryTransferIn, t.CreditAmount); err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO transfers (id, from_wallet_id, to_wallet_id, from_currency, to_currency, quote_id, rate, debit_amount, credit_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7::NUMERIC, $8, $9);
+		`
+
+	_, err = tx.Exec(ctx, query, t.ID, t.FromWalletID, t.ToWalletID, t.FromCurrency, t.ToCurrency, t.QuoteID,
+		t.Rate.FloatString(decimalScale), t.DebitAmount, t.CreditAmount)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
@@ -0,0 +1,217 @@
+package postgres_test
+
+import (
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"regexp"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+func TestStorage_SaveQuote(t *testing.T) {
+	t.Parallel()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	q := wallet.Quote{
+		ID:           uuid.New(),
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+		Rate:         big.NewRat(23, 25),
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}
+
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO fx_quotes`)).
+		WithArgs(q.ID, "USD", "EUR", "0.920000000000000000", q.ExpiresAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	require.NoError(t, storage.SaveQuote(t.Context(), q))
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestStorage_LockWallets(t *testing.T) {
+	t.Parallel()
+
+	fromID, toID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name          string
+		rows          *pgxmock.Rows
+		expected      map[uuid.UUID]string
+		expectedError error
+	}{
+		{
+			name:     "both wallets exist",
+			rows:     pgxmock.NewRows([]string{"id", "currency"}).AddRow(fromID, "USD").AddRow(toID, "EUR"),
+			expected: map[uuid.UUID]string{fromID: "USD", toID: "EUR"},
+		},
+		{
+			name:          "wallet not found",
+			rows:          pgxmock.NewRows([]string{"id", "currency"}).AddRow(fromID, "USD"),
+			expectedError: wallet.ErrWalletNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			mockPool.ExpectBegin()
+			mockTx, err := mockPool.Begin(ctx)
+			require.NoError(t, err)
+
+			mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+				WithArgs([]uuid.UUID{fromID, toID}).
+				WillReturnRows(tt.rows)
+
+			currencies, err := storage.LockWallets(ctx, mockTx, fromID, toID)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.expected, currencies)
+			}
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStorage_UseQuote(t *testing.T) {
+	t.Parallel()
+
+	quoteID := uuid.New()
+	expiresAt := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name          string
+		used          bool
+		exists        bool
+		expectedError error
+	}{
+		{
+			name: "used",
+			used: true,
+		},
+		{
+			name:          "expired or already used",
+			exists:        true,
+			expectedError: wallet.ErrQuoteExpired,
+		},
+		{
+			name:          "not found",
+			expectedError: wallet.ErrQuoteNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			mockPool.ExpectBegin()
+			mockTx, err := mockPool.Begin(ctx)
+			require.NoError(t, err)
+
+			update := mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE fx_quotes`)).WithArgs(quoteID)
+			if tt.used {
+				update.WillReturnRows(pgxmock.NewRows([]string{"from_currency", "to_currency", "rate", "expires_at"}).
+					AddRow("USD", "EUR", "0.920000000000000000", expiresAt))
+			} else {
+				update.WillReturnError(pgx.ErrNoRows)
+				mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+					WithArgs(quoteID).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(tt.exists))
+			}
+
+			quote, err := storage.UseQuote(ctx, mockTx, quoteID)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "USD", quote.FromCurrency)
+				require.Equal(t, "EUR", quote.ToCurrency)
+				require.Zero(t, quote.Rate.Cmp(big.NewRat(23, 25)))
+			}
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestStorage_Transfer(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	quoteID := uuid.New()
+	transfer := wallet.Transfer{
+		ID:           uuid.New(),
+		FromWalletID: uuid.New(),
+		ToWalletID:   uuid.New(),
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+		QuoteID:      &quoteID,
+		Rate:         big.NewRat(23, 25),
+		DebitAmount:  100,
+		CreditAmount: 92,
+	}
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectBegin()
+	mockTx, err := mockPool.Begin(ctx)
+	require.NoError(t, err)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+		WithArgs(int64(-100), transfer.FromWalletID).
+		WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(int64(400)))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_buckets`)).
+		WithArgs(transfer.FromWalletID).
+		WillReturnRows(pgxmock.NewRows([]string{"name", "balance"}).AddRow(wallet.BucketMain, int64(500)))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_lots`)).
+		WithArgs(transfer.FromWalletID).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "bucket", "remaining", "expired"}))
+	expectBucketChange(mockPool, transfer.FromWalletID, wallet.BucketMain, wallet.EntryTransferOut, -100)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+		WithArgs(int64(92), transfer.ToWalletID).
+		WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(int64(92)))
+	expectBucketChange(mockPool, transfer.ToWalletID, wallet.BucketMain, wallet.EntryTransferIn, 92)
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO transfers`)).
+		WithArgs(transfer.ID, transfer.FromWalletID, transfer.ToWalletID, "USD", "EUR", &quoteID,
+			"0.920000000000000000", int64(100), int64(92)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	balance, err := storage.Transfer(ctx, mockTx, transfer, []string{wallet.BucketMain})
+	require.NoError(t, err)
+	require.Equal(t, int64(400), balance)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
@@ -0,0 +1,208 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"math/big"
+	"math/rand/v2"
+	"time"
+	"wallet/internal/metrics"
+	repo "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+)
+
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// RetryPolicy controls how transactions aborted by a serialization failure or a deadlock are retried.
+type RetryPolicy struct {
+	MaxAttempts int           // MaxAttempts is the total number of attempts, including the first one
+	BaseDelay   time.Duration // BaseDelay is the backoff cap of the first retry, doubled on every next one
+	MaxDelay    time.Duration // MaxDelay caps the backoff of a single retry
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   5 * time.Millisecond,
+	MaxDelay:    200 * time.Millisecond,
+}
+
+// backoff returns a random delay in [0, min(MaxDelay, BaseDelay*2^(retry-1))] ("full jitter"),
+// so that conflicting transactions do not collide again in lockstep.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.BaseDelay << (retry - 1)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return rand.N(ceiling + 1)
+}
+
+// WithinTx runs fn in a repeatable read transaction and commits it when fn succeeds.
+// Transactions aborted by a serialization failure or a deadlock are retried from scratch
+// with jittered backoff until the retry policy is exhausted.
+func (s *Storage) WithinTx(ctx context.Context, fn repo.TxFunc) error {
+	attempts := max(s.retry.MaxAttempts, 1)
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = s.runTx(ctx, fn)
+
+		reason, ok := retryReason(err)
+		if !ok {
+			return err
+		}
+
+		if attempt >= attempts {
+			metrics.TxRetriesExhausted.WithLabelValues(reason).Inc()
+			return fmt.Errorf("%w: %w", wallet.ErrTooManyConflicts, err)
+		}
+
+		metrics.TxRetries.WithLabelValues(reason).Inc()
+
+		timer := time.NewTimer(s.retry.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *Storage) runTx(ctx context.Context, fn repo.TxFunc) error {
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel: pgx.RepeatableRead,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(ctx, &walletTx{s: s, tx: tx}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// retryReason reports whether err aborted the transaction in a way that is safe to retry,
+// and the metrics label describing why.
+func retryReason(err error) (string, bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return "", false
+	}
+
+	switch pgErr.Code {
+	case sqlStateSerializationFailure:
+		return "serialization_failure", true
+	case sqlStateDeadlockDetected:
+		return "deadlock", true
+	default:
+		return "", false
+	}
+}
+
+// walletTx binds Storage operations to a running transaction.
+type walletTx struct {
+	s  *Storage
+	tx pgx.Tx
+}
+
+func (t *walletTx) Deposit(ctx context.Context, walletID uuid.UUID, credit wallet.Credit) (int64, error) {
+	return t.s.Deposit(ctx, t.tx, walletID, credit)
+}
+
+func (t *walletTx) CheckVersion(ctx context.Context, walletID uuid.UUID, version int64) error {
+	return t.s.CheckVersion(ctx, t.tx, walletID, version)
+}
+
+func (t *walletTx) Withdraw(ctx context.Context, walletID uuid.UUID, amount int64, order []string) (int64, error) {
+	return t.s.Withdraw(ctx, t.tx, walletID, amount, order)
+}
+
+func (t *walletTx) DepositBatch(ctx context.Context, walletID uuid.UUID, bucket string, amounts []int64) ([]int64, error) {
+	return t.s.DepositBatch(ctx, t.tx, walletID, bucket, amounts)
+}
+
+func (t *walletTx) ExpireLots(ctx context.Context, limit int) ([]wallet.Expiry, error) {
+	return t.s.ExpireLots(ctx, t.tx, limit)
+}
+
+func (t *walletTx) ChargeFee(ctx context.Context, walletID, feeWalletID uuid.UUID, fee int64, order []string) (int64, error) {
+	return t.s.ChargeFee(ctx, t.tx, walletID, feeWalletID, fee, order)
+}
+
+func (t *walletTx) Tier(ctx context.Context, walletID uuid.UUID) (string, error) {
+	return t.s.Tier(ctx, t.tx, walletID)
+}
+
+func (t *walletTx) LockWallets(ctx context.Context, walletIDs ...uuid.UUID) (map[uuid.UUID]string, error) {
+	return t.s.LockWallets(ctx, t.tx, walletIDs...)
+}
+
+func (t *walletTx) UseQuote(ctx context.Context, quoteID uuid.UUID) (wallet.Quote, error) {
+	return t.s.UseQuote(ctx, t.tx, quoteID)
+}
+
+func (t *walletTx) Transfer(ctx context.Context, transfer wallet.Transfer, order []string) (int64, error) {
+	return t.s.Transfer(ctx, t.tx, transfer, order)
+}
+
+func (t *walletTx) NextDueSchedule(ctx context.Context) (*wallet.Schedule, error) {
+	return t.s.NextDueSchedule(ctx, t.tx)
+}
+
+func (t *walletTx) RecordRun(ctx context.Context, run wallet.ScheduleRun) (bool, error) {
+	return t.s.RecordRun(ctx, t.tx, run)
+}
+
+func (t *walletTx) AdvanceSchedule(ctx context.Context, scheduleID uuid.UUID, runAt time.Time, next *time.Time) error {
+	return t.s.AdvanceSchedule(ctx, t.tx, scheduleID, runAt, next)
+}
+
+func (t *walletTx) BucketBalanceAt(ctx context.Context, walletID uuid.UUID, bucket string, at time.Time) (int64, error) {
+	return t.s.BucketBalanceAt(ctx, t.tx, walletID, bucket, at)
+}
+
+func (t *walletTx) AccruedInterest(ctx context.Context, walletID uuid.UUID, from, to time.Time) (*big.Rat, error) {
+	return t.s.AccruedInterest(ctx, t.tx, walletID, from, to)
+}
+
+func (t *walletTx) RecordAccrual(ctx context.Context, accrual wallet.Accrual) (bool, error) {
+	return t.s.RecordAccrual(ctx, t.tx, accrual)
+}
+
+func (t *walletTx) PostInterest(ctx context.Context, walletID, fundingWalletID uuid.UUID, amount int64, at time.Time) error {
+	return t.s.PostInterest(ctx, t.tx, walletID, fundingWalletID, amount, at)
+}
+
+func (t *walletTx) RecordOperation(ctx context.Context, op wallet.OperationRecord) error {
+	return t.s.RecordOperation(ctx, t.tx, op)
+}
+
+func (t *walletTx) Adjust(ctx context.Context, adj wallet.Adjustment) (int64, error) {
+	return t.s.Adjust(ctx, t.tx, adj)
+}
+
+func (t *walletTx) ProposeAdjustment(ctx context.Context, req wallet.AdjustmentRequest) error {
+	return t.s.ProposeAdjustment(ctx, t.tx, req)
+}
+
+func (t *walletTx) DecideAdjustment(ctx context.Context, requestID uuid.UUID, status wallet.AdjustmentStatus, principal, note string) (wallet.AdjustmentRequest, error) {
+	return t.s.DecideAdjustment(ctx, t.tx, requestID, status, principal, note)
+}
+
+func (t *walletTx) RecordAdjustmentEvent(ctx context.Context, event wallet.AdjustmentEvent) error {
+	return t.s.RecordAdjustmentEvent(ctx, t.tx, event)
+}
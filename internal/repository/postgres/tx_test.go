@@ -0,0 +1,125 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+	"time"
+	repo "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/postgres"
+)
+
+func TestStorage_WithinTx(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	serializationFailure := &pgconn.PgError{Code: "40001"}
+	txOptions := pgx.TxOptions{IsoLevel: pgx.RepeatableRead}
+	errFn := errors.New("fn failed")
+
+	tests := []struct {
+		name          string
+		setup         func(mock pgxmock.PgxPoolIface)
+		fnError       error
+		expectedError error
+		expectedCalls int
+	}{
+		{
+			name: "commit on success",
+			setup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(txOptions)
+				expectUpdate(mock, walletID, 100, 100)
+				mock.ExpectCommit()
+			},
+			expectedCalls: 1,
+		},
+		{
+			name: "rollback on error",
+			setup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(txOptions)
+				expectUpdate(mock, walletID, 100, 100)
+				mock.ExpectRollback()
+			},
+			fnError:       errFn,
+			expectedError: errFn,
+			expectedCalls: 1,
+		},
+		{
+			name: "retry on serialization failure",
+			setup: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectBeginTx(txOptions)
+				mock.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+					WithArgs(int64(100), walletID).
+					WillReturnError(serializationFailure)
+				mock.ExpectRollback()
+				mock.ExpectBeginTx(txOptions)
+				expectUpdate(mock, walletID, 100, 100)
+				mock.ExpectCommit()
+			},
+			expectedCalls: 2,
+		},
+		{
+			name: "give up after max attempts",
+			setup: func(mock pgxmock.PgxPoolIface) {
+				for range 3 {
+					mock.ExpectBeginTx(txOptions)
+					mock.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+						WithArgs(int64(100), walletID).
+						WillReturnError(serializationFailure)
+					mock.ExpectRollback()
+				}
+			},
+			expectedError: wallet.ErrTooManyConflicts,
+			expectedCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			tt.setup(mockPool)
+			storage := postgres.New(mockPool, postgres.WithRetryPolicy(postgres.RetryPolicy{
+				MaxAttempts: 3,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    time.Millisecond,
+			}))
+
+			calls := 0
+			err = storage.WithinTx(t.Context(), func(ctx context.Context, tx repo.Tx) error {
+				calls++
+				if _, err := tx.Deposit(ctx, walletID, wallet.Credit{Bucket: wallet.BucketMain, Amount: 100}); err != nil {
+					return err
+				}
+				return tt.fnError
+			})
+
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tt.expectedCalls, calls)
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
+
+func expectUpdate(mock pgxmock.PgxPoolIface, walletID uuid.UUID, delta, balance int64) {
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+		WithArgs(delta, walletID).
+		WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(balance))
+	expectBucketChange(mock, walletID, wallet.BucketMain, wallet.EntryDeposit, delta)
+}
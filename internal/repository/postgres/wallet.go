@@ -1,40 +1,246 @@
 package postgres
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"maps"
+	"slices"
 	"wallet/internal/model/wallet"
 )
 
-func (s *Storage) GetBalance(ctx context.Context, walletID uuid.UUID) (int64, error) {
+func (s *Storage) GetBalance(ctx context.Context, walletID uuid.UUID) (wallet.Balance, error) {
 	query := `
-		SELECT balance 
-		FROM wallets 
-		WHERE id = $1
+		SELECT w.balance, w.version, w.metadata, b.name, b.balance
+		FROM wallets w
+		LEFT JOIN wallet_buckets b ON b.wallet_id = w.id
+		WHERE w.id = $1
 	`
 
-	var balance int64
+	rows, err := s.db.Query(ctx, query, walletID)
+	if err != nil {
+		return wallet.Balance{}, err
+	}
+	defer rows.Close()
+
+	balance := wallet.Balance{Buckets: make(map[string]int64)}
+	found := false
+	for rows.Next() {
+		var (
+			name          *string
+			bucketBalance *int64
+		)
+		if err := rows.Scan(&balance.Total, &balance.Version, &balance.Metadata, &name, &bucketBalance); err != nil {
+			return wallet.Balance{}, err
+		}
+
+		found = true
+		if name != nil {
+			balance.Buckets[*name] = *bucketBalance
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return wallet.Balance{}, err
+	}
+
+	if !found {
+		return wallet.Balance{}, wallet.ErrWalletNotFound
+	}
+
+	return balance, nil
+}
+
+// HotWallets returns ids of wallets flagged for write batching.
+func (s *Storage) HotWallets(ctx context.Context) ([]uuid.UUID, error) {
+	query := `
+		SELECT id
+		FROM wallets
+		WHERE hot
+	`
 
-	err := s.db.QueryRow(ctx, query, walletID).Scan(&balance)
+	rows, err := s.db.Query(ctx, query)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return 0, wallet.ErrWalletNotFound
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+func (s *Storage) Deposit(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, credit wallet.Credit) (int64, error) {
+	balance, err := s.updateActiveBalance(ctx, tx, walletID, credit.Amount)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.updateBucket(ctx, tx, walletID, credit.Bucket, credit.Amount); err != nil {
+		return 0, err
+	}
+
+	if credit.ExpiresAt != nil {
+		if err := s.addLot(ctx, tx, walletID, credit); err != nil {
+			return 0, err
 		}
+	}
+
+	if err := s.addEntry(ctx, tx, walletID, credit.Bucket, wallet.EntryDeposit, credit.Amount); err != nil {
 		return 0, err
 	}
 
 	return balance, nil
 }
 
-func (s *Storage) Deposit(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, amount int64) (int64, error) {
-	return s.updateBalance(ctx, tx, walletID, amount)
+// CheckVersion locks the wallet, so that its version cannot change until the transaction
+// ends, and fails with ErrVersionMismatch unless the wallet is at version.
+func (s *Storage) CheckVersion(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, version int64) error {
+	query := `
+		SELECT version
+		FROM wallets
+		WHERE id = $1
+		FOR UPDATE;
+		`
+
+	var current int64
+	if err := tx.QueryRow(ctx, query, walletID).Scan(&current); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return wallet.ErrWalletNotFound
+		}
+		return err
+	}
+
+	if current != version {
+		return wallet.ErrVersionMismatch
+	}
+
+	return nil
 }
 
-func (s *Storage) Withdraw(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, amount int64) (int64, error) {
-	return s.updateBalance(ctx, tx, walletID, -amount)
+// Withdraw debits the buckets listed in order, expiring credits in them first, soonest-expiring
+// first, then the buckets in the given order. Buckets missing from order are never withdrawn:
+// ErrNotEnoughMoney is returned when the listed buckets do not cover amount. A negative balance
+// is returned as is and nothing else is written; the caller is expected to reject it and roll
+// the transaction back.
+func (s *Storage) Withdraw(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, amount int64, order []string) (int64, error) {
+	return s.debit(ctx, tx, walletID, wallet.EntryWithdraw, amount, order, order)
+}
+
+// ChargeFee debits the fee from any bucket of the wallet and credits it to the main bucket
+// of the fee-income wallet. Expiring credits are spent first, soonest-expiring first, then
+// buckets in the given order; buckets missing from order are spent last, by name. It
+// returns the debited wallet balance.
+func (s *Storage) ChargeFee(ctx context.Context, tx pgx.Tx, walletID, feeWalletID uuid.UUID, fee int64, order []string) (int64, error) {
+	balance, err := s.debit(ctx, tx, walletID, wallet.EntryFee, fee, nil, order)
+	if err != nil || balance < 0 {
+		return balance, err
+	}
+
+	if _, err := s.updateBalance(ctx, tx, feeWalletID, fee); err != nil {
+		if errors.Is(err, wallet.ErrWalletNotFound) {
+			return 0, fmt.Errorf("fee wallet %s does not exist", feeWalletID)
+		}
+		return 0, err
+	}
+
+	if err := s.updateBucket(ctx, tx, feeWalletID, wallet.BucketMain, fee); err != nil {
+		return 0, err
+	}
+
+	if err := s.addEntry(ctx, tx, feeWalletID, wallet.BucketMain, wallet.EntryFee, fee); err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+// Tier returns the pricing tier of the wallet.
+func (s *Storage) Tier(ctx context.Context, tx pgx.Tx, walletID uuid.UUID) (string, error) {
+	query := `
+		SELECT tier
+		FROM wallets
+		WHERE id = $1;
+		`
+
+	var tier string
+	err := tx.QueryRow(ctx, query, walletID).Scan(&tier)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", wallet.ErrWalletNotFound
+		}
+		return "", err
+	}
+
+	return tier, nil
+}
+
+// debit takes amount off the wallet, spending only the given buckets, or any bucket when
+// buckets is nil, in spending order.
+func (s *Storage) debit(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, entryType wallet.EntryType, amount int64, buckets, order []string) (int64, error) {
+	balance, err := s.updateActiveBalance(ctx, tx, walletID, -amount)
+	if err != nil || balance < 0 {
+		return balance, err
+	}
+
+	debits, lots, err := s.planDebits(ctx, tx, walletID, amount, buckets, order)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, lot := range lots {
+		if err := s.debitLot(ctx, tx, lot.id, lot.amount); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, debit := range debits {
+		if err := s.updateBucket(ctx, tx, walletID, debit.bucket, -debit.amount); err != nil {
+			return 0, err
+		}
+
+		if err := s.addEntry(ctx, tx, walletID, debit.bucket, entryType, -debit.amount); err != nil {
+			return 0, err
+		}
+	}
+
+	return balance, nil
+}
+
+// DepositBatch applies several deposits to one wallet bucket with a single balance update
+// and returns the balance observed after each of them, in order.
+func (s *Storage) DepositBatch(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, bucket string, amounts []int64) ([]int64, error) {
+	var total int64
+	for _, amount := range amounts {
+		total += amount
+	}
+
+	balance, err := s.updateActiveBalance(ctx, tx, walletID, total)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.updateBucket(ctx, tx, walletID, bucket, total); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO wallet_entries (wallet_id, bucket, type, amount)
+		SELECT $1, $2, $3, unnest($4::BIGINT[]);
+		`
+
+	if _, err := tx.Exec(ctx, query, walletID, bucket, wallet.EntryDeposit, amounts); err != nil {
+		return nil, err
+	}
+
+	balances := make([]int64, len(amounts))
+	running := balance - total
+	for i, amount := range amounts {
+		running += amount
+		balances[i] = running
+	}
+
+	return balances, nil
 }
 
 func (s *Storage) updateBalance(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, delta int64) (int64, error) {
@@ -56,3 +262,298 @@ func (s *Storage) updateBalance(ctx context.Context, tx pgx.Tx, walletID uuid.UU
 
 	return balance, nil
 }
+
+// updateActiveBalance changes the balance the way updateBalance does, but only of an active
+// wallet; customer operations use it, while fees, expiry and interest move funds regardless.
+func (s *Storage) updateActiveBalance(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, delta int64) (int64, error) {
+	query := `
+		UPDATE wallets
+		SET balance = balance + $1
+		WHERE id = $2 AND status = 'ACTIVE'
+		RETURNING balance;
+		`
+
+	var balance int64
+	err := tx.QueryRow(ctx, query, delta, walletID).Scan(&balance)
+	if err == nil {
+		return balance, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM wallets WHERE id = $1);`, walletID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, wallet.ErrWalletFrozen
+	}
+
+	return 0, wallet.ErrWalletNotFound
+}
+
+func (s *Storage) updateBucket(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, bucket string, delta int64) error {
+	query := `
+		INSERT INTO wallet_buckets (wallet_id, name, balance)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (wallet_id, name) DO UPDATE
+		SET balance = wallet_buckets.balance + EXCLUDED.balance;
+		`
+
+	_, err := tx.Exec(ctx, query, walletID, bucket, delta)
+	return err
+}
+
+// addEntry journals a balance change of the wallet.
+func (s *Storage) addEntry(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, bucket string, entryType wallet.EntryType, amount int64) error {
+	query := `
+		INSERT INTO wallet_entries (wallet_id, bucket, type, amount)
+		VALUES ($1, $2, $3, $4);
+		`
+
+	_, err := tx.Exec(ctx, query, walletID, bucket, entryType, amount)
+	return err
+}
+
+// ExpireLots takes the unspent part of up to limit expired credits off their wallets
+// and journals it as EXPIRE entries. Lots locked by concurrent transactions are skipped.
+func (s *Storage) ExpireLots(ctx context.Context, tx pgx.Tx, limit int) ([]wallet.Expiry, error) {
+	query := `
+		SELECT id, wallet_id, bucket, remaining
+		FROM wallet_lots
+		WHERE remaining > 0 AND expires_at <= now()
+		ORDER BY expires_at, id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED;
+		`
+
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	type expiredLot struct {
+		id int64
+		wallet.Expiry
+	}
+
+	lots, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (expiredLot, error) {
+		var l expiredLot
+		err := row.Scan(&l.id, &l.WalletID, &l.Bucket, &l.Amount)
+		return l, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expired := make([]wallet.Expiry, 0, len(lots))
+	for _, l := range lots {
+		if _, err := s.updateBalance(ctx, tx, l.WalletID, -l.Amount); err != nil {
+			return nil, err
+		}
+
+		if err := s.updateBucket(ctx, tx, l.WalletID, l.Bucket, -l.Amount); err != nil {
+			return nil, err
+		}
+
+		if err := s.debitLot(ctx, tx, l.id, l.Amount); err != nil {
+			return nil, err
+		}
+
+		if err := s.addEntry(ctx, tx, l.WalletID, l.Bucket, wallet.EntryExpire, -l.Amount); err != nil {
+			return nil, err
+		}
+
+		expired = append(expired, l.Expiry)
+	}
+
+	return expired, nil
+}
+
+func (s *Storage) addLot(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, credit wallet.Credit) error {
+	query := `
+		INSERT INTO wallet_lots (wallet_id, bucket, remaining, expires_at)
+		VALUES ($1, $2, $3, $4);
+		`
+
+	_, err := tx.Exec(ctx, query, walletID, credit.Bucket, credit.Amount, *credit.ExpiresAt)
+	return err
+}
+
+func (s *Storage) debitLot(ctx context.Context, tx pgx.Tx, lotID int64, amount int64) error {
+	query := `
+		UPDATE wallet_lots
+		SET remaining = remaining - $1
+		WHERE id = $2;
+		`
+
+	_, err := tx.Exec(ctx, query, amount, lotID)
+	return err
+}
+
+type bucketDebit struct {
+	bucket string
+	amount int64
+}
+
+type lotDebit struct {
+	id     int64
+	amount int64
+}
+
+type lot struct {
+	id        int64
+	bucket    string
+	remaining int64
+	expired   bool
+}
+
+// planDebits splits amount across the wallet's expiring credits and buckets, restricted to
+// buckets unless it is nil. Unexpired credits are spent first, soonest-expiring first, then
+// buckets in spending order. Credits that already expired but were not swept yet are not
+// spendable.
+func (s *Storage) planDebits(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, amount int64, buckets, order []string) ([]bucketDebit, []lotDebit, error) {
+	spendable, err := s.lockBuckets(ctx, tx, walletID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lots, err := s.lockLots(ctx, tx, walletID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if buckets != nil {
+		maps.DeleteFunc(spendable, func(name string, _ int64) bool { return !slices.Contains(buckets, name) })
+		lots = slices.DeleteFunc(lots, func(l lot) bool { return !slices.Contains(buckets, l.bucket) })
+	}
+
+	for _, l := range lots {
+		if l.expired {
+			spendable[l.bucket] -= l.remaining
+		}
+	}
+
+	var (
+		debits    []bucketDebit
+		lotDebits []lotDebit
+		index     = make(map[string]int)
+	)
+	debit := func(bucket string, amount int64) {
+		spendable[bucket] -= amount
+		if i, ok := index[bucket]; ok {
+			debits[i].amount += amount
+			return
+		}
+		index[bucket] = len(debits)
+		debits = append(debits, bucketDebit{bucket: bucket, amount: amount})
+	}
+
+	for _, l := range lots {
+		if amount == 0 {
+			break
+		}
+		if l.expired {
+			continue
+		}
+
+		d := min(l.remaining, amount)
+		lotDebits = append(lotDebits, lotDebit{id: l.id, amount: d})
+		debit(l.bucket, d)
+		amount -= d
+	}
+
+	for _, bucket := range spendingOrder(spendable, order) {
+		if amount == 0 {
+			break
+		}
+		if spendable[bucket] <= 0 {
+			continue
+		}
+
+		d := min(spendable[bucket], amount)
+		debit(bucket, d)
+		amount -= d
+	}
+
+	if amount > 0 {
+		return nil, nil, wallet.ErrNotEnoughMoney
+	}
+
+	return debits, lotDebits, nil
+}
+
+func (s *Storage) lockBuckets(ctx context.Context, tx pgx.Tx, walletID uuid.UUID) (map[string]int64, error) {
+	query := `
+		SELECT name, balance
+		FROM wallet_buckets
+		WHERE wallet_id = $1 AND balance > 0
+		FOR UPDATE;
+		`
+
+	rows, err := tx.Query(ctx, query, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := make(map[string]int64)
+	for rows.Next() {
+		var (
+			name    string
+			balance int64
+		)
+		if err := rows.Scan(&name, &balance); err != nil {
+			return nil, err
+		}
+		buckets[name] = balance
+	}
+
+	return buckets, rows.Err()
+}
+
+func (s *Storage) lockLots(ctx context.Context, tx pgx.Tx, walletID uuid.UUID) ([]lot, error) {
+	query := `
+		SELECT id, bucket, remaining, expires_at <= now()
+		FROM wallet_lots
+		WHERE wallet_id = $1 AND remaining > 0
+		ORDER BY expires_at, id
+		FOR UPDATE;
+		`
+
+	rows, err := tx.Query(ctx, query, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (lot, error) {
+		var l lot
+		err := row.Scan(&l.id, &l.bucket, &l.remaining, &l.expired)
+		return l, err
+	})
+}
+
+// spendingOrder sorts bucket names by their position in order. Buckets missing from
+// order go last, by name.
+func spendingOrder(buckets map[string]int64, order []string) []string {
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		rank[name] = i
+	}
+
+	names := slices.Collect(maps.Keys(buckets))
+	slices.SortFunc(names, func(a, b string) int {
+		ra, ok := rank[a]
+		if !ok {
+			ra = len(order)
+		}
+		rb, ok := rank[b]
+		if !ok {
+			rb = len(order)
+		}
+		return cmp.Or(cmp.Compare(ra, rb), cmp.Compare(a, b))
+	})
+
+	return names
+}
@@ -2,10 +2,12 @@ package postgres_test
 
 import (
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/require"
 	"regexp"
 	"testing"
+	"time"
 	"wallet/internal/model/wallet"
 	"wallet/internal/repository/postgres"
 )
@@ -13,27 +15,76 @@ import (
 func TestStorage_Withdraw(t *testing.T) {
 	t.Parallel()
 
-	ctx := t.Context()
 	walletID := uuid.New()
 
-	mockPool, err := pgxmock.NewPool()
-	require.NoError(t, err)
-	defer mockPool.Close()
-
-	storage := postgres.New(mockPool)
-
 	tests := []struct {
 		name            string
 		amount          int64
+		order           []string // order defaults to the main bucket only
+		buckets         map[string]int64
+		lots            []lotRow
+		expectedLots    []lotChange
+		expectedDebits  []bucketChange
+		frozen          bool
+		spendError      error // spendError fails the debit after the balance is updated
 		expectedError   error
 		expectedBalance int64
 	}{
 		{
 			name:            "successful withdraw",
 			amount:          100,
+			buckets:         map[string]int64{wallet.BucketMain: 1000},
+			expectedDebits:  []bucketChange{{wallet.BucketMain, 100}},
+			expectedError:   nil,
+			expectedBalance: 900,
+		},
+		{
+			name:            "spends only the main bucket",
+			amount:          100,
+			buckets:         map[string]int64{wallet.BucketMain: 940, "bonus": 30, "cashback": 30},
+			expectedDebits:  []bucketChange{{wallet.BucketMain, 100}},
+			expectedError:   nil,
+			expectedBalance: 900,
+		},
+		{
+			name:            "spends buckets in withdrawal order",
+			amount:          100,
+			order:           []string{"cashback", wallet.BucketMain},
+			buckets:         map[string]int64{wallet.BucketMain: 940, "bonus": 30, "cashback": 30},
+			expectedDebits:  []bucketChange{{"cashback", 30}, {wallet.BucketMain, 70}},
 			expectedError:   nil,
 			expectedBalance: 900,
 		},
+		{
+			name:            "promotional credit is not withdrawable",
+			amount:          100,
+			buckets:         map[string]int64{"bonus": 1000},
+			spendError:      wallet.ErrNotEnoughMoney,
+			expectedBalance: 900,
+		},
+		{
+			name:    "spends soonest-expiring main credit first",
+			amount:  100,
+			buckets: map[string]int64{wallet.BucketMain: 900, "cashback": 100},
+			lots: []lotRow{
+				{id: 2, bucket: "cashback", remaining: 60},
+				{id: 1, bucket: wallet.BucketMain, remaining: 20},
+			},
+			expectedLots:    []lotChange{{1, 20}},
+			expectedDebits:  []bucketChange{{wallet.BucketMain, 100}},
+			expectedError:   nil,
+			expectedBalance: 900,
+		},
+		{
+			name:    "expired credit is not spendable",
+			amount:  100,
+			buckets: map[string]int64{wallet.BucketMain: 150},
+			lots: []lotRow{
+				{id: 1, bucket: wallet.BucketMain, remaining: 100, expired: true},
+			},
+			spendError:      wallet.ErrNotEnoughMoney,
+			expectedBalance: 50,
+		},
 		{
 			name:            "negative balance",
 			amount:          1000,
@@ -46,92 +97,207 @@ func TestStorage_Withdraw(t *testing.T) {
 			expectedError:   wallet.ErrWalletNotFound,
 			expectedBalance: 0,
 		},
+		{
+			name:            "frozen wallet",
+			amount:          100,
+			frozen:          true,
+			expectedError:   wallet.ErrWalletFrozen,
+			expectedBalance: 0,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
+			ctx := t.Context()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
 			mockPool.ExpectBegin()
 			mockTx, err := mockPool.Begin(ctx)
 			require.NoError(t, err)
 
+			updateErr := tt.expectedError
+			if tt.frozen {
+				updateErr = pgx.ErrNoRows
+			}
 			mockPool.ExpectQuery(regexp.QuoteMeta(`
 				UPDATE wallets
 				SET balance = balance + $1
-				WHERE id = $2
+				WHERE id = $2 AND status = 'ACTIVE'
 				RETURNING balance;
 			`)).
 				WithArgs(-tt.amount, walletID).
 				WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(tt.expectedBalance)).
-				WillReturnError(tt.expectedError)
+				WillReturnError(updateErr)
+			if tt.frozen {
+				mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+					WithArgs(walletID).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			}
 
-			balance, err := storage.Withdraw(ctx, mockTx, walletID, tt.amount)
+			if tt.expectedError == nil && tt.expectedBalance >= 0 {
+				rows := pgxmock.NewRows([]string{"name", "balance"})
+				for name, balance := range tt.buckets {
+					rows.AddRow(name, balance)
+				}
+				mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_buckets`)).
+					WithArgs(walletID).
+					WillReturnRows(rows)
+
+				lots := pgxmock.NewRows([]string{"id", "bucket", "remaining", "expired"})
+				for _, l := range tt.lots {
+					lots.AddRow(l.id, l.bucket, l.remaining, l.expired)
+				}
+				mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_lots`)).
+					WithArgs(walletID).
+					WillReturnRows(lots)
+
+				for _, l := range tt.expectedLots {
+					mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE wallet_lots`)).
+						WithArgs(l.amount, l.id).
+						WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				}
+
+				for _, debit := range tt.expectedDebits {
+					expectBucketChange(mockPool, walletID, debit.bucket, wallet.EntryWithdraw, -debit.amount)
+				}
+			}
 
-			if tt.expectedError != nil {
+			order := tt.order
+			if order == nil {
+				order = []string{wallet.BucketMain}
+			}
+
+			balance, err := storage.Withdraw(ctx, mockTx, walletID, tt.amount, order)
+
+			switch {
+			case tt.spendError != nil:
+				require.ErrorIs(t, err, tt.spendError)
+				require.NoError(t, mockPool.ExpectationsWereMet())
+				return
+			case tt.expectedError != nil:
 				require.ErrorIs(t, err, tt.expectedError)
-			} else {
+			default:
 				require.NoError(t, err)
 			}
 
 			require.Equal(t, tt.expectedBalance, balance)
-
-			_ = mockTx.Rollback(ctx)
+			require.NoError(t, mockPool.ExpectationsWereMet())
 		})
 	}
-
-	require.NoError(t, mockPool.ExpectationsWereMet())
 }
 
 func TestStorage_Deposit(t *testing.T) {
 	t.Parallel()
 
-	ctx := t.Context()
 	walletID := uuid.New()
-
-	mockPool, err := pgxmock.NewPool()
-	require.NoError(t, err)
-	defer mockPool.Close()
-
-	storage := postgres.New(mockPool)
+	expiresAt := time.Now().Add(time.Hour)
 
 	tests := []struct {
 		name            string
+		bucket          string
 		amount          int64
+		expiresAt       *time.Time
+		frozen          bool
 		expectedError   error
 		expectedBalance int64
 	}{
 		{
 			name:            "successful deposit",
+			bucket:          wallet.BucketMain,
+			amount:          100,
+			expectedError:   nil,
+			expectedBalance: 900,
+		},
+		{
+			name:            "deposit to bonus bucket",
+			bucket:          "bonus",
 			amount:          100,
 			expectedError:   nil,
 			expectedBalance: 900,
 		},
+		{
+			name:            "expiring deposit",
+			bucket:          "bonus",
+			amount:          100,
+			expiresAt:       &expiresAt,
+			expectedError:   nil,
+			expectedBalance: 900,
+		},
 		{
 			name:            "wallet not found",
+			bucket:          wallet.BucketMain,
 			amount:          100,
 			expectedError:   wallet.ErrWalletNotFound,
 			expectedBalance: 0,
 		},
+		{
+			name:            "frozen wallet",
+			bucket:          wallet.BucketMain,
+			amount:          100,
+			frozen:          true,
+			expectedError:   wallet.ErrWalletFrozen,
+			expectedBalance: 0,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
 			mockPool.ExpectBegin()
 			mockTx, err := mockPool.Begin(ctx)
 			require.NoError(t, err)
 
+			updateErr := tt.expectedError
+			if tt.frozen {
+				updateErr = pgx.ErrNoRows
+			}
 			mockPool.ExpectQuery(regexp.QuoteMeta(`
 				UPDATE wallets
 				SET balance = balance + $1
-				WHERE id = $2
+				WHERE id = $2 AND status = 'ACTIVE'
 				RETURNING balance;
 			`)).
 				WithArgs(tt.amount, walletID).
 				WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(tt.expectedBalance)).
-				WillReturnError(tt.expectedError)
+				WillReturnError(updateErr)
+			if tt.frozen {
+				mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS`)).
+					WithArgs(walletID).
+					WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+			}
+
+			if tt.expectedError == nil {
+				mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_buckets`)).
+					WithArgs(walletID, tt.bucket, tt.amount).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				if tt.expiresAt != nil {
+					mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_lots`)).
+						WithArgs(walletID, tt.bucket, tt.amount, *tt.expiresAt).
+						WillReturnResult(pgxmock.NewResult("INSERT", 1))
+				}
+				mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_entries`)).
+					WithArgs(walletID, tt.bucket, wallet.EntryDeposit, tt.amount).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			}
 
-			balance, err := storage.Deposit(ctx, mockTx, walletID, tt.amount)
+			credit := wallet.Credit{Bucket: tt.bucket, Amount: tt.amount, ExpiresAt: tt.expiresAt}
+			balance, err := storage.Deposit(ctx, mockTx, walletID, credit)
 
 			if tt.expectedError != nil {
 				require.ErrorIs(t, err, tt.expectedError)
@@ -140,12 +306,9 @@ func TestStorage_Deposit(t *testing.T) {
 			}
 
 			require.Equal(t, tt.expectedBalance, balance)
-
-			_ = mockTx.Rollback(ctx)
+			require.NoError(t, mockPool.ExpectationsWereMet())
 		})
 	}
-
-	require.NoError(t, mockPool.ExpectationsWereMet())
 }
 
 func TestStorage_GetBalance(t *testing.T) {
@@ -163,31 +326,52 @@ func TestStorage_GetBalance(t *testing.T) {
 
 	tests := []struct {
 		name            string
+		rows            *pgxmock.Rows
 		expectedError   error
-		expectedBalance int64
+		expectedBalance wallet.Balance
 	}{
 		{
-			name:            "successful get balance",
-			expectedError:   nil,
-			expectedBalance: 100,
+			name: "successful get balance",
+			rows: pgxmock.NewRows([]string{"balance", "version", "metadata", "name", "balance"}).
+				AddRow(int64(100), int64(7), wallet.Metadata{"customerId": "c-42"}, ptr(wallet.BucketMain), ptr(int64(70))).
+				AddRow(int64(100), int64(7), wallet.Metadata{"customerId": "c-42"}, ptr("bonus"), ptr(int64(30))),
+			expectedError: nil,
+			expectedBalance: wallet.Balance{
+				Total:    100,
+				Buckets:  map[string]int64{wallet.BucketMain: 70, "bonus": 30},
+				Metadata: wallet.Metadata{"customerId": "c-42"},
+				Version:  7,
+			},
+		},
+		{
+			name: "wallet without buckets",
+			rows: pgxmock.NewRows([]string{"balance", "version", "metadata", "name", "balance"}).
+				AddRow(int64(0), int64(0), wallet.Metadata{}, (*string)(nil), (*int64)(nil)),
+			expectedError: nil,
+			expectedBalance: wallet.Balance{
+				Total:    0,
+				Buckets:  map[string]int64{},
+				Metadata: wallet.Metadata{},
+			},
 		},
 		{
 			name:            "wallet not found",
+			rows:            pgxmock.NewRows([]string{"balance", "version", "metadata", "name", "balance"}),
 			expectedError:   wallet.ErrWalletNotFound,
-			expectedBalance: 0,
+			expectedBalance: wallet.Balance{},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockPool.ExpectQuery(regexp.QuoteMeta(`
-				SELECT balance 
-				FROM wallets 
-				WHERE id = $1
+				SELECT w.balance, w.version, w.metadata, b.name, b.balance
+				FROM wallets w
+				LEFT JOIN wallet_buckets b ON b.wallet_id = w.id
+				WHERE w.id = $1
 			`)).
 				WithArgs(walletID).
-				WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(tt.expectedBalance)).
-				WillReturnError(tt.expectedError)
+				WillReturnRows(tt.rows)
 
 			balance, err := storage.GetBalance(ctx, walletID)
 
@@ -204,3 +388,227 @@ func TestStorage_GetBalance(t *testing.T) {
 		})
 	}
 }
+
+func TestStorage_DepositBatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	walletID := uuid.New()
+	amounts := []int64{100, 20, 5}
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectBegin()
+	mockTx, err := mockPool.Begin(ctx)
+	require.NoError(t, err)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+		WithArgs(int64(125), walletID).
+		WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(int64(1125)))
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_buckets`)).
+		WithArgs(walletID, wallet.BucketMain, int64(125)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_entries`)).
+		WithArgs(walletID, wallet.BucketMain, wallet.EntryDeposit, amounts).
+		WillReturnResult(pgxmock.NewResult("INSERT", 3))
+
+	balances, err := storage.DepositBatch(ctx, mockTx, walletID, wallet.BucketMain, amounts)
+	require.NoError(t, err)
+	require.Equal(t, []int64{1100, 1120, 1125}, balances)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestStorage_ExpireLots(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	walletID := uuid.New()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectBegin()
+	mockTx, err := mockPool.Begin(ctx)
+	require.NoError(t, err)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_lots`)).
+		WithArgs(10).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "wallet_id", "bucket", "remaining"}).
+			AddRow(int64(7), walletID, "bonus", int64(40)))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+		WithArgs(int64(-40), walletID).
+		WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(int64(60)))
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_buckets`)).
+		WithArgs(walletID, "bonus", int64(-40)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`UPDATE wallet_lots`)).
+		WithArgs(int64(40), int64(7)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mockPool.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_entries`)).
+		WithArgs(walletID, "bonus", wallet.EntryExpire, int64(-40)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	expired, err := storage.ExpireLots(ctx, mockTx, 10)
+	require.NoError(t, err)
+	require.Equal(t, []wallet.Expiry{{WalletID: walletID, Bucket: "bonus", Amount: 40}}, expired)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestStorage_ChargeFee(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	walletID := uuid.New()
+	feeWalletID := uuid.New()
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectBegin()
+	mockTx, err := mockPool.Begin(ctx)
+	require.NoError(t, err)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+		WithArgs(int64(-5), walletID).
+		WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(int64(95)))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_buckets`)).
+		WithArgs(walletID).
+		WillReturnRows(pgxmock.NewRows([]string{"name", "balance"}).AddRow(wallet.BucketMain, int64(97)).AddRow("bonus", int64(3)))
+	mockPool.ExpectQuery(regexp.QuoteMeta(`FROM wallet_lots`)).
+		WithArgs(walletID).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "bucket", "remaining", "expired"}))
+	// fees spend promotional credit first
+	expectBucketChange(mockPool, walletID, "bonus", wallet.EntryFee, -3)
+	expectBucketChange(mockPool, walletID, wallet.BucketMain, wallet.EntryFee, -2)
+	mockPool.ExpectQuery(regexp.QuoteMeta(`UPDATE wallets`)).
+		WithArgs(int64(5), feeWalletID).
+		WillReturnRows(pgxmock.NewRows([]string{"balance"}).AddRow(int64(1005)))
+	expectBucketChange(mockPool, feeWalletID, wallet.BucketMain, wallet.EntryFee, 5)
+
+	balance, err := storage.ChargeFee(ctx, mockTx, walletID, feeWalletID, 5, []string{"bonus", wallet.BucketMain})
+	require.NoError(t, err)
+	require.Equal(t, int64(95), balance)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+func TestStorage_HotWallets(t *testing.T) {
+	t.Parallel()
+
+	hot := []uuid.UUID{uuid.New(), uuid.New()}
+
+	mockPool, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mockPool.Close()
+
+	storage := postgres.New(mockPool)
+
+	mockPool.ExpectQuery(regexp.QuoteMeta(`SELECT id`)).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(hot[0]).AddRow(hot[1]))
+
+	ids, err := storage.HotWallets(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, hot, ids)
+
+	require.NoError(t, mockPool.ExpectationsWereMet())
+}
+
+type lotRow struct {
+	id        int64
+	bucket    string
+	remaining int64
+	expired   bool
+}
+
+type lotChange struct {
+	id     int64
+	amount int64
+}
+
+type bucketChange struct {
+	bucket string
+	amount int64
+}
+
+func expectBucketChange(mock pgxmock.PgxPoolIface, walletID uuid.UUID, bucket string, entryType wallet.EntryType, delta int64) {
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_buckets`)).
+		WithArgs(walletID, bucket, delta).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO wallet_entries`)).
+		WithArgs(walletID, bucket, entryType, delta).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestStorage_CheckVersion(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+
+	tests := []struct {
+		name          string
+		rows          *pgxmock.Rows
+		expectedError error
+	}{
+		{
+			name: "same version",
+			rows: pgxmock.NewRows([]string{"version"}).AddRow(int64(7)),
+		},
+		{
+			name:          "changed version",
+			rows:          pgxmock.NewRows([]string{"version"}).AddRow(int64(8)),
+			expectedError: wallet.ErrVersionMismatch,
+		},
+		{
+			name:          "wallet not found",
+			rows:          pgxmock.NewRows([]string{"version"}),
+			expectedError: wallet.ErrWalletNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := t.Context()
+
+			mockPool, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mockPool.Close()
+
+			storage := postgres.New(mockPool)
+
+			mockPool.ExpectBegin()
+			mockTx, err := mockPool.Begin(ctx)
+			require.NoError(t, err)
+
+			mockPool.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+				WithArgs(walletID).
+				WillReturnRows(tt.rows)
+
+			err = storage.CheckVersion(ctx, mockTx, walletID, 7)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.NoError(t, mockPool.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package rest
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	model "wallet/internal/model/handler"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+type principalKey struct{}
+
+// Principal returns the admin authenticated by AdminAuth, or an empty string.
+func Principal(ctx context.Context) string {
+	p, _ := ctx.Value(principalKey{}).(string)
+	return p
+}
+
+// AdminAuth lets through requests carrying one of the bearer tokens, keyed by principal,
+// and makes the principal available to the next handler through Principal.
+func AdminAuth(tokens map[string]string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			for principal, t := range tokens {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+					if p, ok := r.Context().Value(auditPrincipalKey{}).(*string); ok {
+						*p = principal
+					}
+					next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+					return
+				}
+			}
+		}
+
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeProblem(w, r, http.StatusUnauthorized, CodeUnauthorized, "missing or unknown admin token", nil)
+	})
+}
+
+// Reconcile checks every wallet balance against the journal and reports the discrepancies.
+func (h *WalletHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	report, err := h.svc.Reconcile(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := model.ReconciliationResponse{
+		CheckedAt:         report.CheckedAt,
+		MismatchedWallets: report.MismatchedWallets,
+		Discrepancies:     make([]model.DiscrepancyResponse, 0, len(report.Discrepancies)),
+	}
+	for _, d := range report.Discrepancies {
+		resp.Discrepancies = append(resp.Discrepancies, model.DiscrepancyResponse{
+			WalletID: d.WalletID,
+			Bucket:   d.Bucket,
+			Balance:  d.Balance,
+			Journal:  d.Journal,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CreateWallet opens an active wallet.
+func (h *WalletHandler) CreateWallet(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateWalletRequest
+	violations, err := decodeStrict(w, r, &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if req.Currency == "" {
+		req.Currency = "USD"
+	}
+
+	if !currencyCode.MatchString(req.Currency) {
+		violations = append(violations, model.Field("currency", model.ErrInvalidCurrency))
+	}
+
+	if err := model.ValidateMetadata(req.Metadata); err != nil {
+		violations = append(violations, model.Field("metadata", err))
+	}
+
+	if err := joinViolations(violations...); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	created, err := h.svc.CreateWallet(r.Context(), wallet.Wallet{
+		ID:       req.WalletID,
+		OwnerID:  req.OwnerID,
+		Currency: req.Currency,
+		Metadata: req.Metadata,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	json.NewEncoder(w).Encode(walletResponse(created))
+}
+
+// FreezeWallet makes the wallet reject deposits, withdrawals and transfers.
+func (h *WalletHandler) FreezeWallet(w http.ResponseWriter, r *http.Request) {
+	h.setStatus(w, r, wallet.StatusFrozen)
+}
+
+// UnfreezeWallet makes a frozen wallet active again.
+func (h *WalletHandler) UnfreezeWallet(w http.ResponseWriter, r *http.Request) {
+	h.setStatus(w, r, wallet.StatusActive)
+}
+
+func (h *WalletHandler) setStatus(w http.ResponseWriter, r *http.Request, status wallet.Status) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, model.Field("id", model.ErrInvalidRequest))
+		return
+	}
+
+	if err := h.svc.SetStatus(r.Context(), walletID, status); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
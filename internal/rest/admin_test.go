@@ -0,0 +1,251 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminAuth(t *testing.T) {
+	t.Parallel()
+
+	tokens := map[string]string{"alice": "secret-a", "bob": "secret-b"}
+
+	tests := []struct {
+		name              string
+		authorization     string
+		expectedStatus    int
+		expectedPrincipal string
+	}{
+		{
+			name:              "valid token",
+			authorization:     "Bearer secret-b",
+			expectedStatus:    http.StatusOK,
+			expectedPrincipal: "bob",
+		},
+		{
+			name:           "unknown token",
+			authorization:  "Bearer secret-c",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "not a bearer token",
+			authorization:  "Basic secret-a",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "no token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var principal string
+			handler := rest.AdminAuth(tokens, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				principal = rest.Principal(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/reconciliation", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			require.Equal(t, tt.expectedPrincipal, principal)
+			if tt.expectedStatus == http.StatusUnauthorized {
+				require.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestWalletHandler_Reconcile(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	walletID := uuid.New()
+	checkedAt := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	svc.EXPECT().
+		Reconcile(gomock.Any()).
+		Return(walletModel.Reconciliation{
+			CheckedAt:         checkedAt,
+			MismatchedWallets: 1,
+			Discrepancies:     []walletModel.Discrepancy{{WalletID: walletID, Balance: 1000, Journal: 900}},
+		}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/reconciliation", nil)
+	rec := httptest.NewRecorder()
+
+	handler.Reconcile(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp handlerModel.ReconciliationResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Equal(t, handlerModel.ReconciliationResponse{
+		CheckedAt:         checkedAt,
+		MismatchedWallets: 1,
+		Discrepancies:     []handlerModel.DiscrepancyResponse{{WalletID: walletID, Balance: 1000, Journal: 900}},
+	}, resp)
+}
+
+func TestWalletHandler_CreateWallet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		body           string
+		contentType    string
+		serviceError   error
+		expectCall     bool
+		expectedStatus int
+	}{
+		{
+			name:           "wallet created",
+			body:           `{"ownerId":"u-1","metadata":{"tier":"gold"}}`,
+			expectCall:     true,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "wallet exists",
+			body:           `{"walletId":"c8b43e22-3cc0-4647-b18b-53fba78d6fed","ownerId":"u-1","metadata":{"tier":"gold"}}`,
+			serviceError:   walletModel.ErrWalletExists,
+			expectCall:     true,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "invalid currency",
+			body:           `{"currency":"usd"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid body",
+			body:           `{`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown field",
+			body:           `{"ownerId":"u-1","balance":100}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not json",
+			body:           `{"ownerId":"u-1"}`,
+			contentType:    "text/plain",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			handler := rest.NewWalletHandler(svc)
+
+			walletID := uuid.New()
+			if tt.expectCall {
+				svc.EXPECT().
+					CreateWallet(gomock.Any(), gomock.Cond(func(w walletModel.Wallet) bool {
+						return w.Currency == "USD" && *w.OwnerID == "u-1" && w.Metadata["tier"] == "gold"
+					})).
+					DoAndReturn(func(_ context.Context, w walletModel.Wallet) (walletModel.Wallet, error) {
+						if tt.serviceError != nil {
+							return walletModel.Wallet{}, tt.serviceError
+						}
+						w.ID = walletID
+						w.Status = walletModel.StatusActive
+						return w, nil
+					})
+			}
+
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/wallets", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", contentType)
+			rec := httptest.NewRecorder()
+
+			handler.CreateWallet(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var resp handlerModel.WalletResponse
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				require.Equal(t, walletID, resp.WalletID)
+				require.Equal(t, "ACTIVE", resp.Status)
+				require.Equal(t, "USD", resp.Currency)
+			}
+		})
+	}
+}
+
+func TestWalletHandler_FreezeWallet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		serviceError   error
+		expectedStatus int
+	}{
+		{
+			name:           "wallet frozen",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "wallet not found",
+			serviceError:   walletModel.ErrWalletNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			handler := rest.NewWalletHandler(svc)
+
+			walletID := uuid.New()
+			svc.EXPECT().
+				SetStatus(gomock.Any(), walletID, walletModel.StatusFrozen).
+				Return(tt.serviceError)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/wallets/"+walletID.String()+"/freeze", nil)
+			req.SetPathValue("id", walletID.String())
+			rec := httptest.NewRecorder()
+
+			handler.FreezeWallet(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
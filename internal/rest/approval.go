@@ -0,0 +1,230 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	model "wallet/internal/model/handler"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// maxReasonLength bounds the reason stored with an adjustment and the note of a decision.
+const maxReasonLength = 500
+
+// Adjustment request listing page sizes.
+const (
+	defaultAdjustmentsLimit = 100
+	maxAdjustmentsLimit     = 1000
+)
+
+// ProposeAdjustment requests a correction of a wallet bucket by a positive or negative amount.
+// The request is attributed to the authenticated admin and applied only once another admin
+// approves it.
+func (h *WalletHandler) ProposeAdjustment(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, model.Field("id", model.ErrInvalidRequest))
+		return
+	}
+
+	var req model.AdjustmentRequest
+	violations, err := decodeStrict(w, r, &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if req.Amount == 0 {
+		violations = append(violations, model.Field("amount", model.ErrInvalidAmount))
+	}
+
+	if req.Bucket == "" {
+		req.Bucket = wallet.BucketMain
+	}
+
+	if !model.BucketName.MatchString(req.Bucket) {
+		violations = append(violations, model.Field("bucket", model.ErrInvalidBucket))
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" || len(req.Reason) > maxReasonLength {
+		violations = append(violations, model.Field("reason", model.ErrInvalidReason))
+	}
+
+	if err := joinViolations(violations...); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	proposed, err := h.svc.ProposeAdjustment(r.Context(), wallet.Adjustment{
+		WalletID:  walletID,
+		Bucket:    req.Bucket,
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+		CreatedBy: Principal(r.Context()),
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	json.NewEncoder(w).Encode(adjustmentRequestResponse(proposed))
+}
+
+// ApproveAdjustment approves a pending adjustment request and applies it. The admin who
+// proposed the request cannot approve it.
+func (h *WalletHandler) ApproveAdjustment(w http.ResponseWriter, r *http.Request) {
+	requestID, note, err := adjustmentDecision(w, r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	req, balance, err := h.svc.ApproveAdjustment(r.Context(), requestID, Principal(r.Context()), note)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := adjustmentRequestResponse(req)
+	resp.Balance = &balance
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// RejectAdjustment rejects a pending adjustment request. The admin who proposed the request
+// cannot reject it.
+func (h *WalletHandler) RejectAdjustment(w http.ResponseWriter, r *http.Request) {
+	requestID, note, err := adjustmentDecision(w, r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	req, err := h.svc.RejectAdjustment(r.Context(), requestID, Principal(r.Context()), note)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(adjustmentRequestResponse(req))
+}
+
+// ListAdjustments returns adjustment requests, newest first, optionally only those in status.
+func (h *WalletHandler) ListAdjustments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	status := wallet.AdjustmentStatus(query.Get("status"))
+	switch status {
+	case "", wallet.AdjustmentPending, wallet.AdjustmentApproved, wallet.AdjustmentRejected:
+	default:
+		h.handleError(w, r, model.Field("status", model.ErrInvalidRequest))
+		return
+	}
+
+	limit := defaultAdjustmentsLimit
+	if v := query.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxAdjustmentsLimit {
+			h.handleError(w, r, model.Field("limit", model.ErrInvalidRequest))
+			return
+		}
+	}
+
+	reqs, err := h.svc.AdjustmentRequests(r.Context(), status, limit)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := make([]model.AdjustmentRequestResponse, 0, len(reqs))
+	for _, req := range reqs {
+		resp = append(resp, adjustmentRequestResponse(req))
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetAdjustment returns an adjustment request with its audit trail.
+func (h *WalletHandler) GetAdjustment(w http.ResponseWriter, r *http.Request) {
+	requestID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, model.Field("id", model.ErrInvalidRequest))
+		return
+	}
+
+	req, events, err := h.svc.AdjustmentRequest(r.Context(), requestID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := model.AdjustmentDetailResponse{
+		AdjustmentRequestResponse: adjustmentRequestResponse(req),
+		Events:                    make([]model.AdjustmentEventResponse, 0, len(events)),
+	}
+	for _, e := range events {
+		resp.Events = append(resp.Events, model.AdjustmentEventResponse{
+			Action:    string(e.Action),
+			Principal: e.Principal,
+			Note:      e.Note,
+			CreatedAt: e.CreatedAt,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// adjustmentDecision reads the request id and the optional note of an approval or rejection.
+// The note is optional, and so is the body: requests without one decide without a note.
+func adjustmentDecision(w http.ResponseWriter, r *http.Request) (uuid.UUID, string, error) {
+	requestID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		return uuid.Nil, "", model.Field("id", model.ErrInvalidRequest)
+	}
+
+	var req model.AdjustmentDecisionRequest
+	var violations []error
+	if r.ContentLength != 0 {
+		if violations, err = decodeStrict(w, r, &req); err != nil {
+			return uuid.Nil, "", err
+		}
+	}
+
+	req.Note = strings.TrimSpace(req.Note)
+	if len(req.Note) > maxReasonLength {
+		violations = append(violations, model.Field("note", model.ErrInvalidRequest))
+	}
+
+	if err := joinViolations(violations...); err != nil {
+		return uuid.Nil, "", err
+	}
+
+	return requestID, req.Note, nil
+}
+
+func adjustmentRequestResponse(req wallet.AdjustmentRequest) model.AdjustmentRequestResponse {
+	return model.AdjustmentRequestResponse{
+		RequestID:   req.ID,
+		WalletID:    req.WalletID,
+		Bucket:      req.Bucket,
+		Amount:      req.Amount,
+		Reason:      req.Reason,
+		Status:      string(req.Status),
+		RequestedBy: req.CreatedBy,
+		RequestedAt: req.CreatedAt,
+		DecidedBy:   req.DecidedBy,
+		DecidedAt:   req.DecidedAt,
+		Note:        req.Note,
+	}
+}
@@ -0,0 +1,299 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var adminTokens = map[string]string{"alice": "secret-a", "bob": "secret-b"}
+
+func TestWalletHandler_ProposeAdjustment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		body           string
+		contentType    string
+		serviceError   error
+		expectCall     bool
+		expectedStatus int
+	}{
+		{
+			name:           "adjustment proposed",
+			body:           `{"amount":-30,"reason":"duplicate deposit"}`,
+			expectCall:     true,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "wallet not found",
+			body:           `{"amount":-30,"reason":"duplicate deposit"}`,
+			serviceError:   walletModel.ErrWalletNotFound,
+			expectCall:     true,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "missing reason",
+			body:           `{"amount":-30,"reason":"  "}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "zero amount",
+			body:           `{"amount":0,"reason":"duplicate deposit"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid bucket",
+			body:           `{"bucket":"Main!","amount":10,"reason":"duplicate deposit"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown field",
+			body:           `{"amount":-30,"reason":"duplicate deposit","approvedBy":"bob"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not json",
+			body:           `{"amount":-30,"reason":"duplicate deposit"}`,
+			contentType:    "text/plain",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "too large",
+			body:           `{"amount":-30,"reason":"` + strings.Repeat("x", 64<<10) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			handler := rest.AdminAuth(adminTokens, http.HandlerFunc(rest.NewWalletHandler(svc).ProposeAdjustment))
+
+			walletID := uuid.New()
+			if tt.expectCall {
+				svc.EXPECT().
+					ProposeAdjustment(gomock.Any(), walletModel.Adjustment{
+						WalletID:  walletID,
+						Bucket:    walletModel.BucketMain,
+						Amount:    -30,
+						Reason:    "duplicate deposit",
+						CreatedBy: "alice",
+					}).
+					DoAndReturn(func(_ context.Context, adj walletModel.Adjustment) (walletModel.AdjustmentRequest, error) {
+						adj.ID = uuid.New()
+						return walletModel.AdjustmentRequest{Adjustment: adj, Status: walletModel.AdjustmentPending}, tt.serviceError
+					})
+			}
+
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/wallets/"+walletID.String()+"/adjustments", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("Authorization", "Bearer secret-a")
+			req.SetPathValue("id", walletID.String())
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var resp handlerModel.AdjustmentRequestResponse
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				require.NotEqual(t, uuid.Nil, resp.RequestID)
+				require.Equal(t, "PENDING", resp.Status)
+				require.Equal(t, "alice", resp.RequestedBy)
+				require.Nil(t, resp.Balance)
+			}
+		})
+	}
+}
+
+func TestWalletHandler_ApproveAdjustment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		body           string
+		contentType    string
+		serviceError   error
+		expectCall     bool
+		expectedStatus int
+	}{
+		{
+			name:           "adjustment approved",
+			body:           `{"note":"checked"}`,
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "proposer approves",
+			body:           `{"note":"checked"}`,
+			serviceError:   walletModel.ErrAdjustmentSelfCheck,
+			expectCall:     true,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "already decided",
+			body:           `{"note":"checked"}`,
+			serviceError:   walletModel.ErrAdjustmentDecided,
+			expectCall:     true,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "unknown field",
+			body:           `{"note":"checked","amount":-30}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not json",
+			body:           `{"note":"checked"}`,
+			contentType:    "text/plain",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "too large",
+			body:           `{"note":"` + strings.Repeat("x", 64<<10) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			handler := rest.AdminAuth(adminTokens, http.HandlerFunc(rest.NewWalletHandler(svc).ApproveAdjustment))
+
+			requestID := uuid.New()
+			decidedAt := time.Now()
+			if tt.expectCall {
+				svc.EXPECT().
+					ApproveAdjustment(gomock.Any(), requestID, "bob", "checked").
+					Return(walletModel.AdjustmentRequest{
+						Adjustment: walletModel.Adjustment{ID: requestID, Amount: -30, CreatedBy: "alice"},
+						Status:     walletModel.AdjustmentApproved,
+						DecidedBy:  "bob",
+						DecidedAt:  &decidedAt,
+						Note:       "checked",
+					}, int64(70), tt.serviceError)
+			}
+
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/adjustments/"+requestID.String()+"/approve", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("Authorization", "Bearer secret-b")
+			req.SetPathValue("id", requestID.String())
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var resp handlerModel.AdjustmentRequestResponse
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				require.Equal(t, "APPROVED", resp.Status)
+				require.Equal(t, "bob", resp.DecidedBy)
+				require.Equal(t, int64(70), *resp.Balance)
+			}
+		})
+	}
+}
+
+func TestWalletHandler_RejectAdjustment_EmptyBody(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.AdminAuth(adminTokens, http.HandlerFunc(rest.NewWalletHandler(svc).RejectAdjustment))
+
+	requestID := uuid.New()
+	svc.EXPECT().
+		RejectAdjustment(gomock.Any(), requestID, "bob", "").
+		Return(walletModel.AdjustmentRequest{
+			Adjustment: walletModel.Adjustment{ID: requestID},
+			Status:     walletModel.AdjustmentRejected,
+			DecidedBy:  "bob",
+		}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/adjustments/"+requestID.String()+"/reject", nil)
+	req.Header.Set("Authorization", "Bearer secret-b")
+	req.SetPathValue("id", requestID.String())
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestWalletHandler_GetAdjustment(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	requestID := uuid.New()
+	at := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	svc.EXPECT().
+		AdjustmentRequest(gomock.Any(), requestID).
+		Return(walletModel.AdjustmentRequest{
+			Adjustment: walletModel.Adjustment{ID: requestID, Amount: 500, Reason: "goodwill", CreatedBy: "alice", CreatedAt: at},
+			Status:     walletModel.AdjustmentPending,
+		}, []walletModel.AdjustmentEvent{
+			{RequestID: requestID, Action: walletModel.AdjustmentPropose, Principal: "alice", Note: "goodwill", CreatedAt: at},
+		}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/adjustments/"+requestID.String(), nil)
+	req.SetPathValue("id", requestID.String())
+	rec := httptest.NewRecorder()
+
+	handler.GetAdjustment(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp handlerModel.AdjustmentDetailResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	require.Equal(t, requestID, resp.RequestID)
+	require.Equal(t, []handlerModel.AdjustmentEventResponse{
+		{Action: "PROPOSE", Principal: "alice", Note: "goodwill", CreatedAt: at},
+	}, resp.Events)
+}
+
+func TestWalletHandler_ListAdjustments_InvalidStatus(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/adjustments?status=DONE", nil)
+	rec := httptest.NewRecorder()
+
+	handler.ListAdjustments(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
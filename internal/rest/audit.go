@@ -0,0 +1,148 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// maxAuditPeek bounds the part of a request body read to find the audited wallet and amount.
+const maxAuditPeek = 64 << 10
+
+// maxRequestIDLength bounds request ids accepted from clients.
+const maxRequestIDLength = 128
+
+const requestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+type auditPrincipalKey struct{}
+
+// RequestID returns the id WithRequestID assigned to the request, or an empty string.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithRequestID gives every request an id, the X-Request-ID header when the client sends
+// a usable one and a random UUID otherwise, and echoes it in the X-Request-ID response header.
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// Audit records every mutating request handled by next under operation, with the admin
+// authenticated by AdminAuth, in front of or behind Audit, the wallet and amount it targets
+// and the response status. Wallets are taken from the walletId or fromWalletId body fields
+// or from a /wallets/{id} path; other {id} path values are recorded as the resource.
+//
+// The record is written once next has answered. A record that cannot be written is kept
+// by the recorder, and mutating requests are refused with 503 until it is written, so that
+// no more of them go unaudited.
+func Audit(recorder wallet.AuditRecorder, operation string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if err := recorder.Flush(r.Context()); err != nil {
+			w.Header().Set("Retry-After", "5")
+			writeProblem(w, r, http.StatusServiceUnavailable, CodeAuditUnavailable, wallet.ErrAuditUnavailable.Error(), nil)
+			return
+		}
+
+		rec := wallet.AuditRecord{
+			Principal:  Principal(r.Context()),
+			RemoteAddr: r.RemoteAddr,
+			RequestID:  RequestID(r.Context()),
+			Operation:  operation,
+		}
+		auditTarget(r, &rec)
+
+		// AdminAuth behind this handler reports the admin it authenticates through the context
+		ctx := context.WithValue(r.Context(), auditPrincipalKey{}, &rec.Principal)
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+		rec.Status = sw.status
+		rec.Outcome = wallet.AuditSucceeded
+		if sw.status >= http.StatusBadRequest {
+			rec.Outcome = wallet.AuditFailed
+		}
+
+		// the request is audited even when the client has gone away. The response is sent
+		// already: a record that cannot be written is logged, kept by the recorder and
+		// refuses the next mutations until it is written
+		_ = recorder.Record(context.WithoutCancel(r.Context()), rec)
+	})
+}
+
+// auditTarget fills the wallet, resource and amount of rec from the request, leaving the
+// body readable for the handler.
+func auditTarget(r *http.Request, rec *wallet.AuditRecord) {
+	if id := r.PathValue("id"); id != "" {
+		if walletID, err := uuid.Parse(id); err == nil && strings.Contains(r.Pattern, "/wallets/{id}") {
+			rec.WalletID = &walletID
+		} else {
+			rec.Resource = id
+		}
+	}
+
+	if r.Body == nil {
+		return
+	}
+
+	peek, err := io.ReadAll(io.LimitReader(r.Body, maxAuditPeek))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peek), r.Body), r.Body}
+	if err != nil {
+		return
+	}
+
+	var target struct {
+		WalletID     string `json:"walletId"`
+		FromWalletID string `json:"fromWalletId"`
+		Amount       *int64 `json:"amount"`
+	}
+	if json.Unmarshal(peek, &target) != nil {
+		return // the handler rejects what is not a JSON object
+	}
+
+	rec.Amount = target.Amount
+	for _, id := range []string{target.WalletID, target.FromWalletID} {
+		if walletID, err := uuid.Parse(id); err == nil && rec.WalletID == nil {
+			rec.WalletID = &walletID
+		}
+	}
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
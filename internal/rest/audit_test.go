@@ -0,0 +1,249 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+//go:generate mockgen -destination=mocks/mock_audit_recorder.go -package=mocks wallet/internal/model/wallet AuditRecorder
+
+func TestWithRequestID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		header     string
+		expectSame bool
+	}{
+		{
+			name:       "client request id",
+			header:     "req-42",
+			expectSame: true,
+		},
+		{
+			name: "no request id",
+		},
+		{
+			name:   "request id too long",
+			header: strings.Repeat("x", 129),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var requestID string
+			handler := rest.WithRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestID = rest.RequestID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, requestID, rec.Header().Get("X-Request-ID"))
+			if tt.expectSame {
+				require.Equal(t, tt.header, requestID)
+			} else {
+				require.NoError(t, uuid.Validate(requestID))
+			}
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	requestID := uuid.New()
+	amount := int64(100)
+
+	tests := []struct {
+		name           string
+		method         string
+		pattern        string
+		admin          bool
+		token          string // token is the bearer token sent, secret-a when empty
+		target         string
+		body           string
+		handlerStatus  int
+		flushErr       error // flushErr fails writing the records kept after earlier failures
+		expectedRecord *walletModel.AuditRecord
+	}{
+		{
+			name:          "wallet operation",
+			method:        http.MethodPost,
+			pattern:       "/api/v1/wallet",
+			target:        "/api/v1/wallet",
+			body:          `{"walletId":"` + walletID.String() + `","operationType":"DEPOSIT","amount":100}`,
+			handlerStatus: http.StatusOK,
+			expectedRecord: &walletModel.AuditRecord{
+				RemoteAddr: "192.0.2.1:1234",
+				RequestID:  "req-1",
+				Operation:  "WalletOperation",
+				WalletID:   &walletID,
+				Amount:     &amount,
+				Status:     http.StatusOK,
+				Outcome:    walletModel.AuditSucceeded,
+			},
+		},
+		{
+			name:          "transfer rejected",
+			method:        http.MethodPost,
+			pattern:       "/api/v1/transfers",
+			target:        "/api/v1/transfers",
+			body:          `{"fromWalletId":"` + walletID.String() + `","toWalletId":"` + uuid.NewString() + `","amount":100}`,
+			handlerStatus: http.StatusConflict,
+			expectedRecord: &walletModel.AuditRecord{
+				RemoteAddr: "192.0.2.1:1234",
+				RequestID:  "req-1",
+				Operation:  "WalletOperation",
+				WalletID:   &walletID,
+				Amount:     &amount,
+				Status:     http.StatusConflict,
+				Outcome:    walletModel.AuditFailed,
+			},
+		},
+		{
+			name:          "wallet in path",
+			method:        http.MethodPost,
+			pattern:       "/api/v1/admin/wallets/{id}/freeze",
+			admin:         true,
+			target:        "/api/v1/admin/wallets/" + walletID.String() + "/freeze",
+			handlerStatus: http.StatusNoContent,
+			expectedRecord: &walletModel.AuditRecord{
+				Principal:  "alice",
+				RemoteAddr: "192.0.2.1:1234",
+				RequestID:  "req-1",
+				Operation:  "WalletOperation",
+				WalletID:   &walletID,
+				Status:     http.StatusNoContent,
+				Outcome:    walletModel.AuditSucceeded,
+			},
+		},
+		{
+			name:          "resource in path",
+			method:        http.MethodPost,
+			pattern:       "/api/v1/admin/adjustments/{id}/approve",
+			admin:         true,
+			target:        "/api/v1/admin/adjustments/" + requestID.String() + "/approve",
+			handlerStatus: http.StatusOK,
+			expectedRecord: &walletModel.AuditRecord{
+				Principal:  "alice",
+				RemoteAddr: "192.0.2.1:1234",
+				RequestID:  "req-1",
+				Operation:  "WalletOperation",
+				Resource:   requestID.String(),
+				Status:     http.StatusOK,
+				Outcome:    walletModel.AuditSucceeded,
+			},
+		},
+		{
+			name:          "unknown admin token",
+			method:        http.MethodPost,
+			pattern:       "/api/v1/admin/wallets/{id}/freeze",
+			admin:         true,
+			token:         "guess",
+			target:        "/api/v1/admin/wallets/" + walletID.String() + "/freeze",
+			handlerStatus: http.StatusUnauthorized,
+			expectedRecord: &walletModel.AuditRecord{
+				RemoteAddr: "192.0.2.1:1234",
+				RequestID:  "req-1",
+				Operation:  "WalletOperation",
+				WalletID:   &walletID,
+				Status:     http.StatusUnauthorized,
+				Outcome:    walletModel.AuditFailed,
+			},
+		},
+		{
+			name:          "audit log unavailable",
+			method:        http.MethodPost,
+			pattern:       "/api/v1/wallet",
+			target:        "/api/v1/wallet",
+			body:          `{"walletId":"` + walletID.String() + `","operationType":"DEPOSIT","amount":100}`,
+			handlerStatus: http.StatusServiceUnavailable,
+			flushErr:      walletModel.ErrAuditUnavailable,
+		},
+		{
+			name:          "read not audited",
+			method:        http.MethodGet,
+			pattern:       "/api/v1/wallets/{id}",
+			target:        "/api/v1/wallets/" + walletID.String(),
+			handlerStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			recorder := mocks.NewMockAuditRecorder(ctrl)
+
+			if tt.method != http.MethodGet {
+				recorder.EXPECT().
+					Flush(gomock.Any()).
+					Return(tt.flushErr)
+			}
+			if tt.expectedRecord != nil {
+				recorder.EXPECT().
+					Record(gomock.Any(), *tt.expectedRecord).
+					Return(nil)
+			}
+
+			var body string
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				body = string(b)
+				w.WriteHeader(tt.handlerStatus)
+			})
+			// wrapped as Register does, so that rejected admin requests are audited too
+			if tt.admin {
+				handler = rest.AdminAuth(adminTokens, handler)
+			}
+			handler = rest.Audit(recorder, "WalletOperation", handler)
+			mux := http.NewServeMux()
+			mux.Handle(tt.pattern, rest.WithRequestID(handler))
+
+			token := "secret-a"
+			if tt.token != "" {
+				token = tt.token
+			}
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("X-Request-ID", "req-1")
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.handlerStatus, rec.Code)
+			if tt.flushErr != nil {
+				// the mutation is refused before it reaches the handler
+				require.Empty(t, body)
+				var problem handlerModel.Problem
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+				require.Equal(t, rest.CodeAuditUnavailable, problem.Code)
+				return
+			}
+			require.Equal(t, tt.body, body)
+		})
+	}
+}
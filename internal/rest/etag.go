@@ -0,0 +1,57 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	model "wallet/internal/model/handler"
+)
+
+// etag is the entity tag of a wallet balance: the wallet version, bumped by every change of
+// the balance or the metadata.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// noneMatch reports whether the If-None-Match header lists tag or is "*". Tags are compared
+// weakly, as RFC 9110 requires for If-None-Match.
+func noneMatch(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatch returns the wallet version required by the If-Match header, nil when the header
+// is missing or "*", which any existing wallet matches. The header must otherwise be a single
+// strong tag as returned in the ETag of the balance.
+func ifMatch(r *http.Request) (*int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	quoted, found := strings.CutPrefix(header, `"`)
+	if found {
+		quoted, found = strings.CutSuffix(quoted, `"`)
+	}
+	if !found {
+		return nil, model.Field("If-Match", model.ErrInvalidRequest)
+	}
+
+	version, err := strconv.ParseInt(quoted, 10, 64)
+	if err != nil || version < 0 {
+		return nil, model.Field("If-Match", model.ErrInvalidRequest)
+	}
+
+	return &version, nil
+}
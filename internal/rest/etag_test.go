@@ -0,0 +1,229 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWalletHandler_GetBalance_ETag(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+
+	tests := []struct {
+		name           string
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{
+			name:           "no precondition",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "current version",
+			ifNoneMatch:    `"7"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "weak current version in a list",
+			ifNoneMatch:    `"5", W/"7"`,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "any version",
+			ifNoneMatch:    "*",
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "stale version",
+			ifNoneMatch:    `"6"`,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			svc.EXPECT().GetBalance(gomock.Any(), walletID).Return(walletModel.Balance{Total: 100, Version: 7}, nil)
+			handler := rest.NewWalletHandler(svc)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String(), nil)
+			req.SetPathValue("id", walletID.String())
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.GetBalance(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			require.Equal(t, `"7"`, rec.Header().Get("ETag"))
+			if tt.expectedStatus == http.StatusNotModified {
+				require.Empty(t, rec.Body.Bytes())
+				return
+			}
+
+			var resp handlerModel.WalletOperationResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			require.Equal(t, int64(100), resp.Balance)
+		})
+	}
+}
+
+func TestWalletHandler_GetBalance_ETagAfterMetadataChange(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	// storage bumps the version when the metadata changes, as it does for balance changes
+	gomock.InOrder(
+		svc.EXPECT().GetBalance(gomock.Any(), walletID).
+			Return(walletModel.Balance{Total: 100, Version: 7, Metadata: walletModel.Metadata{"tier": "silver"}}, nil),
+		svc.EXPECT().SetMetadata(gomock.Any(), walletID, walletModel.Metadata{"tier": "gold"}).Return(nil),
+		svc.EXPECT().GetBalance(gomock.Any(), walletID).
+			Return(walletModel.Balance{Total: 100, Version: 8, Metadata: walletModel.Metadata{"tier": "gold"}}, nil),
+	)
+
+	getBalance := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String(), nil)
+		req.SetPathValue("id", walletID.String())
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.GetBalance(rec, req)
+		return rec
+	}
+
+	first := getBalance("")
+	require.Equal(t, http.StatusOK, first.Code)
+	tag := first.Header().Get("ETag")
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/wallets/"+walletID.String()+"/metadata",
+		strings.NewReader(`{"metadata": {"tier": "gold"}}`))
+	req.SetPathValue("id", walletID.String())
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	handler.SetMetadata(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// the balance is unchanged, but the old tag no longer matches the representation
+	second := getBalance(tag)
+	require.Equal(t, http.StatusOK, second.Code)
+	require.NotEqual(t, tag, second.Header().Get("ETag"))
+
+	var resp handlerModel.WalletOperationResponse
+	require.NoError(t, json.NewDecoder(second.Body).Decode(&resp))
+	require.Equal(t, map[string]string{"tier": "gold"}, resp.Metadata)
+}
+
+func TestWalletHandler_WalletOperation_IfMatch(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	body := `{"walletId": "` + walletID.String() + `", "operationType": "WITHDRAW", "amount": 100}`
+
+	version := int64(7)
+
+	tests := []struct {
+		name           string
+		ifMatch        string
+		setupMock      func(svc *mocks.MockWalletService)
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "no precondition",
+			setupMock: func(svc *mocks.MockWalletService) {
+				svc.EXPECT().Withdraw(gomock.Any(), walletID, int64(100), nil, gomock.Nil()).Return(walletModel.Receipt{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "any version",
+			ifMatch: "*",
+			setupMock: func(svc *mocks.MockWalletService) {
+				svc.EXPECT().Withdraw(gomock.Any(), walletID, int64(100), nil, gomock.Nil()).Return(walletModel.Receipt{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "unchanged balance",
+			ifMatch: `"7"`,
+			setupMock: func(svc *mocks.MockWalletService) {
+				svc.EXPECT().Withdraw(gomock.Any(), walletID, int64(100), nil, &version).Return(walletModel.Receipt{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "changed balance",
+			ifMatch: `"7"`,
+			setupMock: func(svc *mocks.MockWalletService) {
+				svc.EXPECT().Withdraw(gomock.Any(), walletID, int64(100), nil, &version).Return(walletModel.Receipt{}, walletModel.ErrVersionMismatch)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedCode:   rest.CodePreconditionFailed,
+		},
+		{
+			name:           "weak tag",
+			ifMatch:        `W/"7"`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   rest.CodeInvalidRequest,
+		},
+		{
+			name:           "several tags",
+			ifMatch:        `"6", "7"`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   rest.CodeInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			if tt.setupMock != nil {
+				tt.setupMock(svc)
+			}
+			handler := rest.NewWalletHandler(svc)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.WalletOperation(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedCode == "" {
+				return
+			}
+
+			var problem handlerModel.Problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			require.Equal(t, tt.expectedCode, problem.Code)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	model "wallet/internal/model/handler"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// Balance event stream defaults.
+const (
+	defaultHeartbeatInterval = 15 * time.Second
+	defaultMaxStreamDuration = time.Hour
+)
+
+// Balance event stream message types.
+const (
+	eventBalance = "balance" // eventBalance is the current balance, sent when a stream starts without resuming
+	eventChange  = "change"
+)
+
+// StopStreams ends open balance event streams, so that a graceful shutdown of the HTTP
+// server does not wait for them. Clients reconnect and resume from the last event.
+func (h *WalletHandler) StopStreams() {
+	h.stopOnce.Do(func() { close(h.stopping) })
+}
+
+// BalanceEvents streams the balance and metadata changes of the wallet as server-sent events with the
+// wallet version as the event id. A client reconnecting with Last-Event-ID receives the
+// changes it missed from the change log. Comment lines are sent as heartbeats, and streams
+// are closed after the maximum stream duration.
+func (h *WalletHandler) BalanceEvents(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, model.Field("id", model.ErrInvalidRequest))
+		return
+	}
+
+	var lastVersion *int64
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		version, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || version < 0 {
+			h.handleError(w, r, model.Field("Last-Event-ID", model.ErrInvalidRequest))
+			return
+		}
+		lastVersion = &version
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.maxStreamDuration)
+	defer cancel()
+
+	balance, events, err := h.svc.WatchBalance(ctx, walletID, lastVersion)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // keep reverse proxies from buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	if lastVersion == nil || *lastVersion > balance.Version {
+		writeEvent(w, eventBalance, balance.Version, model.BalanceEventResponse{
+			WalletID: walletID,
+			Version:  balance.Version,
+			Balance:  balance.Total,
+		})
+	}
+	if rc.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			writeEvent(w, eventChange, ev.Version, balanceEventResponse(ev))
+		case <-heartbeat.C:
+			io.WriteString(w, ": heartbeat\n\n")
+		case <-h.stopping:
+			return
+		case <-ctx.Done():
+			return
+		}
+
+		if rc.Flush() != nil {
+			return // the client has gone away
+		}
+	}
+}
+
+func writeEvent(w io.Writer, event string, id int64, data any) {
+	b, _ := json.Marshal(data)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, b)
+}
+
+func balanceEventResponse(ev wallet.BalanceEvent) model.BalanceEventResponse {
+	return model.BalanceEventResponse{
+		WalletID:  ev.WalletID,
+		Version:   ev.Version,
+		Balance:   ev.Balance,
+		Delta:     ev.Delta,
+		CreatedAt: &ev.CreatedAt,
+	}
+}
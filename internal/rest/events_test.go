@@ -0,0 +1,133 @@
+package rest_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWalletHandler_BalanceEvents(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.MustParse("c8b43e22-3cc0-4647-b18b-53fba78d6fed")
+	createdAt := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+	lastVersion := int64(4)
+
+	tests := []struct {
+		name                string
+		lastEventID         string
+		expectCall          bool
+		expectedLastVersion *int64
+		serviceError        error
+		expectedStatus      int
+		expectedBody        string
+	}{
+		{
+			name:           "new stream",
+			expectCall:     true,
+			expectedStatus: http.StatusOK,
+			expectedBody: "id: 5\nevent: balance\ndata: {\"walletId\":\"c8b43e22-3cc0-4647-b18b-53fba78d6fed\",\"version\":5,\"balance\":500}\n\n" +
+				"id: 6\nevent: change\ndata: {\"walletId\":\"c8b43e22-3cc0-4647-b18b-53fba78d6fed\",\"version\":6,\"balance\":600,\"delta\":100,\"createdAt\":\"2026-04-01T12:00:00Z\"}\n\n",
+		},
+		{
+			name:                "resumed stream",
+			lastEventID:         "4",
+			expectCall:          true,
+			expectedLastVersion: &lastVersion,
+			expectedStatus:      http.StatusOK,
+			expectedBody:        "id: 6\nevent: change\ndata: {\"walletId\":\"c8b43e22-3cc0-4647-b18b-53fba78d6fed\",\"version\":6,\"balance\":600,\"delta\":100,\"createdAt\":\"2026-04-01T12:00:00Z\"}\n\n",
+		},
+		{
+			name:           "invalid last event id",
+			lastEventID:    "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "wallet not found",
+			expectCall:     true,
+			serviceError:   walletModel.ErrWalletNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "too many streams",
+			expectCall:     true,
+			serviceError:   walletModel.ErrTooManyWatchers,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			handler := rest.NewWalletHandler(svc, rest.WithEventStreams(time.Hour, time.Hour))
+
+			if tt.expectCall {
+				events := make(chan walletModel.BalanceEvent, 1)
+				events <- walletModel.BalanceEvent{WalletID: walletID, Version: 6, Balance: 600, Delta: 100, CreatedAt: createdAt}
+				close(events)
+
+				svc.EXPECT().
+					WatchBalance(gomock.Any(), walletID, tt.expectedLastVersion).
+					Return(walletModel.Balance{Total: 500, Version: 5}, (<-chan walletModel.BalanceEvent)(events), tt.serviceError)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/events", nil)
+			req.SetPathValue("id", walletID.String())
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.BalanceEvents(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				require.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+				require.Equal(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestWalletHandler_BalanceEventsHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc, rest.WithEventStreams(time.Millisecond, 50*time.Millisecond))
+
+	walletID := uuid.New()
+	events := make(chan walletModel.BalanceEvent)
+	svc.EXPECT().
+		WatchBalance(gomock.Any(), walletID, gomock.Nil()).
+		Return(walletModel.Balance{}, (<-chan walletModel.BalanceEvent)(events), nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.SetPathValue("id", walletID.String())
+		handler.BalanceEvents(w, r)
+	}))
+	defer server.Close()
+
+	// the stream ends after the maximum stream duration
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body := new(strings.Builder)
+	_, err = io.Copy(body, resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, body.String(), ": heartbeat\n\n")
+}
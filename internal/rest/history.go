@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	model "wallet/internal/model/handler"
+
+	"github.com/google/uuid"
+)
+
+// BalanceAt returns the balance at the time given by the at query parameter, an RFC 3339
+// timestamp not in the future, or the current balance when at is not set.
+func (h *WalletHandler) BalanceAt(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, model.Field("id", model.ErrInvalidRequest))
+		return
+	}
+
+	resp := model.WalletOperationResponse{WalletID: walletID}
+
+	if v := r.URL.Query().Get("at"); v != "" {
+		at, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil || at.After(time.Now()) {
+			h.handleError(w, r, model.Field("at", model.ErrInvalidRequest))
+			return
+		}
+
+		balance, err := h.svc.BalanceAt(r.Context(), walletID, at)
+		if err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+		resp.Balance, resp.Buckets, resp.At = balance.Total, balance.Buckets, &at
+	} else {
+		balance, err := h.svc.GetBalance(r.Context(), walletID)
+		if err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+		resp.Balance, resp.Buckets, resp.Metadata = balance.Total, balance.Buckets, balance.Metadata
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(resp)
+}
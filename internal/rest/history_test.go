@@ -0,0 +1,112 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletHandler_BalanceAt(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	walletID := uuid.New()
+	at := time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   *handlerModel.WalletOperationResponse
+	}{
+		{
+			name:  "historical balance",
+			query: "?at=2026-03-31T23:59:59Z",
+			setupMock: func() {
+				svc.EXPECT().
+					BalanceAt(gomock.Any(), walletID, at).
+					Return(walletModel.Balance{Total: 700, Buckets: map[string]int64{walletModel.BucketMain: 700}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: &handlerModel.WalletOperationResponse{
+				WalletID: walletID,
+				Balance:  700,
+				Buckets:  map[string]int64{walletModel.BucketMain: 700},
+				At:       &at,
+			},
+		},
+		{
+			name:  "current balance",
+			query: "",
+			setupMock: func() {
+				svc.EXPECT().
+					GetBalance(gomock.Any(), walletID).
+					Return(walletModel.Balance{Total: 900}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   &handlerModel.WalletOperationResponse{WalletID: walletID, Balance: 900},
+		},
+		{
+			name:           "invalid timestamp",
+			query:          "?at=yesterday",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "timestamp in the future",
+			query:          "?at=" + time.Now().Add(time.Hour).Format(time.RFC3339),
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "wallet not found",
+			query: "?at=2026-03-31T23:59:59Z",
+			setupMock: func() {
+				svc.EXPECT().
+					BalanceAt(gomock.Any(), walletID, at).
+					Return(walletModel.Balance{}, walletModel.ErrWalletNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/balance"+tt.query, nil)
+			req.SetPathValue("id", walletID.String())
+			rec := httptest.NewRecorder()
+
+			handler.BalanceAt(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.expectedStatus, res.StatusCode)
+			if tt.expectedBody == nil {
+				return
+			}
+
+			var resp handlerModel.WalletOperationResponse
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+			require.Equal(t, *tt.expectedBody, resp)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	model "wallet/internal/model/handler"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// Wallet listing page sizes.
+const (
+	defaultWalletsLimit = 100
+	maxWalletsLimit     = 1000
+)
+
+// ListWallets returns a page of wallets, oldest first. Wallets are filtered by ownerId, status,
+// currency, minBalance and maxBalance (inclusive), repeated metadata=key:value pairs and
+// createdFrom (inclusive) and createdTo (exclusive) RFC 3339 timestamps. cursor continues
+// the listing from the nextCursor of the previous page.
+func (h *WalletHandler) ListWallets(w http.ResponseWriter, r *http.Request) {
+	filter, err := walletFilter(r.URL.Query())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	page, err := h.svc.ListWallets(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := model.WalletListResponse{Wallets: make([]model.WalletResponse, 0, len(page.Wallets))}
+	for _, wal := range page.Wallets {
+		resp.Wallets = append(resp.Wallets, walletResponse(wal))
+	}
+	if page.Next != nil {
+		resp.NextCursor = encodeCursor(*page.Next)
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+func walletResponse(wal wallet.Wallet) model.WalletResponse {
+	return model.WalletResponse{
+		WalletID:  wal.ID,
+		OwnerID:   wal.OwnerID,
+		Status:    string(wal.Status),
+		Currency:  wal.Currency,
+		Balance:   wal.Balance,
+		Metadata:  wal.Metadata,
+		CreatedAt: wal.CreatedAt,
+	}
+}
+
+func walletFilter(query url.Values) (wallet.WalletFilter, error) {
+	filter := wallet.WalletFilter{Limit: defaultWalletsLimit}
+
+	if v := query.Get("ownerId"); v != "" {
+		filter.OwnerID = &v
+	}
+
+	if v := query.Get("status"); v != "" {
+		status := wallet.Status(v)
+		if status != wallet.StatusActive && status != wallet.StatusFrozen {
+			return wallet.WalletFilter{}, model.Field("status", model.ErrInvalidRequest)
+		}
+		filter.Status = &status
+	}
+
+	if v := query.Get("currency"); v != "" {
+		if !currencyCode.MatchString(v) {
+			return wallet.WalletFilter{}, model.Field("currency", model.ErrInvalidCurrency)
+		}
+		filter.Currency = &v
+	}
+
+	var err error
+	if filter.MinBalance, err = int64Param(query, "minBalance"); err != nil {
+		return wallet.WalletFilter{}, err
+	}
+	if filter.MaxBalance, err = int64Param(query, "maxBalance"); err != nil {
+		return wallet.WalletFilter{}, err
+	}
+	if filter.MinBalance != nil && filter.MaxBalance != nil && *filter.MinBalance > *filter.MaxBalance {
+		return wallet.WalletFilter{}, model.Field("maxBalance", model.ErrInvalidRequest)
+	}
+
+	if filter.Metadata, err = metadataFilter(query); err != nil {
+		return wallet.WalletFilter{}, err
+	}
+
+	if filter.CreatedFrom, err = timeParam(query, "createdFrom"); err != nil {
+		return wallet.WalletFilter{}, err
+	}
+	if filter.CreatedBefore, err = timeParam(query, "createdTo"); err != nil {
+		return wallet.WalletFilter{}, err
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return wallet.WalletFilter{}, model.Field("cursor", model.ErrInvalidRequest)
+		}
+		filter.After = &cursor
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxWalletsLimit {
+			return wallet.WalletFilter{}, model.Field("limit", model.ErrInvalidRequest)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+func int64Param(query url.Values, name string) (*int64, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, model.Field(name, model.ErrInvalidRequest)
+	}
+
+	return &n, nil
+}
+
+func timeParam(query url.Values, name string) (*time.Time, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return nil, model.Field(name, model.ErrInvalidRequest)
+	}
+
+	return &t, nil
+}
+
+// encodeCursor makes an opaque page cursor of the position of the last listed wallet.
+func encodeCursor(c wallet.WalletCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.Format(time.RFC3339Nano) + "," + c.ID.String()))
+}
+
+func decodeCursor(s string) (wallet.WalletCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return wallet.WalletCursor{}, err
+	}
+
+	createdAt, id, _ := strings.Cut(string(data), ",")
+
+	var c wallet.WalletCursor
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return wallet.WalletCursor{}, err
+	}
+	if c.ID, err = uuid.Parse(id); err != nil {
+		return wallet.WalletCursor{}, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,113 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletHandler_ListWallets(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	listed := walletModel.Wallet{
+		ID:        uuid.New(),
+		Status:    walletModel.StatusActive,
+		Currency:  "EUR",
+		Balance:   500,
+		CreatedAt: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	next := walletModel.WalletCursor{CreatedAt: listed.CreatedAt, ID: listed.ID}
+
+	status := walletModel.StatusFrozen
+	currency := "EUR"
+	owner := "owner-1"
+	minBalance, maxBalance := int64(10), int64(1000)
+	createdFrom := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// follow the cursor of the first page
+	var cursor string
+
+	t.Run("first page", func(t *testing.T) {
+		svc.EXPECT().
+			ListWallets(gomock.Any(), walletModel.WalletFilter{
+				OwnerID:     &owner,
+				Status:      &status,
+				Currency:    &currency,
+				MinBalance:  &minBalance,
+				MaxBalance:  &maxBalance,
+				Metadata:    walletModel.Metadata{"label": "vip"},
+				CreatedFrom: &createdFrom,
+				Limit:       1,
+			}).
+			Return(walletModel.WalletPage{Wallets: []walletModel.Wallet{listed}, Next: &next}, nil)
+
+		query := "?ownerId=owner-1&status=FROZEN&currency=EUR&minBalance=10&maxBalance=1000&metadata=label:vip&createdFrom=2026-01-01T00:00:00Z&limit=1"
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets"+query, nil)
+		rec := httptest.NewRecorder()
+
+		handler.ListWallets(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp handlerModel.WalletListResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		require.Equal(t, []handlerModel.WalletResponse{{
+			WalletID:  listed.ID,
+			Status:    "ACTIVE",
+			Currency:  "EUR",
+			Balance:   500,
+			CreatedAt: listed.CreatedAt,
+		}}, resp.Wallets)
+		require.NotEmpty(t, resp.NextCursor)
+
+		cursor = resp.NextCursor
+	})
+
+	t.Run("next page", func(t *testing.T) {
+		svc.EXPECT().
+			ListWallets(gomock.Any(), walletModel.WalletFilter{After: &next, Limit: 100}).
+			Return(walletModel.WalletPage{}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets?cursor="+cursor, nil)
+		rec := httptest.NewRecorder()
+
+		handler.ListWallets(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, `{"wallets": []}`, rec.Body.String())
+	})
+
+	for _, query := range []string{
+		"?status=CLOSED",
+		"?currency=euro",
+		"?minBalance=100&maxBalance=10",
+		"?createdFrom=yesterday",
+		"?cursor=not-a-cursor",
+		"?limit=0",
+	} {
+		t.Run("invalid "+query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets"+query, nil)
+			rec := httptest.NewRecorder()
+
+			handler.ListWallets(rec, req)
+
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	model "wallet/internal/model/handler"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// Wallet history page sizes.
+const (
+	defaultOperationsLimit = 100
+	maxOperationsLimit     = 1000
+)
+
+// SetMetadata replaces the metadata of the wallet.
+func (h *WalletHandler) SetMetadata(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, model.Field("id", model.ErrInvalidRequest))
+		return
+	}
+
+	var req model.MetadataRequest
+	violations, err := decodeStrict(w, r, &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := model.ValidateMetadata(req.Metadata); err != nil {
+		violations = append(violations, model.Field("metadata", err))
+	}
+
+	if err := joinViolations(violations...); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := h.svc.SetMetadata(r.Context(), walletID, req.Metadata); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(req)
+}
+
+// ListOperations returns the wallet history, newest first. Repeated metadata=key:value
+// parameters select operations having all of the pairs; before continues a previous page
+// after the operation with the given id.
+func (h *WalletHandler) ListOperations(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, model.Field("id", model.ErrInvalidRequest))
+		return
+	}
+
+	query := r.URL.Query()
+	filter := wallet.OperationFilter{Limit: defaultOperationsLimit}
+
+	if filter.Metadata, err = metadataFilter(query); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if v := query.Get("before"); v != "" {
+		before, err := uuid.Parse(v)
+		if err != nil {
+			h.handleError(w, r, model.Field("before", model.ErrInvalidRequest))
+			return
+		}
+		filter.Before = &before
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxOperationsLimit {
+			h.handleError(w, r, model.Field("limit", model.ErrInvalidRequest))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	ops, err := h.svc.Operations(r.Context(), walletID, filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := make([]model.OperationResponse, 0, len(ops))
+	for _, op := range ops {
+		resp = append(resp, model.OperationResponse{
+			OperationID:   op.ID,
+			WalletID:      op.WalletID,
+			OperationType: model.OperationType(op.Type),
+			Amount:        op.Amount,
+			Fee:           op.Fee,
+			Metadata:      op.Metadata,
+			CreatedAt:     op.CreatedAt,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// metadataFilter reads repeated metadata=key:value query parameters, nil when there are none.
+func metadataFilter(query url.Values) (wallet.Metadata, error) {
+	pairs := query["metadata"]
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	filter := make(wallet.Metadata, len(pairs))
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, ":")
+		if !ok || k == "" {
+			return nil, model.Field("metadata", fmt.Errorf("%w: filters must be key:value", model.ErrInvalidMetadata))
+		}
+		filter[k] = v
+	}
+
+	return filter, nil
+}
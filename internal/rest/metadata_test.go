@@ -0,0 +1,207 @@
+package rest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletHandler_SetMetadata(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	walletID := uuid.New()
+
+	tests := []struct {
+		name           string
+		body           string
+		contentType    string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "metadata replaced",
+			body: `{"metadata": {"customerId": "c-42", "label": "vip"}}`,
+			setupMock: func() {
+				svc.EXPECT().
+					SetMetadata(gomock.Any(), walletID, walletModel.Metadata{"customerId": "c-42", "label": "vip"}).
+					Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "wallet not found",
+			body: `{"metadata": {}}`,
+			setupMock: func() {
+				svc.EXPECT().
+					SetMetadata(gomock.Any(), walletID, walletModel.Metadata{}).
+					Return(walletModel.ErrWalletNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "value too long",
+			body:           `{"metadata": {"label": "` + strings.Repeat("x", 513) + `"}}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "values must be strings",
+			body:           `{"metadata": {"count": 3}}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown field",
+			body:           `{"metadata": {}, "merge": true}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not json",
+			body:           `{"metadata": {}}`,
+			contentType:    "application/x-www-form-urlencoded",
+			setupMock:      func() {},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "too large",
+			body:           `{"metadata": {"label": "` + strings.Repeat("x", 64<<10) + `"}}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/wallets/"+walletID.String()+"/metadata", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", contentType)
+			req.SetPathValue("id", walletID.String())
+			rec := httptest.NewRecorder()
+
+			handler.SetMetadata(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestWalletHandler_ListOperations(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	walletID := uuid.New()
+	before := uuid.New()
+	op := walletModel.OperationRecord{
+		ID:        uuid.New(),
+		WalletID:  walletID,
+		Type:      walletModel.OperationWithdraw,
+		Amount:    100,
+		Fee:       5,
+		Metadata:  walletModel.Metadata{"merchantId": "m-7"},
+		CreatedAt: time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   []handlerModel.OperationResponse
+	}{
+		{
+			name:  "filtered by metadata",
+			query: "?metadata=merchantId:m-7&metadata=label:a:b&limit=10&before=" + before.String(),
+			setupMock: func() {
+				svc.EXPECT().
+					Operations(gomock.Any(), walletID, walletModel.OperationFilter{
+						Metadata: walletModel.Metadata{"merchantId": "m-7", "label": "a:b"},
+						Before:   &before,
+						Limit:    10,
+					}).
+					Return([]walletModel.OperationRecord{op}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: []handlerModel.OperationResponse{{
+				OperationID:   op.ID,
+				WalletID:      walletID,
+				OperationType: handlerModel.OperationWithdraw,
+				Amount:        100,
+				Fee:           5,
+				Metadata:      map[string]string{"merchantId": "m-7"},
+				CreatedAt:     op.CreatedAt,
+			}},
+		},
+		{
+			name:  "default limit",
+			query: "",
+			setupMock: func() {
+				svc.EXPECT().
+					Operations(gomock.Any(), walletID, walletModel.OperationFilter{Limit: 100}).
+					Return(nil, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   []handlerModel.OperationResponse{},
+		},
+		{
+			name:           "filter without value",
+			query:          "?metadata=merchantId",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "limit too large",
+			query:          "?limit=1001",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/operations"+tt.query, nil)
+			req.SetPathValue("id", walletID.String())
+			rec := httptest.NewRecorder()
+
+			handler.ListOperations(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedBody != nil {
+				var resp []handlerModel.OperationResponse
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+				require.Equal(t, tt.expectedBody, resp)
+			}
+		})
+	}
+}
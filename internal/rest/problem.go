@@ -0,0 +1,192 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	model "wallet/internal/model/handler"
+	"wallet/internal/model/wallet"
+)
+
+// Error codes identify problems independently of their wording.
+const (
+	CodeWalletNotFound      = "WALLET_NOT_FOUND"
+	CodeInsufficientFunds   = "INSUFFICIENT_FUNDS"
+	CodeWalletFrozen        = "WALLET_FROZEN"
+	CodeWalletExists        = "WALLET_EXISTS"
+	CodeQuoteNotFound       = "QUOTE_NOT_FOUND"
+	CodeQuoteExpired        = "QUOTE_EXPIRED"
+	CodeSameWallet          = "SAME_WALLET"
+	CodeQuoteRequired       = "QUOTE_REQUIRED"
+	CodeCurrencyMismatch    = "CURRENCY_MISMATCH"
+	CodeRateNotFound        = "RATE_NOT_FOUND"
+	CodeAmountTooSmall      = "AMOUNT_TOO_SMALL"
+	CodeAdjustmentNotFound  = "ADJUSTMENT_NOT_FOUND"
+	CodeAdjustmentDecided   = "ADJUSTMENT_DECIDED"
+	CodeAdjustmentSelfCheck = "ADJUSTMENT_SELF_CHECK"
+	CodeScheduleNotFound    = "SCHEDULE_NOT_FOUND"
+	CodeScheduleNotActive   = "SCHEDULE_NOT_ACTIVE"
+	CodeInvalidRecurrence   = "INVALID_RECURRENCE"
+	CodeTooManyConflicts    = "TOO_MANY_CONFLICTS"
+	CodePreconditionFailed  = "PRECONDITION_FAILED"
+	CodeTooManyStreams      = "TOO_MANY_STREAMS"
+	CodeAuditUnavailable    = "AUDIT_UNAVAILABLE"
+	CodeInvalidAmount       = "INVALID_AMOUNT"
+	CodeInvalidBucket       = "INVALID_BUCKET"
+	CodeInvalidExpiry       = "INVALID_EXPIRY"
+	CodeInvalidCurrency     = "INVALID_CURRENCY"
+	CodeInvalidMetadata     = "INVALID_METADATA"
+	CodeInvalidReason       = "INVALID_REASON"
+	CodeInvalidSchedule     = "INVALID_SCHEDULE"
+	CodeInvalidOperation    = "INVALID_OPERATION_TYPE"
+	CodeInvalidWalletID     = "INVALID_WALLET_ID"
+	CodeUnknownField        = "UNKNOWN_FIELD"
+	CodeUnsupportedMedia    = "UNSUPPORTED_MEDIA_TYPE"
+	CodeRequestTooLarge     = "REQUEST_TOO_LARGE"
+	CodeInvalidRequest      = "INVALID_REQUEST"
+	CodeValidationFailed    = "VALIDATION_FAILED"
+	CodeUnauthorized        = "UNAUTHORIZED"
+	CodeMethodNotAllowed    = "METHOD_NOT_ALLOWED"
+	CodeNotFound            = "NOT_FOUND"
+	CodeInternal            = "INTERNAL_ERROR"
+)
+
+// problemType is the problem type of every problem: the code, not the type, tells them apart.
+const problemType = "about:blank"
+
+// classify returns the HTTP status and error code of err.
+func classify(err error) (int, string) {
+	switch {
+	case errors.Is(err, wallet.ErrWalletNotFound):
+		return http.StatusNotFound, CodeWalletNotFound
+	case errors.Is(err, wallet.ErrNotEnoughMoney):
+		return http.StatusConflict, CodeInsufficientFunds
+	case errors.Is(err, wallet.ErrWalletFrozen):
+		return http.StatusConflict, CodeWalletFrozen
+	case errors.Is(err, wallet.ErrWalletExists):
+		return http.StatusConflict, CodeWalletExists
+	case errors.Is(err, wallet.ErrQuoteNotFound):
+		return http.StatusNotFound, CodeQuoteNotFound
+	case errors.Is(err, wallet.ErrQuoteExpired):
+		return http.StatusConflict, CodeQuoteExpired
+	case errors.Is(err, wallet.ErrSameWallet):
+		return http.StatusBadRequest, CodeSameWallet
+	case errors.Is(err, wallet.ErrQuoteRequired):
+		return http.StatusBadRequest, CodeQuoteRequired
+	case errors.Is(err, wallet.ErrCurrencyMismatch):
+		return http.StatusUnprocessableEntity, CodeCurrencyMismatch
+	case errors.Is(err, wallet.ErrRateNotFound):
+		return http.StatusUnprocessableEntity, CodeRateNotFound
+	case errors.Is(err, wallet.ErrAmountTooSmall):
+		return http.StatusUnprocessableEntity, CodeAmountTooSmall
+	case errors.Is(err, wallet.ErrAdjustmentNotFound):
+		return http.StatusNotFound, CodeAdjustmentNotFound
+	case errors.Is(err, wallet.ErrAdjustmentDecided):
+		return http.StatusConflict, CodeAdjustmentDecided
+	case errors.Is(err, wallet.ErrAdjustmentSelfCheck):
+		return http.StatusForbidden, CodeAdjustmentSelfCheck
+	case errors.Is(err, wallet.ErrScheduleNotFound):
+		return http.StatusNotFound, CodeScheduleNotFound
+	case errors.Is(err, wallet.ErrScheduleNotActive):
+		return http.StatusConflict, CodeScheduleNotActive
+	case errors.Is(err, wallet.ErrInvalidRecurrence):
+		return http.StatusBadRequest, CodeInvalidRecurrence
+	case errors.Is(err, wallet.ErrVersionMismatch):
+		return http.StatusPreconditionFailed, CodePreconditionFailed
+	case errors.Is(err, wallet.ErrTooManyConflicts):
+		return http.StatusServiceUnavailable, CodeTooManyConflicts
+	case errors.Is(err, wallet.ErrTooManyWatchers):
+		return http.StatusServiceUnavailable, CodeTooManyStreams
+	case errors.Is(err, wallet.ErrAuditUnavailable):
+		return http.StatusServiceUnavailable, CodeAuditUnavailable
+	case errors.Is(err, model.ErrInvalidAmount):
+		return http.StatusBadRequest, CodeInvalidAmount
+	case errors.Is(err, model.ErrInvalidBucket):
+		return http.StatusBadRequest, CodeInvalidBucket
+	case errors.Is(err, model.ErrInvalidExpiry):
+		return http.StatusBadRequest, CodeInvalidExpiry
+	case errors.Is(err, model.ErrInvalidCurrency):
+		return http.StatusBadRequest, CodeInvalidCurrency
+	case errors.Is(err, model.ErrInvalidMetadata):
+		return http.StatusBadRequest, CodeInvalidMetadata
+	case errors.Is(err, model.ErrInvalidReason):
+		return http.StatusBadRequest, CodeInvalidReason
+	case errors.Is(err, model.ErrInvalidSchedule):
+		return http.StatusBadRequest, CodeInvalidSchedule
+	case errors.Is(err, model.ErrInvalidOperationType):
+		return http.StatusBadRequest, CodeInvalidOperation
+	case errors.Is(err, model.ErrInvalidWalletID):
+		return http.StatusBadRequest, CodeInvalidWalletID
+	case errors.Is(err, model.ErrUnknownField):
+		return http.StatusBadRequest, CodeUnknownField
+	case errors.Is(err, model.ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType, CodeUnsupportedMedia
+	case errors.Is(err, model.ErrRequestTooLarge):
+		return http.StatusRequestEntityTooLarge, CodeRequestTooLarge
+	case errors.Is(err, model.ErrInvalidRequest):
+		return http.StatusBadRequest, CodeInvalidRequest
+	default:
+		return http.StatusInternalServerError, CodeInternal
+	}
+}
+
+// fieldViolations collects the field errors in the tree of err.
+func fieldViolations(err error) []model.FieldViolation {
+	switch e := err.(type) {
+	case *model.FieldError:
+		_, code := classify(e.Err)
+		return []model.FieldViolation{{Field: e.Field, Code: code, Message: e.Err.Error()}}
+	case interface{ Unwrap() []error }:
+		var violations []model.FieldViolation
+		for _, inner := range e.Unwrap() {
+			violations = append(violations, fieldViolations(inner)...)
+		}
+		return violations
+	case interface{ Unwrap() error }:
+		return fieldViolations(e.Unwrap())
+	default:
+		return nil
+	}
+}
+
+// writeProblem writes a problem+json response with the request id of r.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string, violations []model.FieldViolation) {
+	problem := model.Problem{
+		Type:      problemType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: RequestID(r.Context()),
+		Errors:    violations,
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+func (h *WalletHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := classify(err)
+	detail := err.Error()
+
+	switch {
+	case status == http.StatusInternalServerError:
+		detail = "internal server error"
+	case errors.Is(err, wallet.ErrTooManyConflicts):
+		w.Header().Set("Retry-After", "1")
+	case errors.Is(err, wallet.ErrTooManyWatchers):
+		w.Header().Set("Retry-After", "5")
+	}
+
+	violations := fieldViolations(err)
+	if len(violations) > 1 {
+		code = CodeValidationFailed
+		detail = fmt.Sprintf("%d fields are invalid", len(violations))
+	}
+
+	writeProblem(w, r, status, code, detail, violations)
+}
@@ -0,0 +1,129 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProblemResponses(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		body            string
+		setupMock       func(svc *mocks.MockWalletService)
+		expectedStatus  int
+		expectedCode    string
+		expectedErrors  []handlerModel.FieldViolation
+		expectedHeaders map[string]string
+	}{
+		{
+			name: "wallet not found",
+			path: "/api/v1/wallets/" + walletID.String(),
+			setupMock: func(svc *mocks.MockWalletService) {
+				svc.EXPECT().GetBalance(gomock.Any(), walletID).Return(walletModel.Balance{}, walletModel.ErrWalletNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   rest.CodeWalletNotFound,
+		},
+		{
+			name:   "insufficient funds",
+			method: http.MethodPost,
+			path:   "/api/v1/wallet",
+			body:   `{"walletId": "` + walletID.String() + `", "operationType": "WITHDRAW", "amount": 100}`,
+			setupMock: func(svc *mocks.MockWalletService) {
+				svc.EXPECT().Withdraw(gomock.Any(), walletID, int64(100), nil, gomock.Nil()).Return(walletModel.Receipt{}, walletModel.ErrNotEnoughMoney)
+			},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   rest.CodeInsufficientFunds,
+		},
+		{
+			name:           "invalid field",
+			method:         http.MethodPost,
+			path:           "/api/v1/wallet",
+			body:           `{"walletId": "` + walletID.String() + `", "operationType": "DEPOSIT", "amount": -5}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   rest.CodeInvalidAmount,
+			expectedErrors: []handlerModel.FieldViolation{{Field: "amount", Code: rest.CodeInvalidAmount,
+				Message: "invalid amount: must be between 1 and 1000000000000"}},
+		},
+		{
+			name: "too many conflicts",
+			path: "/api/v1/wallets/" + walletID.String(),
+			setupMock: func(svc *mocks.MockWalletService) {
+				svc.EXPECT().GetBalance(gomock.Any(), walletID).Return(walletModel.Balance{}, walletModel.ErrTooManyConflicts)
+			},
+			expectedStatus:  http.StatusServiceUnavailable,
+			expectedCode:    rest.CodeTooManyConflicts,
+			expectedHeaders: map[string]string{"Retry-After": "1"},
+		},
+		{
+			name:            "missing admin token",
+			method:          http.MethodPost,
+			path:            "/api/v1/admin/reconciliation",
+			expectedStatus:  http.StatusUnauthorized,
+			expectedCode:    rest.CodeUnauthorized,
+			expectedHeaders: map[string]string{"WWW-Authenticate": "Bearer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			recorder := mocks.NewMockAuditRecorder(ctrl)
+			recorder.EXPECT().Record(gomock.Any(), gomock.Any()).AnyTimes()
+			recorder.EXPECT().Flush(gomock.Any()).AnyTimes()
+			if tt.setupMock != nil {
+				tt.setupMock(svc)
+			}
+
+			mux := http.NewServeMux()
+			rest.Register(mux, rest.NewWalletHandler(svc).Routes(), recorder, map[string]string{"alice": "secret"})
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("X-Request-ID", "req-1")
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			rest.WithRequestID(mux).ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+			for k, v := range tt.expectedHeaders {
+				require.Equal(t, v, rec.Header().Get(k))
+			}
+
+			var problem handlerModel.Problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			require.Equal(t, tt.expectedStatus, problem.Status)
+			require.Equal(t, http.StatusText(tt.expectedStatus), problem.Title)
+			require.Equal(t, tt.expectedCode, problem.Code)
+			require.Equal(t, "req-1", problem.RequestID)
+			require.Equal(t, tt.path, problem.Instance)
+			require.NotEmpty(t, problem.Detail)
+			require.Equal(t, tt.expectedErrors, problem.Errors)
+		})
+	}
+}
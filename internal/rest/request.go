@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	model "wallet/internal/model/handler"
+)
+
+// maxRequestBody bounds strictly decoded request bodies; the largest valid body, an
+// operation with full metadata, is well below it.
+const maxRequestBody = 64 << 10
+
+// decodeStrict decodes the JSON object in the body of r into the struct pointed to by v. It
+// rejects bodies that are not application/json, larger than maxRequestBody or not a single
+// JSON object. Unknown fields and fields of the wrong type are returned as field errors,
+// all of them at once, so that they can be reported together with the validation errors.
+func decodeStrict(w http.ResponseWriter, r *http.Request, v any) ([]error, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return nil, model.ErrUnsupportedMediaType
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+
+	var fields map[string]json.RawMessage
+	if err := dec.Decode(&fields); err != nil {
+		return nil, decodeError(err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, decodeError(err)
+	}
+	if fields == nil {
+		return nil, model.ErrInvalidRequest
+	}
+
+	target := reflect.ValueOf(v).Elem()
+	known := jsonFields(target.Type())
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var violations []error
+	for _, name := range names {
+		index, ok := known[name]
+		if !ok {
+			violations = append(violations, model.Field(name, model.ErrUnknownField))
+			continue
+		}
+
+		if err := json.Unmarshal(fields[name], target.Field(index).Addr().Interface()); err != nil {
+			violations = append(violations, model.Field(name, model.ErrInvalidRequest))
+		}
+	}
+
+	return violations, nil
+}
+
+// decodeError tells bodies over the size limit apart from malformed ones.
+func decodeError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return model.ErrRequestTooLarge
+	}
+	return model.ErrInvalidRequest
+}
+
+// jsonFields maps the JSON names of the fields of struct type t to their index.
+func jsonFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = i
+	}
+
+	return fields
+}
+
+// joinViolations joins field errors, keeping the first error of every field.
+func joinViolations(violations ...error) error {
+	seen := make(map[string]bool, len(violations))
+	kept := violations[:0:0]
+	for _, err := range violations {
+		var fe *model.FieldError
+		if errors.As(err, &fe) {
+			if seen[fe.Field] {
+				continue
+			}
+			seen[fe.Field] = true
+		}
+		kept = append(kept, err)
+	}
+
+	return errors.Join(kept...)
+}
@@ -0,0 +1,120 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	handlerModel "wallet/internal/model/handler"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWalletHandler_WalletOperation_Validation(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New().String()
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedFields []string
+	}{
+		{
+			name:           "missing content type",
+			body:           `{"walletId": "` + walletID + `", "operationType": "DEPOSIT", "amount": 100}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedCode:   rest.CodeUnsupportedMedia,
+		},
+		{
+			name:           "form content type",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           `{"walletId": "` + walletID + `", "operationType": "DEPOSIT", "amount": 100}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedCode:   rest.CodeUnsupportedMedia,
+		},
+		{
+			name:           "body too large",
+			contentType:    "application/json",
+			body:           `{"walletId": "` + walletID + `", "operationType": "DEPOSIT", "amount": 100, "metadata": {"k": "` + strings.Repeat("x", 70<<10) + `"}}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedCode:   rest.CodeRequestTooLarge,
+		},
+		{
+			name:           "trailing data",
+			contentType:    "application/json",
+			body:           `{"walletId": "` + walletID + `", "operationType": "DEPOSIT", "amount": 100} {}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   rest.CodeInvalidRequest,
+		},
+		{
+			name:           "unknown field",
+			contentType:    "application/json; charset=utf-8",
+			body:           `{"walletId": "` + walletID + `", "operationType": "DEPOSIT", "amount": 100, "currency": "EUR"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   rest.CodeUnknownField,
+			expectedFields: []string{"currency"},
+		},
+		{
+			name:           "nil wallet id",
+			contentType:    "application/json",
+			body:           `{"walletId": "00000000-0000-0000-0000-000000000000", "operationType": "DEPOSIT", "amount": 100}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   rest.CodeInvalidWalletID,
+			expectedFields: []string{"walletId"},
+		},
+		{
+			name:           "amount above maximum",
+			contentType:    "application/json",
+			body:           `{"walletId": "` + walletID + `", "operationType": "WITHDRAW", "amount": 1001}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   rest.CodeInvalidAmount,
+			expectedFields: []string{"amount"},
+		},
+		{
+			name:           "all violations",
+			contentType:    "application/json",
+			body:           `{"walletId": "not-a-uuid", "operationType": "WITHDRAW", "amount": 0, "bucket": "bonus", "note": "x"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   rest.CodeValidationFailed,
+			expectedFields: []string{"note", "walletId", "amount", "bucket"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			handler := rest.NewWalletHandler(mocks.NewMockWalletService(ctrl), rest.WithMaxAmount(1000))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.WalletOperation(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+
+			var problem handlerModel.Problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			require.Equal(t, tt.expectedCode, problem.Code)
+
+			var fields []string
+			for _, v := range problem.Errors {
+				fields = append(fields, v.Field)
+			}
+			require.Equal(t, tt.expectedFields, fields)
+		})
+	}
+}
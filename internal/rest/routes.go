@@ -0,0 +1,133 @@
+package rest
+
+import (
+	_ "embed"
+	"net/http"
+	"slices"
+	"strings"
+	"wallet/internal/metrics"
+	"wallet/internal/model/wallet"
+)
+
+// openAPI documents the routes of every API version; its paths are relative to the version prefix.
+//
+//go:embed openapi.json
+var openAPI []byte
+
+// API versions. Breaking changes go to the newest version only, so that clients of older
+// versions keep working until they migrate.
+const (
+	APIv1 = "/api/v1"
+	APIv2 = "/api/v2"
+)
+
+// Route is an endpoint of the HTTP API.
+type Route struct {
+	Pattern string // Pattern is the http.ServeMux pattern, with its method
+	Name    string // Name labels the route in metrics and the audit log
+	Handler http.HandlerFunc
+	Admin   bool // Admin routes require an admin bearer token
+}
+
+// Routes returns the endpoints served by the handler under every API version. v2 starts out
+// as a copy of v1.
+func (h *WalletHandler) Routes() []Route {
+	return slices.Concat(h.routes(APIv1), h.routes(APIv2))
+}
+
+// routes returns the endpoints of the API version mounted at prefix.
+func (h *WalletHandler) routes(prefix string) []Route {
+	route := func(method, path, name string, handler http.HandlerFunc) Route {
+		return Route{Pattern: method + " " + prefix + path, Name: name, Handler: handler}
+	}
+	admin := func(method, path, name string, handler http.HandlerFunc) Route {
+		rt := route(method, path, name, handler)
+		rt.Admin = true
+		return rt
+	}
+
+	return []Route{
+		route(http.MethodGet, "/openapi.json", "OpenAPI", h.OpenAPI),
+
+		route(http.MethodPost, "/wallet", "WalletOperation", h.WalletOperation),
+		route(http.MethodGet, "/wallets/{id}", "GetBalance", h.GetBalance),
+		route(http.MethodPost, "/fx/quotes", "CreateQuote", h.CreateQuote),
+		route(http.MethodPost, "/transfers", "Transfer", h.Transfer),
+		route(http.MethodPost, "/schedules", "CreateSchedule", h.CreateSchedule),
+		route(http.MethodDelete, "/schedules/{id}", "CancelSchedule", h.CancelSchedule),
+		route(http.MethodGet, "/wallets/{id}/balance", "BalanceAt", h.BalanceAt),
+		route(http.MethodGet, "/wallets/{id}/statement", "Statement", h.Statement),
+		route(http.MethodPut, "/wallets/{id}/metadata", "SetMetadata", h.SetMetadata),
+		route(http.MethodGet, "/wallets/{id}/operations", "ListOperations", h.ListOperations),
+		route(http.MethodGet, "/wallets/{id}/events", "BalanceEvents", h.BalanceEvents),
+		route(http.MethodGet, "/wallets/{id}/schedules", "ListSchedules", h.ListSchedules),
+
+		admin(http.MethodGet, "/wallets", "ListWallets", h.ListWallets),
+		admin(http.MethodPost, "/admin/reconciliation", "Reconcile", h.Reconcile),
+		admin(http.MethodPost, "/admin/wallets", "CreateWallet", h.CreateWallet),
+		admin(http.MethodPost, "/admin/wallets/{id}/freeze", "FreezeWallet", h.FreezeWallet),
+		admin(http.MethodPost, "/admin/wallets/{id}/unfreeze", "UnfreezeWallet", h.UnfreezeWallet),
+		admin(http.MethodPost, "/admin/wallets/{id}/adjustments", "ProposeAdjustment", h.ProposeAdjustment),
+		admin(http.MethodGet, "/admin/adjustments", "ListAdjustments", h.ListAdjustments),
+		admin(http.MethodGet, "/admin/adjustments/{id}", "GetAdjustment", h.GetAdjustment),
+		admin(http.MethodPost, "/admin/adjustments/{id}/approve", "ApproveAdjustment", h.ApproveAdjustment),
+		admin(http.MethodPost, "/admin/adjustments/{id}/reject", "RejectAdjustment", h.RejectAdjustment),
+	}
+}
+
+// Register adds the routes to mux. Every route is measured, mutating requests are audited,
+// those rejected by the admin check included, and admin routes require one of adminTokens.
+// Requests with a method no route of their path serves are answered with 405 and the
+// allowed methods, requests for paths no route serves with 404.
+func Register(mux *http.ServeMux, routes []Route, recorder wallet.AuditRecorder, adminTokens map[string]string) {
+	allowed := make(map[string][]string)
+	var paths []string
+
+	for _, rt := range routes {
+		var handler http.Handler = rt.Handler
+		if rt.Admin {
+			handler = AdminAuth(adminTokens, handler)
+		}
+		// admin requests are audited even when their token is rejected
+		handler = Audit(recorder, rt.Name, handler)
+		mux.Handle(rt.Pattern, metrics.MetricsMiddleware(handler, rt.Name))
+
+		method, path, _ := strings.Cut(rt.Pattern, " ")
+		if _, ok := allowed[path]; !ok {
+			paths = append(paths, path)
+		}
+		allowed[path] = append(allowed[path], method)
+		if method == http.MethodGet {
+			// GET patterns serve HEAD requests too
+			allowed[path] = append(allowed[path], http.MethodHead)
+		}
+	}
+
+	for _, path := range paths {
+		mux.Handle(path, methodNotAllowed(allowed[path]))
+	}
+	mux.Handle("/", notFound())
+}
+
+// notFound answers every request with 404.
+func notFound() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, http.StatusNotFound, CodeNotFound, "no route matches "+r.URL.Path, nil)
+	})
+}
+
+// methodNotAllowed answers every request with 405 and the allowed methods.
+func methodNotAllowed(methods []string) http.Handler {
+	allow := strings.Join(methods, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		writeProblem(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, r.Method+" is not allowed, use "+allow, nil)
+	})
+}
+
+// OpenAPI serves the OpenAPI 3 document of the API.
+func (h *WalletHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPI)
+}
@@ -0,0 +1,183 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+var pathParam = regexp.MustCompile(`\{[^}]+\}`)
+
+// documentedOperations fetches the OpenAPI document from the handler and returns its
+// operations as "METHOD path" with path parameters written as {}.
+func documentedOperations(t *testing.T, handler *rest.WalletHandler) map[string]bool {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.OpenAPI(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var doc struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	require.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+
+	operations := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method := range item {
+			operations[strings.ToUpper(method)+" "+pathParam.ReplaceAllString(path, "{}")] = true
+		}
+	}
+
+	return operations
+}
+
+func TestRoutes_Documented(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	handler := rest.NewWalletHandler(mocks.NewMockWalletService(ctrl))
+	documented := documentedOperations(t, handler)
+
+	// the document paths are relative to the version prefix, every version serves all of them
+	served := map[string]map[string]bool{rest.APIv1: {}, rest.APIv2: {}}
+	for _, route := range handler.Routes() {
+		method, path, ok := strings.Cut(route.Pattern, " ")
+		require.True(t, ok, "route %s (%s) has no method", route.Pattern, route.Name)
+
+		var version string
+		for prefix := range served {
+			if rel, ok := strings.CutPrefix(path, prefix+"/"); ok {
+				version, path = prefix, "/"+rel
+			}
+		}
+		require.NotEmpty(t, version, "route %s (%s) is not versioned", route.Pattern, route.Name)
+
+		op := method + " " + pathParam.ReplaceAllString(path, "{}")
+		require.True(t, documented[op], "route %s (%s) is not documented", route.Pattern, route.Name)
+		served[version][op] = true
+	}
+
+	for version, ops := range served {
+		for op := range documented {
+			require.True(t, ops[op], "operation %s is documented but not served under %s", op, version)
+		}
+	}
+}
+
+func TestRegister_Methods(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		setupMock      func(svc *mocks.MockWalletService)
+		expectedStatus int
+		expectedAllow  string
+		expectedCode   string // expectedCode is the problem code of error responses
+	}{
+		{
+			name:           "delete wallet operation",
+			method:         http.MethodDelete,
+			path:           "/api/v1/wallet",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedAllow:  "POST",
+			expectedCode:   rest.CodeMethodNotAllowed,
+		},
+		{
+			name:           "post balance",
+			method:         http.MethodPost,
+			path:           "/api/v2/wallets/" + walletID.String(),
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedAllow:  "GET, HEAD",
+			expectedCode:   rest.CodeMethodNotAllowed,
+		},
+		{
+			name:           "unknown path",
+			method:         http.MethodGet,
+			path:           "/api/v1/wallet/" + walletID.String(),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   rest.CodeNotFound,
+		},
+		{
+			name:           "unknown version",
+			method:         http.MethodPost,
+			path:           "/api/v3/wallet",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   rest.CodeNotFound,
+		},
+		{
+			name:           "list wallets without admin token",
+			method:         http.MethodGet,
+			path:           "/api/v1/wallets",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "v1 balance",
+			method: http.MethodGet,
+			path:   "/api/v1/wallets/" + walletID.String(),
+			setupMock: func(svc *mocks.MockWalletService) {
+				svc.EXPECT().GetBalance(gomock.Any(), walletID).Return(walletModel.Balance{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "v2 balance",
+			method: http.MethodGet,
+			path:   "/api/v2/wallets/" + walletID.String(),
+			setupMock: func(svc *mocks.MockWalletService) {
+				svc.EXPECT().GetBalance(gomock.Any(), walletID).Return(walletModel.Balance{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			recorder := mocks.NewMockAuditRecorder(ctrl)
+			recorder.EXPECT().Record(gomock.Any(), gomock.Any()).AnyTimes()
+			recorder.EXPECT().Flush(gomock.Any()).AnyTimes()
+			if tt.setupMock != nil {
+				tt.setupMock(svc)
+			}
+
+			mux := http.NewServeMux()
+			rest.Register(mux, rest.NewWalletHandler(svc).Routes(), recorder, nil)
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			require.Equal(t, tt.expectedAllow, rec.Header().Get("Allow"))
+			if tt.expectedCode == "" {
+				return
+			}
+			require.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+			var problem handlerModel.Problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			require.Equal(t, tt.expectedCode, problem.Code)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	model "wallet/internal/model/handler"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+func (h *WalletHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req model.ScheduleRequest
+
+	violations, err := decodeStrict(w, r, &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if req.Amount <= 0 {
+		violations = append(violations, model.Field("amount", model.ErrInvalidAmount))
+	}
+
+	if err := validateSchedule(req); err != nil {
+		violations = append(violations, err)
+	}
+
+	if err := joinViolations(violations...); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	sch, err := h.svc.CreateSchedule(r.Context(), wallet.Schedule{
+		Operation:  wallet.Operation(req.OperationType),
+		WalletID:   req.WalletID,
+		ToWalletID: req.ToWalletID,
+		Amount:     req.Amount,
+		Recurrence: req.Cron,
+		NextRunAt:  req.RunAt,
+	})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	json.NewEncoder(w).Encode(scheduleResponse(sch))
+}
+
+func (h *WalletHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, model.Field("id", model.ErrInvalidRequest))
+		return
+	}
+
+	schedules, err := h.svc.ListSchedules(r.Context(), walletID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := make([]model.ScheduleResponse, 0, len(schedules))
+	for _, sch := range schedules {
+		resp = append(resp, scheduleResponse(sch))
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *WalletHandler) CancelSchedule(w http.ResponseWriter, r *http.Request) {
+	scheduleID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, model.Field("id", model.ErrInvalidRequest))
+		return
+	}
+
+	if err := h.svc.CancelSchedule(r.Context(), scheduleID); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateSchedule checks that the request describes exactly one of a one-off or a
+// recurring schedule of a known operation.
+func validateSchedule(req model.ScheduleRequest) error {
+	switch req.OperationType {
+	case model.OperationDeposit, model.OperationWithdraw:
+		if req.ToWalletID != nil {
+			return model.Field("toWalletId", model.ErrInvalidSchedule)
+		}
+	case model.OperationTransfer:
+		if req.ToWalletID == nil || *req.ToWalletID == req.WalletID {
+			return model.Field("toWalletId", model.ErrInvalidSchedule)
+		}
+	default:
+		return model.Field("operationType", model.ErrInvalidSchedule)
+	}
+
+	if (req.RunAt == nil) == (req.Cron == "") {
+		return model.Field("cron", model.ErrInvalidSchedule)
+	}
+
+	if req.RunAt != nil && !req.RunAt.After(time.Now()) {
+		return model.Field("runAt", model.ErrInvalidSchedule)
+	}
+
+	return nil
+}
+
+func scheduleResponse(sch wallet.Schedule) model.ScheduleResponse {
+	return model.ScheduleResponse{
+		ScheduleID:    sch.ID,
+		OperationType: model.OperationType(sch.Operation),
+		WalletID:      sch.WalletID,
+		ToWalletID:    sch.ToWalletID,
+		Amount:        sch.Amount,
+		Cron:          sch.Recurrence,
+		NextRunAt:     sch.NextRunAt,
+		Status:        string(sch.Status),
+		CreatedAt:     sch.CreatedAt,
+	}
+}
@@ -0,0 +1,306 @@
+package rest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletHandler_CreateSchedule(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	walletID, toWalletID := uuid.New(), uuid.New()
+	runAt := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name           string
+		request        handlerModel.ScheduleRequest
+		body           string // body overrides request when set
+		contentType    string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "one-off withdrawal",
+			request: handlerModel.ScheduleRequest{
+				OperationType: handlerModel.OperationWithdraw,
+				WalletID:      walletID,
+				Amount:        100,
+				RunAt:         &runAt,
+			},
+			setupMock: func() {
+				svc.EXPECT().
+					CreateSchedule(gomock.Any(), walletModel.Schedule{
+						Operation: walletModel.OperationWithdraw,
+						WalletID:  walletID,
+						Amount:    100,
+						NextRunAt: &runAt,
+					}).
+					Return(walletModel.Schedule{ID: uuid.New(), Operation: walletModel.OperationWithdraw, WalletID: walletID, Amount: 100, NextRunAt: &runAt, Status: walletModel.ScheduleActive}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "recurring transfer",
+			request: handlerModel.ScheduleRequest{
+				OperationType: handlerModel.OperationTransfer,
+				WalletID:      walletID,
+				ToWalletID:    &toWalletID,
+				Amount:        100,
+				Cron:          "0 9 1 * *",
+			},
+			setupMock: func() {
+				svc.EXPECT().
+					CreateSchedule(gomock.Any(), walletModel.Schedule{
+						Operation:  walletModel.OperationTransfer,
+						WalletID:   walletID,
+						ToWalletID: &toWalletID,
+						Amount:     100,
+						Recurrence: "0 9 1 * *",
+					}).
+					Return(walletModel.Schedule{ID: uuid.New()}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "invalid recurrence",
+			request: handlerModel.ScheduleRequest{
+				OperationType: handlerModel.OperationDeposit,
+				WalletID:      walletID,
+				Amount:        100,
+				Cron:          "every day",
+			},
+			setupMock: func() {
+				svc.EXPECT().
+					CreateSchedule(gomock.Any(), gomock.Any()).
+					Return(walletModel.Schedule{}, walletModel.ErrInvalidRecurrence)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "both run time and recurrence",
+			request: handlerModel.ScheduleRequest{
+				OperationType: handlerModel.OperationDeposit,
+				WalletID:      walletID,
+				Amount:        100,
+				RunAt:         &runAt,
+				Cron:          "0 9 * * *",
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "run time in the past",
+			request: handlerModel.ScheduleRequest{
+				OperationType: handlerModel.OperationDeposit,
+				WalletID:      walletID,
+				Amount:        100,
+				RunAt:         &past,
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "transfer without destination",
+			request: handlerModel.ScheduleRequest{
+				OperationType: handlerModel.OperationTransfer,
+				WalletID:      walletID,
+				Amount:        100,
+				Cron:          "0 9 * * *",
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "deposit with destination",
+			request: handlerModel.ScheduleRequest{
+				OperationType: handlerModel.OperationDeposit,
+				WalletID:      walletID,
+				ToWalletID:    &toWalletID,
+				Amount:        100,
+				Cron:          "0 9 * * *",
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown field",
+			body:           `{"operationType":"DEPOSIT","walletId":"` + walletID.String() + `","amount":100,"cron":"0 9 * * *","every":"day"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not json",
+			body:           `{"operationType":"DEPOSIT","walletId":"` + walletID.String() + `","amount":100,"cron":"0 9 * * *"}`,
+			contentType:    "text/plain",
+			setupMock:      func() {},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			body := []byte(tt.body)
+			if tt.body == "" {
+				var err error
+				body, err = json.Marshal(tt.request)
+				require.NoError(t, err)
+			}
+
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/schedules", bytes.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			rec := httptest.NewRecorder()
+
+			handler.CreateSchedule(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.expectedStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestWalletHandler_ListSchedules(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	walletID := uuid.New()
+	next := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	created := time.Now().Truncate(time.Second).UTC()
+	sch := walletModel.Schedule{
+		ID:         uuid.New(),
+		Operation:  walletModel.OperationDeposit,
+		WalletID:   walletID,
+		Amount:     100,
+		Recurrence: "0 * * * *",
+		NextRunAt:  &next,
+		Status:     walletModel.ScheduleActive,
+		CreatedAt:  created,
+	}
+
+	svc.EXPECT().
+		ListSchedules(gomock.Any(), walletID).
+		Return([]walletModel.Schedule{sch}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/schedules", nil)
+	req.SetPathValue("id", walletID.String())
+	rec := httptest.NewRecorder()
+
+	handler.ListSchedules(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var resp []handlerModel.ScheduleResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+	require.Equal(t, []handlerModel.ScheduleResponse{{
+		ScheduleID:    sch.ID,
+		OperationType: handlerModel.OperationDeposit,
+		WalletID:      walletID,
+		Amount:        100,
+		Cron:          "0 * * * *",
+		NextRunAt:     &next,
+		Status:        "ACTIVE",
+		CreatedAt:     created,
+	}}, resp)
+}
+
+func TestWalletHandler_CancelSchedule(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	scheduleID := uuid.New()
+
+	tests := []struct {
+		name           string
+		id             string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "cancelled",
+			id:   scheduleID.String(),
+			setupMock: func() {
+				svc.EXPECT().CancelSchedule(gomock.Any(), scheduleID).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "not active",
+			id:   scheduleID.String(),
+			setupMock: func() {
+				svc.EXPECT().CancelSchedule(gomock.Any(), scheduleID).Return(walletModel.ErrScheduleNotActive)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "not found",
+			id:   scheduleID.String(),
+			setupMock: func() {
+				svc.EXPECT().CancelSchedule(gomock.Any(), scheduleID).Return(walletModel.ErrScheduleNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "invalid id",
+			id:             "invalid-uuid",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/schedules/"+tt.id, nil)
+			req.SetPathValue("id", tt.id)
+			rec := httptest.NewRecorder()
+
+			handler.CancelSchedule(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.expectedStatus, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package rest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	model "wallet/internal/model/handler"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// Statement streams the wallet statement for [from, to) in the requested format: the opening
+// balance, every journal entry with the running balance, and the closing balance. from is
+// required, to defaults to now; both are RFC 3339 timestamps.
+func (h *WalletHandler) Statement(w http.ResponseWriter, r *http.Request) {
+	walletID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.handleError(w, r, model.Field("id", model.ErrInvalidRequest))
+		return
+	}
+
+	query := r.URL.Query()
+
+	from, err := time.Parse(time.RFC3339Nano, query.Get("from"))
+	if err != nil {
+		h.handleError(w, r, model.Field("from", model.ErrInvalidRequest))
+		return
+	}
+
+	to := time.Now()
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			h.handleError(w, r, model.Field("to", model.ErrInvalidRequest))
+			return
+		}
+	}
+
+	if !from.Before(to) {
+		h.handleError(w, r, model.Field("to", model.ErrInvalidRequest))
+		return
+	}
+
+	sw := &statementWriter{w: w, walletID: walletID, from: from, to: to}
+	switch format := query.Get("format"); format {
+	case "", model.StatementJSON:
+		sw.format = &jsonStatement{}
+	case model.StatementNDJSON:
+		sw.format = &ndjsonStatement{}
+	case model.StatementCSV:
+		sw.format = &csvStatement{}
+	default:
+		h.handleError(w, r, model.Field("format", model.ErrInvalidRequest))
+		return
+	}
+
+	if err := h.svc.Statement(r.Context(), walletID, from, to, sw); err != nil {
+		if sw.started {
+			// the status is already sent, abort the response so that the client
+			// does not take a truncated statement for a complete one
+			panic(http.ErrAbortHandler)
+		}
+		h.handleError(w, r, err)
+	}
+}
+
+// statementFormat renders the statement records of one format.
+type statementFormat interface {
+	contentType() string
+	opening(w io.Writer, walletID uuid.UUID, at time.Time, balance int64) error
+	line(w io.Writer, line wallet.StatementLine) error
+	closing(w io.Writer, walletID uuid.UUID, at time.Time, balance int64) error
+}
+
+// statementWriter sends the response headers once the opening balance is known, which
+// tells a missing wallet from an empty statement, and writes records as they arrive.
+type statementWriter struct {
+	w        http.ResponseWriter
+	format   statementFormat
+	walletID uuid.UUID
+	from, to time.Time
+	started  bool
+}
+
+func (sw *statementWriter) Opening(balance int64) error {
+	sw.started = true
+	sw.w.Header().Set("Content-Type", sw.format.contentType())
+	sw.w.WriteHeader(http.StatusOK)
+
+	return sw.format.opening(sw.w, sw.walletID, sw.from, balance)
+}
+
+func (sw *statementWriter) Line(line wallet.StatementLine) error {
+	return sw.format.line(sw.w, line)
+}
+
+func (sw *statementWriter) Closing(balance int64) error {
+	return sw.format.closing(sw.w, sw.walletID, sw.to, balance)
+}
+
+func lineResponse(line wallet.StatementLine) model.StatementLineResponse {
+	return model.StatementLineResponse{
+		EntryID:   line.ID,
+		Type:      string(line.Type),
+		Bucket:    line.Bucket,
+		Amount:    line.Amount,
+		Balance:   line.Balance,
+		CreatedAt: line.CreatedAt,
+	}
+}
+
+// jsonStatement writes a single JSON document, entries in between the balances:
+// {"walletId", "from", "openingBalance", "entries": [...], "to", "closingBalance"}.
+type jsonStatement struct {
+	entries int
+}
+
+func (s *jsonStatement) contentType() string { return "application/json" }
+
+func (s *jsonStatement) opening(w io.Writer, walletID uuid.UUID, at time.Time, balance int64) error {
+	_, err := fmt.Fprintf(w, `{"walletId":"%s","from":"%s","openingBalance":%d,"entries":[`,
+		walletID, at.Format(time.RFC3339Nano), balance)
+	return err
+}
+
+func (s *jsonStatement) line(w io.Writer, line wallet.StatementLine) error {
+	data, err := json.Marshal(lineResponse(line))
+	if err != nil {
+		return err
+	}
+
+	if s.entries > 0 {
+		data = append([]byte{','}, data...)
+	}
+	s.entries++
+
+	_, err = w.Write(data)
+	return err
+}
+
+func (s *jsonStatement) closing(w io.Writer, _ uuid.UUID, at time.Time, balance int64) error {
+	_, err := fmt.Fprintf(w, `],"to":"%s","closingBalance":%d}`+"\n", at.Format(time.RFC3339Nano), balance)
+	return err
+}
+
+// ndjsonStatement writes one JSON object per line: the opening balance, the entries
+// and the closing balance, told apart by their record field.
+type ndjsonStatement struct{}
+
+func (ndjsonStatement) contentType() string { return "application/x-ndjson" }
+
+func (ndjsonStatement) opening(w io.Writer, walletID uuid.UUID, at time.Time, balance int64) error {
+	return json.NewEncoder(w).Encode(model.StatementBalanceResponse{Record: "opening", WalletID: walletID, At: at, Balance: balance})
+}
+
+func (ndjsonStatement) line(w io.Writer, line wallet.StatementLine) error {
+	resp := lineResponse(line)
+	resp.Record = "entry"
+
+	return json.NewEncoder(w).Encode(resp)
+}
+
+func (ndjsonStatement) closing(w io.Writer, walletID uuid.UUID, at time.Time, balance int64) error {
+	return json.NewEncoder(w).Encode(model.StatementBalanceResponse{Record: "closing", WalletID: walletID, At: at, Balance: balance})
+}
+
+// csvStatement writes a header and one row per record; the opening and closing balances
+// are rows of type OPENING_BALANCE and CLOSING_BALANCE without an entry id.
+type csvStatement struct {
+	w *csv.Writer
+}
+
+func (s *csvStatement) contentType() string { return "text/csv" }
+
+func (s *csvStatement) opening(w io.Writer, _ uuid.UUID, at time.Time, balance int64) error {
+	s.w = csv.NewWriter(w)
+	if err := s.w.Write([]string{"createdAt", "entryId", "type", "bucket", "amount", "balance"}); err != nil {
+		return err
+	}
+
+	return s.balance("OPENING_BALANCE", at, balance)
+}
+
+func (s *csvStatement) line(_ io.Writer, line wallet.StatementLine) error {
+	return s.w.Write([]string{
+		line.CreatedAt.Format(time.RFC3339Nano),
+		strconv.FormatInt(line.ID, 10),
+		string(line.Type),
+		line.Bucket,
+		strconv.FormatInt(line.Amount, 10),
+		strconv.FormatInt(line.Balance, 10),
+	})
+}
+
+func (s *csvStatement) closing(_ io.Writer, _ uuid.UUID, at time.Time, balance int64) error {
+	if err := s.balance("CLOSING_BALANCE", at, balance); err != nil {
+		return err
+	}
+
+	s.w.Flush()
+	return s.w.Error()
+}
+
+func (s *csvStatement) balance(record string, at time.Time, balance int64) error {
+	return s.w.Write([]string{at.Format(time.RFC3339Nano), "", record, "", "", strconv.FormatInt(balance, 10)})
+}
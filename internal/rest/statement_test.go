@@ -0,0 +1,170 @@
+package rest_test
+
+import (
+	"context"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletHandler_Statement(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	walletID := uuid.MustParse("c8b43e22-3cc0-4647-b18b-53fba78d6fed")
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+
+	writeStatement := func(_ context.Context, _ uuid.UUID, _, _ time.Time, w walletModel.StatementWriter) error {
+		if err := w.Opening(500); err != nil {
+			return err
+		}
+		lines := []walletModel.StatementLine{
+			{Entry: walletModel.Entry{ID: 7, Type: walletModel.EntryDeposit, Bucket: "main", Amount: 100, CreatedAt: createdAt}, Balance: 600},
+			{Entry: walletModel.Entry{ID: 8, Type: walletModel.EntryWithdraw, Bucket: "main", Amount: -40, CreatedAt: createdAt}, Balance: 560},
+		}
+		for _, line := range lines {
+			if err := w.Line(line); err != nil {
+				return err
+			}
+		}
+		return w.Closing(560)
+	}
+
+	tests := []struct {
+		name                string
+		query               string
+		setupMock           func()
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:  "json",
+			query: "?from=2026-03-01T00:00:00Z&to=2026-04-01T00:00:00Z",
+			setupMock: func() {
+				svc.EXPECT().Statement(gomock.Any(), walletID, from, to, gomock.Any()).DoAndReturn(writeStatement)
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody: `{"walletId":"c8b43e22-3cc0-4647-b18b-53fba78d6fed","from":"2026-03-01T00:00:00Z","openingBalance":500,"entries":[` +
+				`{"entryId":7,"type":"DEPOSIT","bucket":"main","amount":100,"balance":600,"createdAt":"2026-03-15T10:00:00Z"},` +
+				`{"entryId":8,"type":"WITHDRAW","bucket":"main","amount":-40,"balance":560,"createdAt":"2026-03-15T10:00:00Z"}` +
+				`],"to":"2026-04-01T00:00:00Z","closingBalance":560}` + "\n",
+		},
+		{
+			name:  "ndjson",
+			query: "?from=2026-03-01T00:00:00Z&to=2026-04-01T00:00:00Z&format=ndjson",
+			setupMock: func() {
+				svc.EXPECT().Statement(gomock.Any(), walletID, from, to, gomock.Any()).DoAndReturn(writeStatement)
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody: `{"record":"opening","walletId":"c8b43e22-3cc0-4647-b18b-53fba78d6fed","at":"2026-03-01T00:00:00Z","balance":500}` + "\n" +
+				`{"record":"entry","entryId":7,"type":"DEPOSIT","bucket":"main","amount":100,"balance":600,"createdAt":"2026-03-15T10:00:00Z"}` + "\n" +
+				`{"record":"entry","entryId":8,"type":"WITHDRAW","bucket":"main","amount":-40,"balance":560,"createdAt":"2026-03-15T10:00:00Z"}` + "\n" +
+				`{"record":"closing","walletId":"c8b43e22-3cc0-4647-b18b-53fba78d6fed","at":"2026-04-01T00:00:00Z","balance":560}` + "\n",
+		},
+		{
+			name:  "csv",
+			query: "?from=2026-03-01T00:00:00Z&to=2026-04-01T00:00:00Z&format=csv",
+			setupMock: func() {
+				svc.EXPECT().Statement(gomock.Any(), walletID, from, to, gomock.Any()).DoAndReturn(writeStatement)
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedBody: "createdAt,entryId,type,bucket,amount,balance\n" +
+				"2026-03-01T00:00:00Z,,OPENING_BALANCE,,,500\n" +
+				"2026-03-15T10:00:00Z,7,DEPOSIT,main,100,600\n" +
+				"2026-03-15T10:00:00Z,8,WITHDRAW,main,-40,560\n" +
+				"2026-04-01T00:00:00Z,,CLOSING_BALANCE,,,560\n",
+		},
+		{
+			name:  "wallet not found",
+			query: "?from=2026-03-01T00:00:00Z&to=2026-04-01T00:00:00Z",
+			setupMock: func() {
+				svc.EXPECT().Statement(gomock.Any(), walletID, from, to, gomock.Any()).Return(walletModel.ErrWalletNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "missing from",
+			query:          "?to=2026-04-01T00:00:00Z",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "from after to",
+			query:          "?from=2026-04-01T00:00:00Z&to=2026-03-01T00:00:00Z",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown format",
+			query:          "?from=2026-03-01T00:00:00Z&format=xml",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/statement"+tt.query, nil)
+			req.SetPathValue("id", walletID.String())
+			rec := httptest.NewRecorder()
+
+			handler.Statement(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				require.Equal(t, tt.expectedContentType, rec.Header().Get("Content-Type"))
+				require.Equal(t, tt.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestWalletHandler_Statement_AbortsOnError(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	walletID := uuid.New()
+
+	svc.EXPECT().
+		Statement(gomock.Any(), walletID, gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, _, _ time.Time, w walletModel.StatementWriter) error {
+			if err := w.Opening(500); err != nil {
+				return err
+			}
+			return context.DeadlineExceeded
+		})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+walletID.String()+"/statement?from=2026-03-01T00:00:00Z", nil)
+	req.SetPathValue("id", walletID.String())
+	rec := httptest.NewRecorder()
+
+	require.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.Statement(rec, req)
+	})
+}
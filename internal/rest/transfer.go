@@ -0,0 +1,102 @@
+package rest
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
+	model "wallet/internal/model/handler"
+)
+
+// currencyCode matches ISO 4217 alphabetic codes.
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+func (h *WalletHandler) CreateQuote(w http.ResponseWriter, r *http.Request) {
+	var req model.QuoteRequest
+
+	violations, err := decodeStrict(w, r, &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if !currencyCode.MatchString(req.FromCurrency) {
+		violations = append(violations, model.Field("fromCurrency", model.ErrInvalidCurrency))
+	}
+
+	if !currencyCode.MatchString(req.ToCurrency) || req.FromCurrency == req.ToCurrency {
+		violations = append(violations, model.Field("toCurrency", model.ErrInvalidCurrency))
+	}
+
+	if err := joinViolations(violations...); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	quote, err := h.svc.Quote(r.Context(), req.FromCurrency, req.ToCurrency)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := model.QuoteResponse{
+		QuoteID:      quote.ID,
+		FromCurrency: quote.FromCurrency,
+		ToCurrency:   quote.ToCurrency,
+		Rate:         formatRate(quote.Rate),
+		ExpiresAt:    quote.ExpiresAt,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h *WalletHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	var req model.TransferRequest
+
+	violations, err := decodeStrict(w, r, &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if req.Amount <= 0 {
+		violations = append(violations, model.Field("amount", model.ErrInvalidAmount))
+	}
+
+	if err := joinViolations(violations...); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	transfer, err := h.svc.Transfer(r.Context(), req.FromWalletID, req.ToWalletID, req.Amount, req.QuoteID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	resp := model.TransferResponse{
+		TransferID:   transfer.ID,
+		FromWalletID: transfer.FromWalletID,
+		ToWalletID:   transfer.ToWalletID,
+		FromCurrency: transfer.FromCurrency,
+		ToCurrency:   transfer.ToCurrency,
+		QuoteID:      transfer.QuoteID,
+		Rate:         formatRate(transfer.Rate),
+		DebitAmount:  transfer.DebitAmount,
+		CreditAmount: transfer.CreditAmount,
+		Fee:          transfer.Fee,
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// formatRate renders the rate as a decimal without trailing zeros.
+func formatRate(rate *big.Rat) string {
+	s := rate.FloatString(18)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
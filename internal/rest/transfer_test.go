@@ -0,0 +1,256 @@
+package rest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"go.uber.org/mock/gomock"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rest"
+	"wallet/internal/rest/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletHandler_CreateQuote(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	quote := walletModel.Quote{
+		ID:           uuid.New(),
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+		Rate:         big.NewRat(23, 25),
+		ExpiresAt:    time.Now().Add(30 * time.Second).Truncate(time.Second).UTC(),
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		contentType    string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name: "quote created",
+			body: `{"fromCurrency": "USD", "toCurrency": "EUR"}`,
+			setupMock: func() {
+				svc.EXPECT().Quote(gomock.Any(), "USD", "EUR").Return(quote, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "invalid currency",
+			body:           `{"fromCurrency": "usd", "toCurrency": "EUR"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "same currency",
+			body:           `{"fromCurrency": "USD", "toCurrency": "USD"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "rate not available",
+			body: `{"fromCurrency": "USD", "toCurrency": "XAU"}`,
+			setupMock: func() {
+				svc.EXPECT().Quote(gomock.Any(), "USD", "XAU").Return(walletModel.Quote{}, walletModel.ErrRateNotFound)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "unknown field",
+			body:           `{"fromCurrency": "USD", "toCurrency": "EUR", "amount": 100}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not json",
+			body:           `{"fromCurrency": "USD", "toCurrency": "EUR"}`,
+			contentType:    "text/plain",
+			setupMock:      func() {},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/fx/quotes", bytes.NewReader([]byte(tt.body)))
+			req.Header.Set("Content-Type", contentType)
+			rec := httptest.NewRecorder()
+
+			handler.CreateQuote(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.expectedStatus, res.StatusCode)
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			var resp handlerModel.QuoteResponse
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+			require.Equal(t, handlerModel.QuoteResponse{
+				QuoteID:      quote.ID,
+				FromCurrency: "USD",
+				ToCurrency:   "EUR",
+				Rate:         "0.92",
+				ExpiresAt:    quote.ExpiresAt,
+			}, resp)
+		})
+	}
+}
+
+func TestWalletHandler_Transfer(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	fromID, toID, quoteID := uuid.New(), uuid.New(), uuid.New()
+	transfer := walletModel.Transfer{
+		ID:           uuid.New(),
+		FromWalletID: fromID,
+		ToWalletID:   toID,
+		FromCurrency: "USD",
+		ToCurrency:   "EUR",
+		QuoteID:      &quoteID,
+		Rate:         big.NewRat(23, 25),
+		DebitAmount:  100,
+		CreditAmount: 92,
+		Fee:          1,
+	}
+
+	tests := []struct {
+		name           string
+		request        handlerModel.TransferRequest
+		body           string // body replaces the encoded request when set
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name:    "transferred",
+			request: handlerModel.TransferRequest{FromWalletID: fromID, ToWalletID: toID, Amount: 100, QuoteID: &quoteID},
+			setupMock: func() {
+				svc.EXPECT().Transfer(gomock.Any(), fromID, toID, int64(100), &quoteID).Return(transfer, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid amount",
+			request:        handlerModel.TransferRequest{FromWalletID: fromID, ToWalletID: toID},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "quote required",
+			request: handlerModel.TransferRequest{FromWalletID: fromID, ToWalletID: toID, Amount: 50},
+			setupMock: func() {
+				svc.EXPECT().Transfer(gomock.Any(), fromID, toID, int64(50), nil).Return(walletModel.Transfer{}, walletModel.ErrQuoteRequired)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "quote expired",
+			request: handlerModel.TransferRequest{FromWalletID: fromID, ToWalletID: toID, Amount: 60, QuoteID: &quoteID},
+			setupMock: func() {
+				svc.EXPECT().Transfer(gomock.Any(), fromID, toID, int64(60), &quoteID).Return(walletModel.Transfer{}, walletModel.ErrQuoteExpired)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:    "quote not found",
+			request: handlerModel.TransferRequest{FromWalletID: fromID, ToWalletID: toID, Amount: 70, QuoteID: &quoteID},
+			setupMock: func() {
+				svc.EXPECT().Transfer(gomock.Any(), fromID, toID, int64(70), &quoteID).Return(walletModel.Transfer{}, walletModel.ErrQuoteNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:    "currency mismatch",
+			request: handlerModel.TransferRequest{FromWalletID: fromID, ToWalletID: toID, Amount: 80, QuoteID: &quoteID},
+			setupMock: func() {
+				svc.EXPECT().Transfer(gomock.Any(), fromID, toID, int64(80), &quoteID).Return(walletModel.Transfer{}, walletModel.ErrCurrencyMismatch)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "unknown field",
+			body:           `{"fromWalletId":"` + fromID.String() + `","toWalletId":"` + toID.String() + `","amount":100,"currency":"USD"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "too large",
+			body:           `{"fromWalletId":"` + strings.Repeat(" ", 64<<10) + `"}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			body := []byte(tt.body)
+			if tt.body == "" {
+				var err error
+				body, err = json.Marshal(tt.request)
+				require.NoError(t, err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/transfers", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			handler.Transfer(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.expectedStatus, res.StatusCode)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp handlerModel.TransferResponse
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+			require.Equal(t, handlerModel.TransferResponse{
+				TransferID:   transfer.ID,
+				FromWalletID: fromID,
+				ToWalletID:   toID,
+				FromCurrency: "USD",
+				ToCurrency:   "EUR",
+				QuoteID:      &quoteID,
+				Rate:         "0.92",
+				DebitAmount:  100,
+				CreditAmount: 92,
+				Fee:          1,
+			}, resp)
+		})
+	}
+}
@@ -3,8 +3,10 @@ package rest
 import (
 	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 	model "wallet/internal/model/handler"
 	"wallet/internal/model/wallet"
 
@@ -12,85 +14,200 @@ import (
 )
 
 type WalletService interface {
-	Deposit(ctx context.Context, walletID uuid.UUID, amount int64) error
-	Withdraw(ctx context.Context, walletID uuid.UUID, amount int64) error
-	GetBalance(ctx context.Context, walletID uuid.UUID) (int64, error)
+	Deposit(ctx context.Context, walletID uuid.UUID, credit wallet.Credit, metadata wallet.Metadata, expectedVersion *int64) (wallet.Receipt, error)
+	Withdraw(ctx context.Context, walletID uuid.UUID, amount int64, metadata wallet.Metadata, expectedVersion *int64) (wallet.Receipt, error)
+	GetBalance(ctx context.Context, walletID uuid.UUID) (wallet.Balance, error)
+	Quote(ctx context.Context, from, to string) (wallet.Quote, error)
+	Transfer(ctx context.Context, fromID, toID uuid.UUID, amount int64, quoteID *uuid.UUID) (wallet.Transfer, error)
+	CreateSchedule(ctx context.Context, schedule wallet.Schedule) (wallet.Schedule, error)
+	ListSchedules(ctx context.Context, walletID uuid.UUID) ([]wallet.Schedule, error)
+	CancelSchedule(ctx context.Context, scheduleID uuid.UUID) error
+	BalanceAt(ctx context.Context, walletID uuid.UUID, at time.Time) (wallet.Balance, error)
+	Reconcile(ctx context.Context) (wallet.Reconciliation, error)
+	Statement(ctx context.Context, walletID uuid.UUID, from, to time.Time, w wallet.StatementWriter) error
+	SetMetadata(ctx context.Context, walletID uuid.UUID, metadata wallet.Metadata) error
+	Operations(ctx context.Context, walletID uuid.UUID, filter wallet.OperationFilter) ([]wallet.OperationRecord, error)
+	ListWallets(ctx context.Context, filter wallet.WalletFilter) (wallet.WalletPage, error)
+	CreateWallet(ctx context.Context, w wallet.Wallet) (wallet.Wallet, error)
+	SetStatus(ctx context.Context, walletID uuid.UUID, status wallet.Status) error
+	ProposeAdjustment(ctx context.Context, adj wallet.Adjustment) (wallet.AdjustmentRequest, error)
+	ApproveAdjustment(ctx context.Context, requestID uuid.UUID, principal, note string) (wallet.AdjustmentRequest, int64, error)
+	RejectAdjustment(ctx context.Context, requestID uuid.UUID, principal, note string) (wallet.AdjustmentRequest, error)
+	AdjustmentRequests(ctx context.Context, status wallet.AdjustmentStatus, limit int) ([]wallet.AdjustmentRequest, error)
+	AdjustmentRequest(ctx context.Context, requestID uuid.UUID) (wallet.AdjustmentRequest, []wallet.AdjustmentEvent, error)
+	WatchBalance(ctx context.Context, walletID uuid.UUID, lastVersion *int64) (wallet.Balance, <-chan wallet.BalanceEvent, error)
 }
 
 type WalletHandler struct {
-	svc WalletService
+	svc               WalletService
+	maxAmount         int64
+	heartbeatInterval time.Duration
+	maxStreamDuration time.Duration
+	stopping          chan struct{}
+	stopOnce          sync.Once
 }
 
-func NewWalletHandler(svc WalletService) *WalletHandler {
-	return &WalletHandler{
-		svc: svc,
+type HandlerOption func(*WalletHandler)
+
+// WithEventStreams overrides how often balance event streams send heartbeats and how long
+// they stay open before the client has to reconnect.
+func WithEventStreams(heartbeatInterval, maxDuration time.Duration) HandlerOption {
+	return func(h *WalletHandler) {
+		h.heartbeatInterval = heartbeatInterval
+		h.maxStreamDuration = maxDuration
+	}
+}
+
+// WithMaxAmount overrides model.DefaultMaxAmount.
+func WithMaxAmount(max int64) HandlerOption {
+	return func(h *WalletHandler) {
+		h.maxAmount = max
+	}
+}
+
+func NewWalletHandler(svc WalletService, opts ...HandlerOption) *WalletHandler {
+	h := &WalletHandler{
+		svc:               svc,
+		maxAmount:         model.DefaultMaxAmount,
+		heartbeatInterval: defaultHeartbeatInterval,
+		maxStreamDuration: defaultMaxStreamDuration,
+		stopping:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+
+	return h
 }
 
+// WalletOperation deposits into or withdraws from a wallet. A committed operation is answered
+// with 200 and the transaction: its id in the wallet history, the resulting balance and the
+// time it was recorded. The endpoint does not answer 201, as transactions are not resources
+// of their own; nothing is recorded for failed operations. With an If-Match ETag of the
+// balance, the operation is only made while the balance is unchanged, 412 otherwise.
 func (h *WalletHandler) WalletOperation(w http.ResponseWriter, r *http.Request) {
 	var req model.WalletOperationRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.handleError(w, model.ErrInvalidRequest)
+	violations, err := decodeStrict(w, r, &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	violations = append(violations, h.validateOperation(&req)...)
+
+	expectedVersion, err := ifMatch(r)
+	if err != nil {
+		violations = append(violations, err)
+	}
+
+	if err := joinViolations(violations...); err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 
-	if req.Amount <= 0 {
-		h.handleError(w, model.ErrInvalidAmount)
+	var receipt wallet.Receipt
+
+	if req.OperationType == model.OperationDeposit {
+		credit := wallet.Credit{Bucket: req.Bucket, Amount: req.Amount, ExpiresAt: req.ExpiresAt}
+		receipt, err = h.svc.Deposit(r.Context(), req.WalletID, credit, req.Metadata, expectedVersion)
+	} else {
+		receipt, err = h.svc.Withdraw(r.Context(), req.WalletID, req.Amount, req.Metadata, expectedVersion)
+	}
+
+	if err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 
+	resp := model.WalletOperationResult{
+		WalletID:      req.WalletID,
+		TransactionID: receipt.TransactionID,
+		OperationType: req.OperationType,
+		Amount:        req.Amount,
+		Fee:           receipt.Fee,
+		Balance:       receipt.Balance,
+		Metadata:      req.Metadata,
+		CreatedAt:     receipt.CreatedAt,
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// validateOperation returns a field error for every invalid field of the request and sets
+// the default bucket of deposits.
+func (h *WalletHandler) validateOperation(req *model.WalletOperationRequest) []error {
+	var violations []error
+
+	if req.WalletID == uuid.Nil {
+		violations = append(violations, model.Field("walletId", model.ErrInvalidWalletID))
+	}
+
+	if req.Amount <= 0 || req.Amount > h.maxAmount {
+		violations = append(violations, model.Field("amount",
+			fmt.Errorf("%w: must be between 1 and %d", model.ErrInvalidAmount, h.maxAmount)))
+	}
+
+	if err := model.ValidateMetadata(req.Metadata); err != nil {
+		violations = append(violations, model.Field("metadata", err))
+	}
+
 	switch req.OperationType {
 	case model.OperationDeposit:
-		if err := h.svc.Deposit(r.Context(), req.WalletID, req.Amount); err != nil {
-			h.handleError(w, err)
-			return
+		if req.Bucket == "" {
+			req.Bucket = wallet.BucketMain
+		}
+
+		if !model.BucketName.MatchString(req.Bucket) {
+			violations = append(violations, model.Field("bucket", model.ErrInvalidBucket))
+		}
+
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			violations = append(violations, model.Field("expiresAt", model.ErrInvalidExpiry))
 		}
 	case model.OperationWithdraw:
-		if err := h.svc.Withdraw(r.Context(), req.WalletID, req.Amount); err != nil {
-			h.handleError(w, err)
-			return
+		// withdrawals spend the buckets in withdrawal order and create no expiring credit
+		if req.Bucket != "" {
+			violations = append(violations, model.Field("bucket", model.ErrInvalidBucket))
+		}
+
+		if req.ExpiresAt != nil {
+			violations = append(violations, model.Field("expiresAt", model.ErrInvalidExpiry))
 		}
 	default:
-		http.Error(w, "invalid operation type", http.StatusBadRequest)
-		return
+		violations = append(violations, model.Field("operationType", model.ErrInvalidOperationType))
 	}
 
-	w.WriteHeader(http.StatusOK)
+	return violations
 }
 
+// GetBalance returns the current balance of a wallet with its version as ETag, answering
+// 304 when the If-None-Match tags include it.
 func (h *WalletHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
-	// getting wallet uuid
-	walletIDStr := r.URL.Path[len("/api/v1/wallets/"):]
-	walletID, err := uuid.Parse(walletIDStr)
+	walletID, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		h.handleError(w, model.ErrInvalidRequest)
+		h.handleError(w, r, model.Field("id", model.ErrInvalidRequest))
 		return
 	}
 
 	balance, err := h.svc.GetBalance(r.Context(), walletID)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
+		return
+	}
+
+	// clients may keep the balance, but have to revalidate it with If-None-Match
+	tag := etag(balance.Version)
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if noneMatch(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	resp := model.WalletOperationResponse{WalletID: walletID, Balance: balance}
+	resp := model.WalletOperationResponse{WalletID: walletID, Balance: balance.Total, Buckets: balance.Buckets, Metadata: balance.Metadata}
 	w.Header().Set("Content-Type", "application/json")
 
 	json.NewEncoder(w).Encode(resp)
 }
-
-func (h *WalletHandler) handleError(w http.ResponseWriter, err error) {
-	switch {
-	case errors.Is(err, wallet.ErrWalletNotFound):
-		http.Error(w, err.Error(), http.StatusNotFound)
-	case errors.Is(err, wallet.ErrNotEnoughMoney):
-		http.Error(w, err.Error(), http.StatusConflict)
-	case errors.Is(err, model.ErrInvalidAmount):
-		http.Error(w, err.Error(), http.StatusBadRequest)
-	case errors.Is(err, model.ErrInvalidRequest):
-		http.Error(w, err.Error(), http.StatusBadRequest)
-	default:
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-	}
-}
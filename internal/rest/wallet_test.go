@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 	handlerModel "wallet/internal/model/handler"
 	walletModel "wallet/internal/model/wallet"
 	"wallet/internal/rest"
@@ -29,6 +30,8 @@ func TestWalletHandler_WalletOperation(t *testing.T) {
 	handler := rest.NewWalletHandler(svc)
 
 	walletID := uuid.New()
+	expiresAt := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	expired := time.Now().Add(-time.Hour)
 
 	tests := []struct {
 		name           string
@@ -45,8 +48,8 @@ func TestWalletHandler_WalletOperation(t *testing.T) {
 			},
 			setupMock: func() {
 				svc.EXPECT().
-					Deposit(gomock.Any(), walletID, int64(100)).
-					Return(nil)
+					Deposit(gomock.Any(), walletID, walletModel.Credit{Bucket: walletModel.BucketMain, Amount: 100}, nil, gomock.Nil()).
+					Return(walletModel.Receipt{}, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -59,11 +62,37 @@ func TestWalletHandler_WalletOperation(t *testing.T) {
 			},
 			setupMock: func() {
 				svc.EXPECT().
-					Withdraw(gomock.Any(), walletID, int64(50)).
-					Return(nil)
+					Withdraw(gomock.Any(), walletID, int64(50), nil, gomock.Nil()).
+					Return(walletModel.Receipt{}, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "deposit with metadata",
+			request: handlerModel.WalletOperationRequest{
+				WalletID:      walletID,
+				Amount:        100,
+				OperationType: handlerModel.OperationDeposit,
+				Metadata:      map[string]string{"customerId": "c-42"},
+			},
+			setupMock: func() {
+				svc.EXPECT().
+					Deposit(gomock.Any(), walletID, walletModel.Credit{Bucket: walletModel.BucketMain, Amount: 100}, walletModel.Metadata{"customerId": "c-42"}, gomock.Nil()).
+					Return(walletModel.Receipt{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "metadata with empty key",
+			request: handlerModel.WalletOperationRequest{
+				WalletID:      walletID,
+				Amount:        100,
+				OperationType: handlerModel.OperationDeposit,
+				Metadata:      map[string]string{"": "c-42"},
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "invalid operation",
 			request: handlerModel.WalletOperationRequest{
@@ -84,6 +113,83 @@ func TestWalletHandler_WalletOperation(t *testing.T) {
 			setupMock:      func() {},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "deposit to bonus bucket",
+			request: handlerModel.WalletOperationRequest{
+				WalletID:      walletID,
+				Amount:        70,
+				OperationType: handlerModel.OperationDeposit,
+				Bucket:        "bonus",
+			},
+			setupMock: func() {
+				svc.EXPECT().
+					Deposit(gomock.Any(), walletID, walletModel.Credit{Bucket: "bonus", Amount: 70}, nil, gomock.Nil()).
+					Return(walletModel.Receipt{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "invalid bucket name",
+			request: handlerModel.WalletOperationRequest{
+				WalletID:      walletID,
+				Amount:        70,
+				OperationType: handlerModel.OperationDeposit,
+				Bucket:        "Bonus!",
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "withdraw from bucket",
+			request: handlerModel.WalletOperationRequest{
+				WalletID:      walletID,
+				Amount:        70,
+				OperationType: handlerModel.OperationWithdraw,
+				Bucket:        "bonus",
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "deposit with expiry",
+			request: handlerModel.WalletOperationRequest{
+				WalletID:      walletID,
+				Amount:        80,
+				OperationType: handlerModel.OperationDeposit,
+				Bucket:        "bonus",
+				ExpiresAt:     &expiresAt,
+			},
+			setupMock: func() {
+				svc.EXPECT().
+					Deposit(gomock.Any(), walletID, gomock.Cond(func(c walletModel.Credit) bool {
+						return c.Bucket == "bonus" && c.Amount == 80 && c.ExpiresAt.Equal(expiresAt)
+					}), nil, gomock.Nil()).
+					Return(walletModel.Receipt{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "expiry in the past",
+			request: handlerModel.WalletOperationRequest{
+				WalletID:      walletID,
+				Amount:        80,
+				OperationType: handlerModel.OperationDeposit,
+				ExpiresAt:     &expired,
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "withdraw with expiry",
+			request: handlerModel.WalletOperationRequest{
+				WalletID:      walletID,
+				Amount:        80,
+				OperationType: handlerModel.OperationWithdraw,
+				ExpiresAt:     &expiresAt,
+			},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "withdraw not enough money",
 			request: handlerModel.WalletOperationRequest{
@@ -93,11 +199,25 @@ func TestWalletHandler_WalletOperation(t *testing.T) {
 			},
 			setupMock: func() {
 				svc.EXPECT().
-					Withdraw(gomock.Any(), walletID, int64(200)).
-					Return(walletModel.ErrNotEnoughMoney)
+					Withdraw(gomock.Any(), walletID, int64(200), nil, gomock.Nil()).
+					Return(walletModel.Receipt{}, walletModel.ErrNotEnoughMoney)
 			},
 			expectedStatus: http.StatusConflict,
 		},
+		{
+			name: "too many conflicts",
+			request: handlerModel.WalletOperationRequest{
+				WalletID:      walletID,
+				Amount:        120,
+				OperationType: handlerModel.OperationDeposit,
+			},
+			setupMock: func() {
+				svc.EXPECT().
+					Deposit(gomock.Any(), walletID, walletModel.Credit{Bucket: walletModel.BucketMain, Amount: 120}, nil, gomock.Nil()).
+					Return(walletModel.Receipt{}, walletModel.ErrTooManyConflicts)
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
 		{
 			name: "service error",
 			request: handlerModel.WalletOperationRequest{
@@ -107,8 +227,8 @@ func TestWalletHandler_WalletOperation(t *testing.T) {
 			},
 			setupMock: func() {
 				svc.EXPECT().
-					Deposit(gomock.Any(), walletID, int64(150)).
-					Return(errors.New("some service error"))
+					Deposit(gomock.Any(), walletID, walletModel.Credit{Bucket: walletModel.BucketMain, Amount: 150}, nil, gomock.Nil()).
+					Return(walletModel.Receipt{}, errors.New("some service error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
@@ -124,6 +244,7 @@ func TestWalletHandler_WalletOperation(t *testing.T) {
 			require.NoError(t, err)
 
 			req := httptest.NewRequest(http.MethodPost, "/api/v1/wallets", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
 			rec := httptest.NewRecorder()
 
 			handler.WalletOperation(rec, req)
@@ -136,6 +257,54 @@ func TestWalletHandler_WalletOperation(t *testing.T) {
 	}
 }
 
+func TestWalletHandler_WalletOperation_Result(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := mocks.NewMockWalletService(ctrl)
+	handler := rest.NewWalletHandler(svc)
+
+	walletID := uuid.New()
+	transactionID := uuid.New()
+	createdAt := time.Date(2026, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	svc.EXPECT().
+		Withdraw(gomock.Any(), walletID, int64(100), nil, gomock.Nil()).
+		Return(walletModel.Receipt{TransactionID: transactionID, Balance: 397, Fee: 3, CreatedAt: createdAt}, nil)
+
+	body, err := json.Marshal(handlerModel.WalletOperationRequest{
+		WalletID:      walletID,
+		Amount:        100,
+		OperationType: handlerModel.OperationWithdraw,
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/wallet", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	handler.WalletOperation(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	var resp handlerModel.WalletOperationResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+	require.Equal(t, handlerModel.WalletOperationResult{
+		WalletID:      walletID,
+		TransactionID: transactionID,
+		OperationType: handlerModel.OperationWithdraw,
+		Amount:        100,
+		Fee:           3,
+		Balance:       397,
+		CreatedAt:     createdAt,
+	}, resp)
+}
+
 func TestWalletHandler_GetBalance(t *testing.T) {
 	t.Parallel()
 
@@ -160,12 +329,13 @@ func TestWalletHandler_GetBalance(t *testing.T) {
 			setupMock: func() {
 				svc.EXPECT().
 					GetBalance(gomock.Any(), walletID).
-					Return(int64(1000), nil)
+					Return(walletModel.Balance{Total: 1000, Buckets: map[string]int64{walletModel.BucketMain: 1000}}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody: &handlerModel.WalletOperationResponse{
 				WalletID: walletID,
 				Balance:  1000,
+				Buckets:  map[string]int64{walletModel.BucketMain: 1000},
 			},
 		},
 		{
@@ -183,7 +353,7 @@ func TestWalletHandler_GetBalance(t *testing.T) {
 			setupMock: func() {
 				svc.EXPECT().
 					GetBalance(gomock.Any(), walletID).
-					Return(int64(0), walletModel.ErrWalletNotFound)
+					Return(walletModel.Balance{}, walletModel.ErrWalletNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedBody:   nil,
@@ -194,7 +364,7 @@ func TestWalletHandler_GetBalance(t *testing.T) {
 			setupMock: func() {
 				svc.EXPECT().
 					GetBalance(gomock.Any(), walletID).
-					Return(int64(0), errors.New("database error"))
+					Return(walletModel.Balance{}, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedBody:   nil,
@@ -208,6 +378,7 @@ func TestWalletHandler_GetBalance(t *testing.T) {
 			tt.setupMock()
 
 			req := httptest.NewRequest(http.MethodGet, "/api/v1/wallets/"+tt.walletID, nil)
+			req.SetPathValue("id", tt.walletID)
 			rec := httptest.NewRecorder()
 
 			handler.GetBalance(rec, req)
@@ -224,6 +395,7 @@ func TestWalletHandler_GetBalance(t *testing.T) {
 				require.NoError(t, err)
 				require.Equal(t, tt.expectedBody.WalletID, resp.WalletID)
 				require.Equal(t, tt.expectedBody.Balance, resp.Balance)
+				require.Equal(t, tt.expectedBody.Buckets, resp.Buckets)
 			}
 		})
 	}
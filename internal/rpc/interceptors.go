@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"context"
+	"slices"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDKey is the metadata key carrying request ids, the gRPC counterpart of X-Request-ID.
+const requestIDKey = "x-request-id"
+
+// maxRequestIDLength bounds request ids accepted from clients.
+const maxRequestIDLength = 128
+
+// auditedRequest is implemented by the requests of mutating RPCs.
+type auditedRequest interface {
+	GetWalletId() string
+	GetAmount() int64
+}
+
+// AuditInterceptor records the unary calls of the given methods in the audit log, with the
+// peer address, request id, wallet, amount and gRPC status code. Calls are audited after
+// they are handled. A record that cannot be written is kept by the recorder, and calls are
+// refused with Unavailable until it is written, so that no more of them go unaudited.
+func AuditInterceptor(recorder wallet.AuditRecorder, methods ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !slices.Contains(methods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		if err := recorder.Flush(ctx); err != nil {
+			return nil, status.Error(codes.Unavailable, wallet.ErrAuditUnavailable.Error())
+		}
+
+		requestID := incomingRequestID(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, requestID))
+
+		rec := wallet.AuditRecord{
+			RequestID: requestID,
+			Operation: info.FullMethod,
+		}
+		if p, ok := peer.FromContext(ctx); ok {
+			rec.RemoteAddr = p.Addr.String()
+		}
+		if r, ok := req.(auditedRequest); ok {
+			if walletID, err := uuid.Parse(r.GetWalletId()); err == nil {
+				rec.WalletID = &walletID
+			}
+			amount := r.GetAmount()
+			rec.Amount = &amount
+		}
+
+		resp, err := handler(ctx, req)
+		rec.Status = int(status.Code(err))
+		rec.Outcome = wallet.AuditSucceeded
+		if err != nil {
+			rec.Outcome = wallet.AuditFailed
+		}
+
+		// the response is decided already: a record that cannot be written is logged, kept by
+		// the recorder and refuses the next calls until it is written
+		_ = recorder.Record(context.WithoutCancel(ctx), rec)
+
+		return resp, err
+	}
+}
+
+// incomingRequestID returns the x-request-id the client sent when it is usable and a random
+// UUID otherwise.
+func incomingRequestID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(requestIDKey); len(ids) > 0 && ids[0] != "" && len(ids[0]) <= maxRequestIDLength {
+		return ids[0]
+	}
+
+	return uuid.NewString()
+}
@@ -0,0 +1,260 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	model "wallet/internal/model/handler"
+	"wallet/internal/model/wallet"
+	"wallet/internal/rpc/walletpb"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type WalletService interface {
+	Deposit(ctx context.Context, walletID uuid.UUID, credit wallet.Credit, metadata wallet.Metadata, expectedVersion *int64) (wallet.Receipt, error)
+	Withdraw(ctx context.Context, walletID uuid.UUID, amount int64, metadata wallet.Metadata, expectedVersion *int64) (wallet.Receipt, error)
+	GetBalance(ctx context.Context, walletID uuid.UUID) (wallet.Balance, error)
+	WatchBalance(ctx context.Context, walletID uuid.UUID, lastVersion *int64) (wallet.Balance, <-chan wallet.BalanceEvent, error)
+}
+
+// Server implements the gRPC WalletService on top of the wallet service.
+type Server struct {
+	walletpb.UnimplementedWalletServiceServer
+
+	svc       WalletService
+	maxAmount int64
+	stopping  chan struct{}
+	stopOnce  sync.Once
+}
+
+type Option func(*Server)
+
+// WithMaxAmount overrides model.DefaultMaxAmount, so that both APIs accept the same amounts.
+func WithMaxAmount(max int64) Option {
+	return func(s *Server) {
+		s.maxAmount = max
+	}
+}
+
+func NewServer(svc WalletService, opts ...Option) *Server {
+	s := &Server{
+		svc:       svc,
+		maxAmount: model.DefaultMaxAmount,
+		stopping:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Server) Deposit(ctx context.Context, req *walletpb.DepositRequest) (*walletpb.OperationResponse, error) {
+	walletID, err := parseWalletID(req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validAmount(req.GetAmount()); err != nil {
+		return nil, err
+	}
+
+	if err := model.ValidateMetadata(req.GetMetadata()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	credit := wallet.Credit{Bucket: req.GetBucket(), Amount: req.GetAmount()}
+	if credit.Bucket == "" {
+		credit.Bucket = wallet.BucketMain
+	}
+
+	if !model.BucketName.MatchString(credit.Bucket) {
+		return nil, toStatus(model.ErrInvalidBucket)
+	}
+
+	if req.ExpiresAt != nil {
+		expiresAt := req.GetExpiresAt().AsTime()
+		if !expiresAt.After(time.Now()) {
+			return nil, toStatus(model.ErrInvalidExpiry)
+		}
+		credit.ExpiresAt = &expiresAt
+	}
+
+	receipt, err := s.svc.Deposit(ctx, walletID, credit, req.GetMetadata(), nil)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &walletpb.OperationResponse{
+		WalletId:      walletID.String(),
+		Amount:        req.GetAmount(),
+		Fee:           receipt.Fee,
+		Metadata:      req.GetMetadata(),
+		Balance:       receipt.Balance,
+		TransactionId: receipt.TransactionID.String(),
+	}, nil
+}
+
+func (s *Server) Withdraw(ctx context.Context, req *walletpb.WithdrawRequest) (*walletpb.OperationResponse, error) {
+	walletID, err := parseWalletID(req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validAmount(req.GetAmount()); err != nil {
+		return nil, err
+	}
+
+	if err := model.ValidateMetadata(req.GetMetadata()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	receipt, err := s.svc.Withdraw(ctx, walletID, req.GetAmount(), req.GetMetadata(), nil)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &walletpb.OperationResponse{
+		WalletId:      walletID.String(),
+		Amount:        req.GetAmount(),
+		Fee:           receipt.Fee,
+		Metadata:      req.GetMetadata(),
+		Balance:       receipt.Balance,
+		TransactionId: receipt.TransactionID.String(),
+	}, nil
+}
+
+func (s *Server) GetBalance(ctx context.Context, req *walletpb.GetBalanceRequest) (*walletpb.Balance, error) {
+	walletID, err := parseWalletID(req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.svc.GetBalance(ctx, walletID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return balanceMessage(walletID, balance), nil
+}
+
+// Stop ends open WatchBalance streams with Unavailable, so that a graceful stop of the
+// gRPC server does not wait for them.
+func (s *Server) Stop() {
+	s.stopOnce.Do(func() { close(s.stopping) })
+}
+
+// WatchBalance sends the current balance, then the balance after every change published on
+// the balance events of the wallet, until the client cancels or the server stops. Streams
+// that fall too far behind end with Unavailable; the client watches again.
+func (s *Server) WatchBalance(req *walletpb.WatchBalanceRequest, stream walletpb.WalletService_WatchBalanceServer) error {
+	walletID, err := parseWalletID(req.GetWalletId())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	balance, events, err := s.svc.WatchBalance(ctx, walletID, nil)
+	if err != nil {
+		return toStatus(err)
+	}
+
+	if err := stream.Send(balanceMessage(walletID, balance)); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				if ctx.Err() != nil {
+					return status.FromContextError(ctx.Err()).Err()
+				}
+				return status.Error(codes.Unavailable, "balance stream fell behind")
+			}
+			if ev.Version <= balance.Version {
+				continue // already sent with an earlier change
+			}
+
+			// events carry the total only, the buckets are read with it
+			balance, err = s.svc.GetBalance(ctx, walletID)
+			if err != nil {
+				return toStatus(err)
+			}
+			if err := stream.Send(balanceMessage(walletID, balance)); err != nil {
+				return err
+			}
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		}
+	}
+}
+
+// validAmount checks that amount is between 1 and the maximum amount.
+func (s *Server) validAmount(amount int64) error {
+	if amount <= 0 || amount > s.maxAmount {
+		return toStatus(fmt.Errorf("%w: must be between 1 and %d", model.ErrInvalidAmount, s.maxAmount))
+	}
+
+	return nil
+}
+
+func parseWalletID(id string) (uuid.UUID, error) {
+	walletID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid wallet id")
+	}
+
+	return walletID, nil
+}
+
+func balanceMessage(walletID uuid.UUID, balance wallet.Balance) *walletpb.Balance {
+	return &walletpb.Balance{
+		WalletId: walletID.String(),
+		Balance:  balance.Total,
+		Buckets:  balance.Buckets,
+		Metadata: balance.Metadata,
+	}
+}
+
+// toStatus maps service and validation errors to gRPC statuses, the way handleError maps
+// them to HTTP statuses.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, wallet.ErrWalletNotFound), errors.Is(err, wallet.ErrQuoteNotFound),
+		errors.Is(err, wallet.ErrAdjustmentNotFound), errors.Is(err, wallet.ErrScheduleNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, wallet.ErrNotEnoughMoney), errors.Is(err, wallet.ErrWalletFrozen),
+		errors.Is(err, wallet.ErrQuoteExpired), errors.Is(err, wallet.ErrAdjustmentDecided),
+		errors.Is(err, wallet.ErrScheduleNotActive), errors.Is(err, wallet.ErrVersionMismatch),
+		errors.Is(err, wallet.ErrCurrencyMismatch), errors.Is(err, wallet.ErrRateNotFound):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, wallet.ErrWalletExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, wallet.ErrAdjustmentSelfCheck):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, wallet.ErrTooManyConflicts), errors.Is(err, wallet.ErrAuditUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, wallet.ErrTooManyWatchers):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, wallet.ErrSameWallet), errors.Is(err, wallet.ErrQuoteRequired),
+		errors.Is(err, wallet.ErrAmountTooSmall), errors.Is(err, wallet.ErrInvalidRecurrence),
+		errors.Is(err, model.ErrInvalidAmount), errors.Is(err, model.ErrInvalidBucket),
+		errors.Is(err, model.ErrInvalidExpiry), errors.Is(err, model.ErrInvalidCurrency),
+		errors.Is(err, model.ErrInvalidMetadata), errors.Is(err, model.ErrInvalidReason),
+		errors.Is(err, model.ErrInvalidSchedule), errors.Is(err, model.ErrInvalidOperationType),
+		errors.Is(err, model.ErrInvalidWalletID), errors.Is(err, model.ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal server error")
+	}
+}
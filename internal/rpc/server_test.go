@@ -0,0 +1,420 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+	handlerModel "wallet/internal/model/handler"
+	walletModel "wallet/internal/model/wallet"
+	"wallet/internal/rpc"
+	"wallet/internal/rpc/mocks"
+	"wallet/internal/rpc/walletpb"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+//go:generate mockgen -destination=mocks/mock_wallet_service.go -package=mocks wallet/internal/rpc WalletService
+//go:generate mockgen -destination=mocks/mock_audit_recorder.go -package=mocks wallet/internal/model/wallet AuditRecorder
+
+// startServer serves srv over an in-memory listener and returns a client connected to it.
+func startServer(t *testing.T, srv *rpc.Server, opts ...grpc.ServerOption) walletpb.WalletServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(opts...)
+	walletpb.RegisterWalletServiceServer(server, srv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return walletpb.NewWalletServiceClient(conn)
+}
+
+func TestServer_Deposit(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	transactionID := uuid.New()
+
+	tests := []struct {
+		name         string
+		req          *walletpb.DepositRequest
+		serviceError error
+		expectCall   bool
+		expectedCode codes.Code
+	}{
+		{
+			name:         "deposit into main",
+			req:          &walletpb.DepositRequest{WalletId: walletID.String(), Amount: 100, Metadata: map[string]string{"orderId": "o-1"}},
+			expectCall:   true,
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "wallet not found",
+			req:          &walletpb.DepositRequest{WalletId: walletID.String(), Amount: 100},
+			serviceError: walletModel.ErrWalletNotFound,
+			expectCall:   true,
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "wallet frozen",
+			req:          &walletpb.DepositRequest{WalletId: walletID.String(), Amount: 100},
+			serviceError: walletModel.ErrWalletFrozen,
+			expectCall:   true,
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			name:         "invalid wallet id",
+			req:          &walletpb.DepositRequest{WalletId: "not-a-uuid", Amount: 100},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "invalid amount",
+			req:          &walletpb.DepositRequest{WalletId: walletID.String(), Amount: 0},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "amount above maximum",
+			req:          &walletpb.DepositRequest{WalletId: walletID.String(), Amount: 1001},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "invalid bucket",
+			req:          &walletpb.DepositRequest{WalletId: walletID.String(), Amount: 100, Bucket: "Bonus!"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "expiry in the past",
+			req: &walletpb.DepositRequest{WalletId: walletID.String(), Amount: 100, Bucket: "bonus",
+				ExpiresAt: timestamppb.New(time.Now().Add(-time.Hour))},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			client := startServer(t, rpc.NewServer(svc, rpc.WithMaxAmount(1000)))
+
+			if tt.expectCall {
+				svc.EXPECT().
+					Deposit(gomock.Any(), walletID, walletModel.Credit{Bucket: walletModel.BucketMain, Amount: 100}, gomock.Any(), gomock.Nil()).
+					Return(walletModel.Receipt{TransactionID: transactionID, Balance: 598, Fee: 2}, tt.serviceError)
+			}
+
+			resp, err := client.Deposit(t.Context(), tt.req)
+			require.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedCode == codes.OK {
+				require.Equal(t, walletID.String(), resp.GetWalletId())
+				require.Equal(t, int64(100), resp.GetAmount())
+				require.Equal(t, int64(2), resp.GetFee())
+				require.Equal(t, tt.req.GetMetadata(), resp.GetMetadata())
+				require.Equal(t, int64(598), resp.GetBalance())
+				require.Equal(t, transactionID.String(), resp.GetTransactionId())
+			}
+		})
+	}
+}
+
+func TestServer_Withdraw(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+
+	tests := []struct {
+		name         string
+		amount       int64
+		serviceError error
+		expectCall   bool
+		expectedCode codes.Code
+	}{
+		{
+			name:         "withdrawal",
+			amount:       50,
+			expectCall:   true,
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "not enough money",
+			amount:       50,
+			serviceError: walletModel.ErrNotEnoughMoney,
+			expectCall:   true,
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			name:         "too many conflicts",
+			amount:       50,
+			serviceError: walletModel.ErrTooManyConflicts,
+			expectCall:   true,
+			expectedCode: codes.Unavailable,
+		},
+		{
+			name:         "amount above maximum",
+			amount:       1001,
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			client := startServer(t, rpc.NewServer(svc, rpc.WithMaxAmount(1000)))
+
+			if tt.expectCall {
+				svc.EXPECT().
+					Withdraw(gomock.Any(), walletID, tt.amount, gomock.Any(), gomock.Nil()).
+					Return(walletModel.Receipt{Balance: 450}, tt.serviceError)
+			}
+
+			resp, err := client.Withdraw(t.Context(), &walletpb.WithdrawRequest{WalletId: walletID.String(), Amount: tt.amount})
+			require.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedCode == codes.OK {
+				require.Equal(t, int64(450), resp.GetBalance())
+			}
+		})
+	}
+}
+
+func TestServer_ErrorCodes(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+
+	tests := []struct {
+		err          error
+		expectedCode codes.Code
+	}{
+		{err: walletModel.ErrWalletNotFound, expectedCode: codes.NotFound},
+		{err: walletModel.ErrQuoteNotFound, expectedCode: codes.NotFound},
+		{err: walletModel.ErrAdjustmentNotFound, expectedCode: codes.NotFound},
+		{err: walletModel.ErrScheduleNotFound, expectedCode: codes.NotFound},
+		{err: walletModel.ErrNotEnoughMoney, expectedCode: codes.FailedPrecondition},
+		{err: walletModel.ErrWalletFrozen, expectedCode: codes.FailedPrecondition},
+		{err: walletModel.ErrQuoteExpired, expectedCode: codes.FailedPrecondition},
+		{err: walletModel.ErrAdjustmentDecided, expectedCode: codes.FailedPrecondition},
+		{err: walletModel.ErrScheduleNotActive, expectedCode: codes.FailedPrecondition},
+		{err: walletModel.ErrVersionMismatch, expectedCode: codes.FailedPrecondition},
+		{err: walletModel.ErrCurrencyMismatch, expectedCode: codes.FailedPrecondition},
+		{err: walletModel.ErrRateNotFound, expectedCode: codes.FailedPrecondition},
+		{err: walletModel.ErrWalletExists, expectedCode: codes.AlreadyExists},
+		{err: walletModel.ErrAdjustmentSelfCheck, expectedCode: codes.PermissionDenied},
+		{err: walletModel.ErrTooManyConflicts, expectedCode: codes.Unavailable},
+		{err: walletModel.ErrAuditUnavailable, expectedCode: codes.Unavailable},
+		{err: walletModel.ErrTooManyWatchers, expectedCode: codes.ResourceExhausted},
+		{err: walletModel.ErrSameWallet, expectedCode: codes.InvalidArgument},
+		{err: walletModel.ErrQuoteRequired, expectedCode: codes.InvalidArgument},
+		{err: walletModel.ErrAmountTooSmall, expectedCode: codes.InvalidArgument},
+		{err: walletModel.ErrInvalidRecurrence, expectedCode: codes.InvalidArgument},
+		{err: handlerModel.ErrInvalidAmount, expectedCode: codes.InvalidArgument},
+		{err: handlerModel.ErrInvalidCurrency, expectedCode: codes.InvalidArgument},
+		{err: handlerModel.ErrInvalidReason, expectedCode: codes.InvalidArgument},
+		{err: handlerModel.ErrInvalidSchedule, expectedCode: codes.InvalidArgument},
+		{err: handlerModel.ErrInvalidRequest, expectedCode: codes.InvalidArgument},
+		{err: errors.New("connection refused"), expectedCode: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			client := startServer(t, rpc.NewServer(svc))
+
+			svc.EXPECT().
+				Withdraw(gomock.Any(), walletID, int64(50), gomock.Any(), gomock.Nil()).
+				Return(walletModel.Receipt{}, fmt.Errorf("withdraw: %w", tt.err))
+
+			_, err := client.Withdraw(t.Context(), &walletpb.WithdrawRequest{WalletId: walletID.String(), Amount: 50})
+			require.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestServer_GetBalance(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockWalletService(ctrl)
+	client := startServer(t, rpc.NewServer(svc))
+
+	walletID := uuid.New()
+	svc.EXPECT().
+		GetBalance(gomock.Any(), walletID).
+		Return(walletModel.Balance{Total: 150, Buckets: map[string]int64{"main": 100, "bonus": 50}}, nil)
+
+	resp, err := client.GetBalance(t.Context(), &walletpb.GetBalanceRequest{WalletId: walletID.String()})
+	require.NoError(t, err)
+	require.Equal(t, int64(150), resp.GetBalance())
+	require.Equal(t, map[string]int64{"main": 100, "bonus": 50}, resp.GetBuckets())
+}
+
+func TestServer_WatchBalance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		end          func(srv *rpc.Server, events chan walletModel.BalanceEvent)
+		expectedCode codes.Code
+	}{
+		{
+			name:         "server stops",
+			end:          func(srv *rpc.Server, _ chan walletModel.BalanceEvent) { srv.Stop() },
+			expectedCode: codes.Unavailable,
+		},
+		{
+			name:         "stream falls behind",
+			end:          func(_ *rpc.Server, events chan walletModel.BalanceEvent) { close(events) },
+			expectedCode: codes.Unavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			svc := mocks.NewMockWalletService(ctrl)
+			srv := rpc.NewServer(svc)
+			client := startServer(t, srv)
+
+			walletID := uuid.New()
+			events := make(chan walletModel.BalanceEvent, 2)
+			svc.EXPECT().
+				WatchBalance(gomock.Any(), walletID, nil).
+				Return(walletModel.Balance{Total: 100, Version: 4}, (<-chan walletModel.BalanceEvent)(events), nil)
+			svc.EXPECT().
+				GetBalance(gomock.Any(), walletID).
+				Return(walletModel.Balance{Total: 70, Buckets: map[string]int64{"main": 70}, Version: 5}, nil)
+
+			stream, err := client.WatchBalance(t.Context(), &walletpb.WatchBalanceRequest{WalletId: walletID.String()})
+			require.NoError(t, err)
+
+			first, err := stream.Recv()
+			require.NoError(t, err)
+			require.Equal(t, int64(100), first.GetBalance())
+
+			// the balance read for the first change already includes the second one
+			events <- walletModel.BalanceEvent{WalletID: walletID, Version: 5, Balance: 70, Delta: -30}
+			events <- walletModel.BalanceEvent{WalletID: walletID, Version: 5, Balance: 70, Delta: -30}
+
+			second, err := stream.Recv()
+			require.NoError(t, err)
+			require.Equal(t, int64(70), second.GetBalance())
+			require.Equal(t, map[string]int64{"main": 70}, second.GetBuckets())
+
+			// let the server take the duplicate before ending the stream
+			require.Eventually(t, func() bool { return len(events) == 0 }, time.Second, time.Millisecond)
+			tt.end(srv, events)
+			_, err = stream.Recv()
+			require.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestServer_WatchBalanceLimits(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockWalletService(ctrl)
+	client := startServer(t, rpc.NewServer(svc))
+
+	walletID := uuid.New()
+	svc.EXPECT().
+		WatchBalance(gomock.Any(), walletID, nil).
+		Return(walletModel.Balance{}, nil, walletModel.ErrTooManyWatchers)
+
+	stream, err := client.WatchBalance(t.Context(), &walletpb.WatchBalanceRequest{WalletId: walletID.String()})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestAuditInterceptor(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockWalletService(ctrl)
+	recorder := mocks.NewMockAuditRecorder(ctrl)
+	client := startServer(t, rpc.NewServer(svc), grpc.UnaryInterceptor(
+		rpc.AuditInterceptor(recorder, walletpb.WalletService_Withdraw_FullMethodName)))
+
+	walletID := uuid.New()
+	svc.EXPECT().
+		Withdraw(gomock.Any(), walletID, int64(50), gomock.Any(), gomock.Nil()).
+		Return(walletModel.Receipt{}, walletModel.ErrNotEnoughMoney)
+	svc.EXPECT().
+		GetBalance(gomock.Any(), walletID).
+		Return(walletModel.Balance{Total: 10}, nil)
+
+	var rec walletModel.AuditRecord
+	recorder.EXPECT().
+		Flush(gomock.Any()).
+		Return(nil)
+	recorder.EXPECT().
+		Record(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, r walletModel.AuditRecord) error {
+			rec = r
+			return nil
+		})
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(t.Context(), "x-request-id", "req-1")
+	_, err := client.Withdraw(ctx, &walletpb.WithdrawRequest{WalletId: walletID.String(), Amount: 50}, grpc.Header(&header))
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Equal(t, []string{"req-1"}, header.Get("x-request-id"))
+
+	// reads are not audited
+	_, err = client.GetBalance(t.Context(), &walletpb.GetBalanceRequest{WalletId: walletID.String()})
+	require.NoError(t, err)
+
+	amount := int64(50)
+	require.Equal(t, "req-1", rec.RequestID)
+	require.Equal(t, walletpb.WalletService_Withdraw_FullMethodName, rec.Operation)
+	require.Equal(t, &walletID, rec.WalletID)
+	require.Equal(t, &amount, rec.Amount)
+	require.Equal(t, int(codes.FailedPrecondition), rec.Status)
+	require.Equal(t, walletModel.AuditFailed, rec.Outcome)
+	require.NotEmpty(t, rec.RemoteAddr)
+}
+
+func TestAuditInterceptor_AuditUnavailable(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := mocks.NewMockWalletService(ctrl)
+	recorder := mocks.NewMockAuditRecorder(ctrl)
+	client := startServer(t, rpc.NewServer(svc), grpc.UnaryInterceptor(
+		rpc.AuditInterceptor(recorder, walletpb.WalletService_Withdraw_FullMethodName)))
+
+	// the withdrawal is refused before it reaches the service
+	recorder.EXPECT().
+		Flush(gomock.Any()).
+		Return(walletModel.ErrAuditUnavailable)
+
+	_, err := client.Withdraw(t.Context(), &walletpb.WithdrawRequest{WalletId: uuid.NewString(), Amount: 50})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
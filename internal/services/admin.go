@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"wallet/internal/model/wallet"
+)
+
+// CreateWallet opens an active wallet. A wallet without an ID gets a random one.
+func (ws *WalletService) CreateWallet(ctx context.Context, w wallet.Wallet) (wallet.Wallet, error) {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+
+	created, err := ws.repo.CreateWallet(ctx, w)
+	if err != nil {
+		ws.log.Error("Error creating wallet", "walletID", w.ID, "error", err)
+		return wallet.Wallet{}, err
+	}
+
+	ws.log.Info("Wallet created", "walletID", created.ID, "currency", created.Currency)
+	return created, nil
+}
+
+// SetStatus freezes or unfreezes the wallet. Frozen wallets reject deposits, withdrawals
+// and transfers.
+func (ws *WalletService) SetStatus(ctx context.Context, walletID uuid.UUID, status wallet.Status) error {
+	if err := ws.repo.SetStatus(ctx, walletID, status); err != nil {
+		ws.log.Error("Error setting wallet status", "walletID", walletID, "status", status, "error", err)
+		return err
+	}
+
+	ws.cache.Delete(ctx, walletID.String())
+	ws.log.Info("Wallet status changed", "walletID", walletID, "status", status)
+	return nil
+}
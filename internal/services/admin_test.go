@@ -0,0 +1,84 @@
+package services_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+	"wallet/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWalletService_CreateWallet(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+	service := services.NewWalletService(repo, cache, slog.Default())
+
+	repo.EXPECT().
+		CreateWallet(gomock.Any(), gomock.Cond(func(w wallet.Wallet) bool {
+			return w.ID != uuid.Nil && w.Currency == "EUR"
+		})).
+		DoAndReturn(func(_ context.Context, w wallet.Wallet) (wallet.Wallet, error) {
+			w.Status = wallet.StatusActive
+			return w, nil
+		})
+
+	created, err := service.CreateWallet(t.Context(), wallet.Wallet{Currency: "EUR"})
+	require.NoError(t, err)
+	require.NotEqual(t, uuid.Nil, created.ID)
+	require.Equal(t, wallet.StatusActive, created.Status)
+}
+
+func TestWalletService_SetStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		storageError  error
+		expectedError error
+	}{
+		{
+			name: "wallet frozen",
+		},
+		{
+			name:          "wallet not found",
+			storageError:  wallet.ErrWalletNotFound,
+			expectedError: wallet.ErrWalletNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWalletStorage(ctrl)
+			cache := mocks.NewMockWalletCache(ctrl)
+			service := services.NewWalletService(repo, cache, slog.Default())
+
+			walletID := uuid.New()
+
+			repo.EXPECT().
+				SetStatus(gomock.Any(), walletID, wallet.StatusFrozen).
+				Return(tt.storageError)
+			if tt.storageError == nil {
+				cache.EXPECT().
+					Delete(gomock.Any(), walletID.String())
+			}
+
+			err := service.SetStatus(t.Context(), walletID, wallet.StatusFrozen)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"github.com/google/uuid"
+	repo "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+)
+
+// ProposeAdjustment stores a pending request to apply the adjustment. Nothing is applied
+// until another admin approves it.
+func (ws *WalletService) ProposeAdjustment(ctx context.Context, adj wallet.Adjustment) (wallet.AdjustmentRequest, error) {
+	if adj.ID == uuid.Nil {
+		adj.ID = uuid.New()
+	}
+	req := wallet.AdjustmentRequest{Adjustment: adj, Status: wallet.AdjustmentPending}
+
+	err := ws.repo.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+		if err := tx.ProposeAdjustment(ctx, req); err != nil {
+			return err
+		}
+
+		return tx.RecordAdjustmentEvent(ctx, wallet.AdjustmentEvent{
+			RequestID: req.ID,
+			Action:    wallet.AdjustmentPropose,
+			Principal: adj.CreatedBy,
+			Note:      adj.Reason,
+		})
+	})
+	if err != nil {
+		ws.log.Error("Error proposing adjustment", "walletID", adj.WalletID, "amount", adj.Amount, "principal", adj.CreatedBy, "error", err)
+		return wallet.AdjustmentRequest{}, err
+	}
+
+	ws.log.Info("Adjustment proposed", "requestID", req.ID, "walletID", adj.WalletID, "bucket", adj.Bucket,
+		"amount", adj.Amount, "reason", adj.Reason, "principal", adj.CreatedBy)
+	return req, nil
+}
+
+// ApproveAdjustment approves a pending request on behalf of principal and applies the
+// adjustment in the same transaction. It returns the decided request and the updated
+// wallet balance; when the adjustment cannot be applied the request stays pending.
+func (ws *WalletService) ApproveAdjustment(ctx context.Context, requestID uuid.UUID, principal, note string) (wallet.AdjustmentRequest, int64, error) {
+	var (
+		req     wallet.AdjustmentRequest
+		balance int64
+	)
+
+	err := ws.repo.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+		var err error
+		if req, err = tx.DecideAdjustment(ctx, requestID, wallet.AdjustmentApproved, principal, note); err != nil {
+			return err
+		}
+
+		if balance, err = ws.adjust(ctx, tx, req, principal); err != nil {
+			return err
+		}
+
+		return tx.RecordAdjustmentEvent(ctx, wallet.AdjustmentEvent{
+			RequestID: requestID,
+			Action:    wallet.AdjustmentApprove,
+			Principal: principal,
+			Note:      note,
+		})
+	})
+	if err != nil {
+		ws.log.Error("Error approving adjustment", "requestID", requestID, "principal", principal, "error", err)
+		return wallet.AdjustmentRequest{}, 0, err
+	}
+
+	ws.cache.Delete(ctx, req.WalletID.String())
+	ws.log.Info("Adjustment approved", "requestID", requestID, "walletID", req.WalletID, "bucket", req.Bucket,
+		"amount", req.Amount, "proposedBy", req.CreatedBy, "principal", principal, "newBalance", balance)
+	return req, balance, nil
+}
+
+// RejectAdjustment rejects a pending request on behalf of principal.
+func (ws *WalletService) RejectAdjustment(ctx context.Context, requestID uuid.UUID, principal, note string) (wallet.AdjustmentRequest, error) {
+	var req wallet.AdjustmentRequest
+
+	err := ws.repo.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+		var err error
+		if req, err = tx.DecideAdjustment(ctx, requestID, wallet.AdjustmentRejected, principal, note); err != nil {
+			return err
+		}
+
+		return tx.RecordAdjustmentEvent(ctx, wallet.AdjustmentEvent{
+			RequestID: requestID,
+			Action:    wallet.AdjustmentReject,
+			Principal: principal,
+			Note:      note,
+		})
+	})
+	if err != nil {
+		ws.log.Error("Error rejecting adjustment", "requestID", requestID, "principal", principal, "error", err)
+		return wallet.AdjustmentRequest{}, err
+	}
+
+	ws.log.Info("Adjustment rejected", "requestID", requestID, "walletID", req.WalletID, "amount", req.Amount,
+		"proposedBy", req.CreatedBy, "principal", principal, "note", note)
+	return req, nil
+}
+
+// AdjustmentRequests returns up to limit adjustment requests in the status, any status when
+// it is empty, newest first.
+func (ws *WalletService) AdjustmentRequests(ctx context.Context, status wallet.AdjustmentStatus, limit int) ([]wallet.AdjustmentRequest, error) {
+	reqs, err := ws.repo.AdjustmentRequests(ctx, status, limit)
+	if err != nil {
+		ws.log.Error("Error listing adjustment requests", "status", status, "error", err)
+		return nil, err
+	}
+
+	return reqs, nil
+}
+
+// AdjustmentRequest returns the adjustment request with its audit trail, oldest step first.
+func (ws *WalletService) AdjustmentRequest(ctx context.Context, requestID uuid.UUID) (wallet.AdjustmentRequest, []wallet.AdjustmentEvent, error) {
+	req, events, err := ws.repo.AdjustmentRequest(ctx, requestID)
+	if err != nil {
+		ws.log.Error("Error getting adjustment request", "requestID", requestID, "error", err)
+		return wallet.AdjustmentRequest{}, nil, err
+	}
+
+	return req, events, nil
+}
+
+// adjust applies the approved request and records it in the wallet history with its reason.
+func (ws *WalletService) adjust(ctx context.Context, tx repo.Tx, req wallet.AdjustmentRequest, approvedBy string) (int64, error) {
+	balance, err := tx.Adjust(ctx, req.Adjustment)
+	if err != nil {
+		return 0, err
+	}
+
+	op := wallet.OperationRecord{
+		ID:       req.ID,
+		WalletID: req.WalletID,
+		Type:     wallet.OperationAdjust,
+		Amount:   req.Amount,
+		Metadata: wallet.Metadata{"reason": req.Reason, "createdBy": req.CreatedBy, "approvedBy": approvedBy},
+	}
+	return balance, tx.RecordOperation(ctx, op)
+}
@@ -0,0 +1,188 @@
+package services_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	repoModel "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+	"wallet/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWalletService_ProposeAdjustment(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+	service := services.NewWalletService(repo, cache, slog.Default())
+
+	walletID := uuid.New()
+	tx := mocks.NewMockTx(ctrl)
+	repo.EXPECT().
+		WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+			return fn(ctx, tx)
+		})
+
+	var requestID uuid.UUID
+	tx.EXPECT().
+		ProposeAdjustment(gomock.Any(), gomock.Cond(func(req wallet.AdjustmentRequest) bool {
+			requestID = req.ID
+			return req.ID != uuid.Nil && req.WalletID == walletID && req.Status == wallet.AdjustmentPending &&
+				req.CreatedBy == "alice"
+		}))
+	tx.EXPECT().
+		RecordAdjustmentEvent(gomock.Any(), gomock.Cond(func(e wallet.AdjustmentEvent) bool {
+			return e.RequestID == requestID && e.Action == wallet.AdjustmentPropose && e.Principal == "alice" &&
+				e.Note == "duplicate deposit"
+		}))
+
+	req, err := service.ProposeAdjustment(t.Context(), wallet.Adjustment{
+		WalletID:  walletID,
+		Bucket:    wallet.BucketMain,
+		Amount:    -30,
+		Reason:    "duplicate deposit",
+		CreatedBy: "alice",
+	})
+	require.NoError(t, err)
+	require.Equal(t, requestID, req.ID)
+	require.Equal(t, wallet.AdjustmentPending, req.Status)
+}
+
+func TestWalletService_ApproveAdjustment(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		decideError   error
+		adjustError   error
+		expectedError error
+	}{
+		{
+			name: "adjustment applied",
+		},
+		{
+			name:          "proposer approves",
+			decideError:   wallet.ErrAdjustmentSelfCheck,
+			expectedError: wallet.ErrAdjustmentSelfCheck,
+		},
+		{
+			name:          "bucket goes negative",
+			adjustError:   wallet.ErrNotEnoughMoney,
+			expectedError: wallet.ErrNotEnoughMoney,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWalletStorage(ctrl)
+			cache := mocks.NewMockWalletCache(ctrl)
+			service := services.NewWalletService(repo, cache, slog.Default())
+
+			walletID := uuid.New()
+			requestID := uuid.New()
+			req := wallet.AdjustmentRequest{
+				Adjustment: wallet.Adjustment{
+					ID:        requestID,
+					WalletID:  walletID,
+					Bucket:    wallet.BucketMain,
+					Amount:    -30,
+					Reason:    "duplicate deposit",
+					CreatedBy: "alice",
+				},
+				Status:    wallet.AdjustmentApproved,
+				DecidedBy: "bob",
+			}
+
+			tx := mocks.NewMockTx(ctrl)
+			repo.EXPECT().
+				WithinTx(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+					return fn(ctx, tx)
+				})
+
+			tx.EXPECT().
+				DecideAdjustment(gomock.Any(), requestID, wallet.AdjustmentApproved, "bob", "checked").
+				Return(req, tt.decideError)
+
+			if tt.decideError == nil {
+				tx.EXPECT().
+					Adjust(gomock.Any(), req.Adjustment).
+					Return(int64(70), tt.adjustError)
+			}
+
+			if tt.expectedError == nil {
+				tx.EXPECT().
+					RecordOperation(gomock.Any(), gomock.Cond(func(op wallet.OperationRecord) bool {
+						return op.ID == requestID && op.Type == wallet.OperationAdjust && op.Amount == -30 &&
+							op.Metadata["reason"] == "duplicate deposit" && op.Metadata["approvedBy"] == "bob"
+					}))
+				tx.EXPECT().
+					RecordAdjustmentEvent(gomock.Any(), wallet.AdjustmentEvent{
+						RequestID: requestID,
+						Action:    wallet.AdjustmentApprove,
+						Principal: "bob",
+						Note:      "checked",
+					})
+				cache.EXPECT().
+					Delete(gomock.Any(), walletID.String())
+			}
+
+			approved, balance, err := service.ApproveAdjustment(t.Context(), requestID, "bob", "checked")
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, req, approved)
+			require.Equal(t, int64(70), balance)
+		})
+	}
+}
+
+func TestWalletService_RejectAdjustment(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+	service := services.NewWalletService(repo, cache, slog.Default())
+
+	requestID := uuid.New()
+	req := wallet.AdjustmentRequest{
+		Adjustment: wallet.Adjustment{ID: requestID, WalletID: uuid.New(), Amount: 500, CreatedBy: "alice"},
+		Status:     wallet.AdjustmentRejected,
+		DecidedBy:  "bob",
+	}
+
+	tx := mocks.NewMockTx(ctrl)
+	repo.EXPECT().
+		WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+			return fn(ctx, tx)
+		})
+	tx.EXPECT().
+		DecideAdjustment(gomock.Any(), requestID, wallet.AdjustmentRejected, "bob", "no ticket").
+		Return(req, nil)
+	tx.EXPECT().
+		RecordAdjustmentEvent(gomock.Any(), wallet.AdjustmentEvent{
+			RequestID: requestID,
+			Action:    wallet.AdjustmentReject,
+			Principal: "bob",
+			Note:      "no ticket",
+		})
+
+	rejected, err := service.RejectAdjustment(t.Context(), requestID, "bob", "no ticket")
+	require.NoError(t, err)
+	require.Equal(t, req, rejected)
+}
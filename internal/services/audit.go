@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"wallet/internal/metrics"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// auditVerifyBatchSize bounds the number of audit records read at once by Verify.
+const auditVerifyBatchSize = 1000
+
+// maxAuditBacklog bounds the records kept in memory while the audit log cannot be written.
+const maxAuditBacklog = 1000
+
+// genesisHash is the previous hash of the first audit record.
+var genesisHash = strings.Repeat("0", sha256.Size*2)
+
+type AuditStorage interface {
+	// AppendAudit serializes appends: it calls chain with the sequence number and hash of the
+	// last record, zero and empty when the log is empty, and stores the record chain returns
+	AppendAudit(ctx context.Context, chain func(lastSeq int64, lastHash string) wallet.AuditRecord) error
+	AuditRecords(ctx context.Context, afterSeq int64, limit int) ([]wallet.AuditRecord, error)
+}
+
+// AuditLog keeps a hash chained, append-only log of mutating requests.
+type AuditLog struct {
+	log  *slog.Logger
+	repo AuditStorage
+
+	mu      sync.Mutex
+	backlog []wallet.AuditRecord // backlog holds the records not written yet, oldest first
+}
+
+func NewAuditLog(repo AuditStorage, logger *slog.Logger) *AuditLog {
+	return &AuditLog{log: logger, repo: repo}
+}
+
+// Record appends rec to the log, chaining it to the last record. Seq, CreatedAt and the
+// hashes are set here. A record that cannot be written is kept, up to maxAuditBacklog
+// records, and written before the next one; the error wraps ErrAuditUnavailable.
+func (a *AuditLog) Record(ctx context.Context, rec wallet.AuditRecord) error {
+	// the database keeps microseconds, the hash must survive the round trip
+	rec.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.backlog) == maxAuditBacklog {
+		dropped := a.backlog[0]
+		a.log.Error("Audit backlog is full, dropping record", "operation", dropped.Operation, "requestID", dropped.RequestID,
+			"principal", dropped.Principal, "walletID", dropped.WalletID, "outcome", dropped.Outcome)
+		a.backlog = a.backlog[1:]
+	}
+	a.backlog = append(a.backlog, rec)
+
+	return a.flush(ctx)
+}
+
+// Flush writes the records kept after earlier failures. It fails with ErrAuditUnavailable
+// while they cannot be written: mutating requests must then be refused, as they would go
+// unaudited.
+func (a *AuditLog) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.flush(ctx)
+}
+
+func (a *AuditLog) flush(ctx context.Context) error {
+	for len(a.backlog) > 0 {
+		rec := a.backlog[0]
+
+		err := a.repo.AppendAudit(ctx, func(seq int64, hash string) wallet.AuditRecord {
+			if seq == 0 {
+				hash = genesisHash
+			}
+
+			rec.Seq = seq + 1
+			rec.PrevHash = hash
+			rec.Hash = auditHash(rec)
+			return rec
+		})
+		if err != nil {
+			metrics.AuditFailures.Inc()
+			a.log.Error("Error recording audit", "operation", rec.Operation, "requestID", rec.RequestID,
+				"principal", rec.Principal, "walletID", rec.WalletID, "outcome", rec.Outcome,
+				"backlog", len(a.backlog), "error", err)
+			return fmt.Errorf("%w: %w", wallet.ErrAuditUnavailable, err)
+		}
+
+		a.backlog = a.backlog[1:]
+	}
+
+	return nil
+}
+
+// Verify walks the whole log and checks that records are numbered without gaps, that every
+// record carries the hash of the previous one and that its own hash matches its content.
+func (a *AuditLog) Verify(ctx context.Context) (wallet.AuditVerification, error) {
+	result := wallet.AuditVerification{LastHash: genesisHash}
+
+	for {
+		records, err := a.repo.AuditRecords(ctx, result.LastSeq, auditVerifyBatchSize)
+		if err != nil {
+			a.log.Error("Error reading audit log", "afterSeq", result.LastSeq, "error", err)
+			return wallet.AuditVerification{}, err
+		}
+
+		for _, rec := range records {
+			if problem := checkAuditRecord(rec, result.LastSeq, result.LastHash); problem != "" {
+				result.BrokenAt = &rec.Seq
+				result.Problem = problem
+				a.log.Error("Audit log chain is broken", "seq", rec.Seq, "problem", problem)
+				return result, nil
+			}
+
+			result.Records++
+			result.LastSeq = rec.Seq
+			result.LastHash = rec.Hash
+		}
+
+		if len(records) < auditVerifyBatchSize {
+			return result, nil
+		}
+	}
+}
+
+func checkAuditRecord(rec wallet.AuditRecord, prevSeq int64, prevHash string) string {
+	switch {
+	case rec.Seq != prevSeq+1:
+		return "records missing before this one"
+	case rec.PrevHash != prevHash:
+		return "previous hash does not match the previous record"
+	case rec.Hash != auditHash(rec):
+		return "hash does not match the record content"
+	default:
+		return ""
+	}
+}
+
+// auditHash is the SHA-256 of the previous hash and the record content.
+func auditHash(rec wallet.AuditRecord) string {
+	content, _ := json.Marshal(struct {
+		Seq        int64      `json:"seq"`
+		CreatedAt  string     `json:"createdAt"`
+		Principal  string     `json:"principal"`
+		RemoteAddr string     `json:"remoteAddr"`
+		RequestID  string     `json:"requestId"`
+		Operation  string     `json:"operation"`
+		WalletID   *uuid.UUID `json:"walletId"`
+		Resource   string     `json:"resource"`
+		Amount     *int64     `json:"amount"`
+		Status     int        `json:"status"`
+		Outcome    string     `json:"outcome,omitempty"` // omitted for records written before outcomes
+		PrevHash   string     `json:"prevHash"`
+	}{
+		Seq:        rec.Seq,
+		CreatedAt:  rec.CreatedAt.UTC().Format(time.RFC3339Nano),
+		Principal:  rec.Principal,
+		RemoteAddr: rec.RemoteAddr,
+		RequestID:  rec.RequestID,
+		Operation:  rec.Operation,
+		WalletID:   rec.WalletID,
+		Resource:   rec.Resource,
+		Amount:     rec.Amount,
+		Status:     rec.Status,
+		Outcome:    string(rec.Outcome),
+		PrevHash:   rec.PrevHash,
+	})
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
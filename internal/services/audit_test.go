@@ -0,0 +1,227 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+	"wallet/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+//go:generate mockgen -destination=mocks/mock_auditstorage.go -package=mocks wallet/internal/services AuditStorage
+
+// auditStorage expects appends to repo, stores them in memory and returns them. Appends are
+// serialized as the storage does.
+func auditStorage(repo *mocks.MockAuditStorage, times int) func() []wallet.AuditRecord {
+	var (
+		mu     sync.Mutex
+		stored []wallet.AuditRecord
+	)
+	repo.EXPECT().
+		AppendAudit(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, chain func(int64, string) wallet.AuditRecord) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			var (
+				seq  int64
+				hash string
+			)
+			if len(stored) > 0 {
+				seq, hash = stored[len(stored)-1].Seq, stored[len(stored)-1].Hash
+			}
+			stored = append(stored, chain(seq, hash))
+			return nil
+		}).
+		Times(times)
+
+	return func() []wallet.AuditRecord {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]wallet.AuditRecord(nil), stored...)
+	}
+}
+
+// recordAudit appends records through AuditLog.Record and returns them as stored.
+func recordAudit(t *testing.T, recs ...wallet.AuditRecord) []wallet.AuditRecord {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockAuditStorage(ctrl)
+	auditLog := services.NewAuditLog(repo, slog.Default())
+	stored := auditStorage(repo, len(recs))
+
+	for _, rec := range recs {
+		require.NoError(t, auditLog.Record(t.Context(), rec))
+	}
+	return stored()
+}
+
+func TestAuditLog_Record(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	stored := recordAudit(t,
+		wallet.AuditRecord{Operation: "WalletOperation", WalletID: &walletID, Amount: ptr(int64(100)), Status: 200},
+		wallet.AuditRecord{Operation: "FreezeWallet", Principal: "alice", WalletID: &walletID, Status: 204},
+	)
+
+	require.Equal(t, int64(1), stored[0].Seq)
+	require.Equal(t, "0000000000000000000000000000000000000000000000000000000000000000", stored[0].PrevHash)
+	require.Len(t, stored[0].Hash, 64)
+	require.Equal(t, int64(2), stored[1].Seq)
+	require.Equal(t, stored[0].Hash, stored[1].PrevHash)
+	require.NotEqual(t, stored[0].Hash, stored[1].Hash)
+}
+
+func TestAuditLog_RecordConcurrently(t *testing.T) {
+	t.Parallel()
+
+	const records = 50
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockAuditStorage(ctrl)
+	auditLog := services.NewAuditLog(repo, slog.Default())
+	stored := auditStorage(repo, records)
+
+	var wg sync.WaitGroup
+	for i := range records {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, auditLog.Record(t.Context(), wallet.AuditRecord{Operation: "WalletOperation", Amount: ptr(int64(i)), Status: 200}))
+		}()
+	}
+	wg.Wait()
+
+	repo.EXPECT().
+		AuditRecords(gomock.Any(), int64(0), gomock.Any()).
+		Return(stored(), nil)
+
+	result, err := auditLog.Verify(t.Context())
+	require.NoError(t, err)
+	require.Nil(t, result.BrokenAt, result.Problem)
+	require.Equal(t, int64(records), result.Records)
+	require.Equal(t, int64(records), result.LastSeq)
+}
+
+func TestAuditLog_RecordBacklog(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockAuditStorage(ctrl)
+	auditLog := services.NewAuditLog(repo, slog.Default())
+
+	// the first record cannot be written, neither when recorded nor on the first flush;
+	// expectations are matched in the order they are set up
+	repo.EXPECT().
+		AppendAudit(gomock.Any(), gomock.Any()).
+		Return(errors.New("connection refused")).
+		Times(2)
+	stored := auditStorage(repo, 2)
+
+	err := auditLog.Record(t.Context(), wallet.AuditRecord{RequestID: "req-1", Outcome: wallet.AuditSucceeded})
+	require.ErrorIs(t, err, wallet.ErrAuditUnavailable)
+	require.ErrorIs(t, auditLog.Flush(t.Context()), wallet.ErrAuditUnavailable)
+
+	// the kept record is written before the next one
+	require.NoError(t, auditLog.Flush(t.Context()))
+	require.NoError(t, auditLog.Record(t.Context(), wallet.AuditRecord{RequestID: "req-2", Outcome: wallet.AuditSucceeded}))
+
+	recs := stored()
+	require.Len(t, recs, 2)
+	require.Equal(t, "req-1", recs[0].RequestID)
+	require.Equal(t, "req-2", recs[1].RequestID)
+	require.Equal(t, recs[0].Hash, recs[1].PrevHash)
+}
+
+func TestAuditLog_Verify(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	chain := recordAudit(t,
+		wallet.AuditRecord{Operation: "WalletOperation", WalletID: &walletID, Amount: ptr(int64(100)), Status: 200},
+		wallet.AuditRecord{Operation: "WalletOperation", WalletID: &walletID, Amount: ptr(int64(50)), Status: 409},
+		wallet.AuditRecord{Operation: "FreezeWallet", Principal: "alice", WalletID: &walletID, Status: 204},
+	)
+
+	tests := []struct {
+		name           string
+		tamper         func(recs []wallet.AuditRecord) []wallet.AuditRecord
+		expectedBroken *int64
+	}{
+		{
+			name:   "intact chain",
+			tamper: func(recs []wallet.AuditRecord) []wallet.AuditRecord { return recs },
+		},
+		{
+			name: "changed amount",
+			tamper: func(recs []wallet.AuditRecord) []wallet.AuditRecord {
+				recs[1].Amount = ptr(int64(5))
+				return recs
+			},
+			expectedBroken: ptr(int64(2)),
+		},
+		{
+			name: "added outcome",
+			tamper: func(recs []wallet.AuditRecord) []wallet.AuditRecord {
+				recs[1].Outcome = wallet.AuditSucceeded
+				return recs
+			},
+			expectedBroken: ptr(int64(2)),
+		},
+		{
+			name: "removed record",
+			tamper: func(recs []wallet.AuditRecord) []wallet.AuditRecord {
+				return append(recs[:1], recs[2:]...)
+			},
+			expectedBroken: ptr(int64(3)),
+		},
+		{
+			name: "rehashed record",
+			tamper: func(recs []wallet.AuditRecord) []wallet.AuditRecord {
+				recs[0].Hash = recs[1].PrevHash[:63] + "f"
+				return recs
+			},
+			expectedBroken: ptr(int64(1)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockAuditStorage(ctrl)
+			auditLog := services.NewAuditLog(repo, slog.Default())
+
+			recs := tt.tamper(append([]wallet.AuditRecord(nil), chain...))
+			repo.EXPECT().
+				AuditRecords(gomock.Any(), int64(0), gomock.Any()).
+				Return(recs, nil)
+
+			result, err := auditLog.Verify(t.Context())
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedBroken, result.BrokenAt)
+			if tt.expectedBroken == nil {
+				require.Equal(t, int64(3), result.Records)
+				require.Equal(t, chain[2].Seq, result.LastSeq)
+				require.Equal(t, chain[2].Hash, result.LastHash)
+			} else {
+				require.NotEmpty(t, result.Problem)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
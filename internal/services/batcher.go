@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+	repo "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// flushTimeout bounds a single batch transaction, which is detached from callers' contexts.
+const flushTimeout = 5 * time.Second
+
+// DepositBatcher combines concurrent deposits into the main bucket of hot wallets. Deposits
+// are queued per wallet and flushed every window as one transaction, so the wallet row is
+// locked once per batch instead of once per deposit.
+type DepositBatcher struct {
+	uow    repo.UnitOfWork
+	window time.Duration
+
+	mu      sync.Mutex
+	hot     map[uuid.UUID]struct{}
+	pending map[uuid.UUID]*depositBatch
+}
+
+type depositBatch struct {
+	ops     []wallet.OperationRecord
+	results []chan depositResult
+}
+
+type depositResult struct {
+	balance int64
+	err     error
+}
+
+func NewDepositBatcher(uow repo.UnitOfWork, window time.Duration, hot []uuid.UUID) *DepositBatcher {
+	b := &DepositBatcher{
+		uow:     uow,
+		window:  window,
+		pending: make(map[uuid.UUID]*depositBatch),
+	}
+	b.SetHot(hot)
+
+	return b
+}
+
+// SetHot replaces the set of hot wallets. Deposits already queued are flushed as usual.
+func (b *DepositBatcher) SetHot(hot []uuid.UUID) {
+	set := make(map[uuid.UUID]struct{}, len(hot))
+	for _, id := range hot {
+		set[id] = struct{}{}
+	}
+
+	b.mu.Lock()
+	b.hot = set
+	b.mu.Unlock()
+}
+
+// IsHot reports whether deposits into the wallet go through the batcher.
+func (b *DepositBatcher) IsHot(walletID uuid.UUID) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, ok := b.hot[walletID]
+	return ok
+}
+
+// Deposit queues the deposit and blocks until the batch containing it is flushed.
+// It returns the wallet balance right after this deposit was applied. The outcome is
+// always awaited, even when ctx is done, so callers never lose track of a committed deposit.
+func (b *DepositBatcher) Deposit(_ context.Context, op wallet.OperationRecord) (int64, error) {
+	result := make(chan depositResult, 1)
+	walletID := op.WalletID
+
+	b.mu.Lock()
+	batch, ok := b.pending[walletID]
+	if !ok {
+		batch = &depositBatch{}
+		b.pending[walletID] = batch
+		go b.flushLoop(walletID)
+	}
+	batch.ops = append(batch.ops, op)
+	batch.results = append(batch.results, result)
+	b.mu.Unlock()
+
+	res := <-result
+	return res.balance, res.err
+}
+
+// flushLoop flushes the wallet's queue every window until no new deposits arrive.
+// There is at most one loop per wallet, so batches of the same wallet never race each other.
+func (b *DepositBatcher) flushLoop(walletID uuid.UUID) {
+	for {
+		time.Sleep(b.window)
+
+		b.mu.Lock()
+		batch := b.pending[walletID]
+		if len(batch.ops) == 0 {
+			delete(b.pending, walletID)
+			b.mu.Unlock()
+			return
+		}
+		b.pending[walletID] = &depositBatch{}
+		b.mu.Unlock()
+
+		b.flush(walletID, batch)
+	}
+}
+
+func (b *DepositBatcher) flush(walletID uuid.UUID, batch *depositBatch) {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	amounts := make([]int64, len(batch.ops))
+	for i, op := range batch.ops {
+		amounts[i] = op.Amount
+	}
+
+	var balances []int64
+	err := b.uow.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+		var err error
+		if balances, err = tx.DepositBatch(ctx, walletID, wallet.BucketMain, amounts); err != nil {
+			return err
+		}
+
+		for _, op := range batch.ops {
+			if err := tx.RecordOperation(ctx, op); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	for i, result := range batch.results {
+		if err != nil {
+			result <- depositResult{err: err}
+			continue
+		}
+		result <- depositResult{balance: balances[i]}
+	}
+}
@@ -0,0 +1,225 @@
+package services_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+	repoModel "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+	"wallet/internal/repository/cache"
+	"wallet/internal/repository/postgres"
+	"wallet/internal/services"
+
+	"github.com/google/uuid"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// rowLockStorage is an in-memory WalletStorage that serializes transactions the way
+// a row lock on a single wallet does, holding the lock for lockTime per transaction.
+type rowLockStorage struct {
+	// methods not used by the batcher are left unimplemented
+	repoModel.Tx
+	services.WalletStorage
+
+	lockTime time.Duration
+
+	mu       sync.Mutex
+	balances map[uuid.UUID]int64
+	txs      int
+}
+
+func newRowLockStorage(lockTime time.Duration) *rowLockStorage {
+	return &rowLockStorage{lockTime: lockTime, balances: make(map[uuid.UUID]int64)}
+}
+
+func (s *rowLockStorage) WithinTx(ctx context.Context, fn repoModel.TxFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.txs++
+	time.Sleep(s.lockTime)
+
+	return fn(ctx, s)
+}
+
+func (s *rowLockStorage) GetBalance(_ context.Context, walletID uuid.UUID) (wallet.Balance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return wallet.Balance{Total: s.balances[walletID]}, nil
+}
+
+func (s *rowLockStorage) Deposit(_ context.Context, walletID uuid.UUID, credit wallet.Credit) (int64, error) {
+	s.balances[walletID] += credit.Amount
+	return s.balances[walletID], nil
+}
+
+func (s *rowLockStorage) DepositBatch(_ context.Context, walletID uuid.UUID, _ string, amounts []int64) ([]int64, error) {
+	balances := make([]int64, len(amounts))
+	for i, amount := range amounts {
+		s.balances[walletID] += amount
+		balances[i] = s.balances[walletID]
+	}
+
+	return balances, nil
+}
+
+func (s *rowLockStorage) RecordOperation(context.Context, wallet.OperationRecord) error {
+	return nil
+}
+
+func TestDepositBatcher_Deposit(t *testing.T) {
+	t.Parallel()
+
+	storage := newRowLockStorage(0)
+	walletID := uuid.New()
+	batcher := services.NewDepositBatcher(storage, 20*time.Millisecond, []uuid.UUID{walletID})
+
+	require.True(t, batcher.IsHot(walletID))
+	require.False(t, batcher.IsHot(uuid.New()))
+
+	const deposits = 50
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		balances []int64
+	)
+	for range deposits {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			balance, err := batcher.Deposit(t.Context(), wallet.OperationRecord{ID: uuid.New(), WalletID: walletID, Type: wallet.OperationDeposit, Amount: 10})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			mu.Lock()
+			balances = append(balances, balance)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// every caller observes its own balance
+	sort.Slice(balances, func(i, j int) bool { return balances[i] < balances[j] })
+	for i, balance := range balances {
+		require.Equal(t, int64(10*(i+1)), balance)
+	}
+
+	require.Less(t, storage.txs, deposits)
+}
+
+func TestDepositBatcher_SetHot(t *testing.T) {
+	t.Parallel()
+
+	cold, hot := uuid.New(), uuid.New()
+	batcher := services.NewDepositBatcher(newRowLockStorage(0), time.Millisecond, []uuid.UUID{cold})
+
+	batcher.SetHot([]uuid.UUID{hot})
+
+	require.False(t, batcher.IsHot(cold))
+	require.True(t, batcher.IsHot(hot))
+}
+
+// BenchmarkDeposit_HotWallet compares per-request and batched deposits against a simulated
+// row lock held for lockTime per transaction. It measures the batching itself; see
+// BenchmarkDeposit_HotWallet_Postgres for the real row lock.
+func BenchmarkDeposit_HotWallet(b *testing.B) {
+	const lockTime = 200 * time.Microsecond
+
+	walletID := uuid.New()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	benchmarks := []struct {
+		name string
+		opts func(storage *rowLockStorage) []services.Option
+	}{
+		{
+			name: "per request",
+			opts: func(*rowLockStorage) []services.Option { return nil },
+		},
+		{
+			name: "batched",
+			opts: func(storage *rowLockStorage) []services.Option {
+				batcher := services.NewDepositBatcher(storage, time.Millisecond, []uuid.UUID{walletID})
+				return []services.Option{services.WithDepositBatcher(batcher)}
+			},
+		},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			l, err := lru.New(1000)
+			require.NoError(b, err)
+
+			storage := newRowLockStorage(lockTime)
+			service := services.NewWalletService(storage, cache.New(l), logger, bm.opts(storage)...)
+
+			benchmarkDeposits(b, service, walletID)
+		})
+	}
+}
+
+// BenchmarkDeposit_HotWallet_Postgres compares the same modes against the row lock of a real
+// wallet. It needs a migrated database in BENCH_DB_DSN and is skipped otherwise.
+func BenchmarkDeposit_HotWallet_Postgres(b *testing.B) {
+	dsn := os.Getenv("BENCH_DB_DSN")
+	if dsn == "" {
+		b.Skip("BENCH_DB_DSN is not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), dsn)
+	require.NoError(b, err)
+	defer pool.Close()
+
+	storage := postgres.New(pool)
+	owner := "bench"
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, batched := range []bool{false, true} {
+		name := "per request"
+		if batched {
+			name = "batched"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			// a fresh wallet per mode, so that both start from an uncontended row
+			created, err := storage.CreateWallet(context.Background(), wallet.Wallet{ID: uuid.New(), OwnerID: &owner, Currency: "USD"})
+			require.NoError(b, err)
+
+			var opts []services.Option
+			if batched {
+				batcher := services.NewDepositBatcher(storage, time.Millisecond, []uuid.UUID{created.ID})
+				opts = append(opts, services.WithDepositBatcher(batcher))
+			}
+
+			l, err := lru.New(1000)
+			require.NoError(b, err)
+
+			service := services.NewWalletService(storage, cache.New(l), logger, opts...)
+			benchmarkDeposits(b, service, created.ID)
+		})
+	}
+}
+
+func benchmarkDeposits(b *testing.B, service *services.WalletService, walletID uuid.UUID) {
+	b.SetParallelism(64)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := service.Deposit(context.Background(), walletID, wallet.Credit{Bucket: wallet.BucketMain, Amount: 1}, nil, nil); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
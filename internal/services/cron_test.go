@@ -0,0 +1,52 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurrence_Next(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday
+	from := time.Date(2026, 1, 14, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{expr: "* * * * *", expected: time.Date(2026, 1, 14, 10, 18, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", expected: time.Date(2026, 1, 14, 10, 30, 0, 0, time.UTC)},
+		{expr: "0 9 * * *", expected: time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1 * *", expected: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "30 8 * * 1-5", expected: time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC)},
+		{expr: "0 12 * * 7", expected: time.Date(2026, 1, 18, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", expected: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// either day field matches when both are restricted
+		{expr: "0 0 20 * 5", expected: time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 31 2 *", expected: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := services.ParseRecurrence(tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, r.Next(from))
+		})
+	}
+}
+
+func TestParseRecurrence_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		_, err := services.ParseRecurrence(expr)
+		require.ErrorIs(t, err, wallet.ErrInvalidRecurrence, expr)
+	}
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// eventBuffer is the number of events buffered per stream; streams falling further behind are closed.
+	eventBuffer = 64
+	// eventReplayBatch bounds the balance events read from the change log at once.
+	eventReplayBatch = 100
+	// listenRetryDelay is the pause before the event hub listens again after a failure.
+	listenRetryDelay = time.Second
+)
+
+var errEventsDisabled = errors.New("balance events are not enabled")
+
+type EventSource interface {
+	Listen(ctx context.Context, fn func(wallet.BalanceEvent)) error // Listen calls fn with every committed balance event
+}
+
+// EventHub fans balance events out to the streams watching their wallets. It bounds the
+// number of open streams in total and per wallet.
+type EventHub struct {
+	log          *slog.Logger
+	source       EventSource
+	maxStreams   int
+	maxPerWallet int
+
+	mu        sync.Mutex
+	total     int
+	streams   map[uuid.UUID]map[chan wallet.BalanceEvent]struct{}
+	observers []func(wallet.BalanceEvent)
+}
+
+func NewEventHub(source EventSource, log *slog.Logger, maxStreams, maxPerWallet int) *EventHub {
+	return &EventHub{
+		log:          log,
+		source:       source,
+		maxStreams:   maxStreams,
+		maxPerWallet: maxPerWallet,
+		streams:      make(map[uuid.UUID]map[chan wallet.BalanceEvent]struct{}),
+	}
+}
+
+// Run publishes events from the source until ctx is done, listening again after failures.
+// Streams recover events missed meanwhile from the change log with the next event of their wallet.
+func (h *EventHub) Run(ctx context.Context) {
+	for {
+		err := h.source.Listen(ctx, h.publish)
+		if ctx.Err() != nil {
+			return
+		}
+		h.log.Error("Error listening to balance events", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+// observe calls fn with every published event.
+func (h *EventHub) observe(fn func(wallet.BalanceEvent)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.observers = append(h.observers, fn)
+}
+
+// publish hands the event to the observers and the streams of its wallet. Streams with a
+// full buffer are closed.
+func (h *EventHub) publish(ev wallet.BalanceEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, fn := range h.observers {
+		fn(ev)
+	}
+
+	for ch := range h.streams[ev.WalletID] {
+		select {
+		case ch <- ev:
+		default:
+			h.remove(ev.WalletID, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *EventHub) subscribe(walletID uuid.UUID) (chan wallet.BalanceEvent, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.total >= h.maxStreams || len(h.streams[walletID]) >= h.maxPerWallet {
+		return nil, wallet.ErrTooManyWatchers
+	}
+
+	ch := make(chan wallet.BalanceEvent, eventBuffer)
+	if h.streams[walletID] == nil {
+		h.streams[walletID] = make(map[chan wallet.BalanceEvent]struct{})
+	}
+	h.streams[walletID][ch] = struct{}{}
+	h.total++
+
+	return ch, nil
+}
+
+func (h *EventHub) unsubscribe(walletID uuid.UUID, ch chan wallet.BalanceEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.streams[walletID][ch]; ok {
+		h.remove(walletID, ch)
+	}
+}
+
+func (h *EventHub) remove(walletID uuid.UUID, ch chan wallet.BalanceEvent) {
+	delete(h.streams[walletID], ch)
+	if len(h.streams[walletID]) == 0 {
+		delete(h.streams, walletID)
+	}
+	h.total--
+}
+
+// WatchBalance returns the current balance of the wallet and streams its changes. Changes
+// start after lastVersion when it is set and not ahead of the current version, so a client
+// can resume where it left off, and after the current version otherwise. The channel is
+// closed when ctx is done, when reading the change log fails or when the stream falls too
+// far behind; the client then resumes from the last version it received.
+func (ws *WalletService) WatchBalance(ctx context.Context, walletID uuid.UUID, lastVersion *int64) (wallet.Balance, <-chan wallet.BalanceEvent, error) {
+	if ws.events == nil {
+		return wallet.Balance{}, nil, errEventsDisabled
+	}
+
+	// subscribe before reading the balance so that no change falls in between
+	sub, err := ws.events.subscribe(walletID)
+	if err != nil {
+		return wallet.Balance{}, nil, err
+	}
+
+	balance, err := ws.repo.GetBalance(ctx, walletID)
+	if err != nil {
+		ws.events.unsubscribe(walletID, sub)
+		return wallet.Balance{}, nil, err
+	}
+
+	last := balance.Version
+	if lastVersion != nil && *lastVersion <= balance.Version {
+		last = *lastVersion
+	}
+
+	out := make(chan wallet.BalanceEvent)
+	go func() {
+		defer ws.events.unsubscribe(walletID, sub)
+		defer close(out)
+
+		send := func(ev wallet.BalanceEvent) bool {
+			select {
+			case out <- ev:
+				last = ev.Version
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// catchUp sends the logged events after last
+		catchUp := func() bool {
+			for {
+				events, err := ws.repo.BalanceEvents(ctx, walletID, last, eventReplayBatch)
+				if err != nil {
+					ws.log.Error("Error reading balance events", "walletID", walletID, "version", last, "error", err)
+					return false
+				}
+
+				for _, ev := range events {
+					if !send(ev) {
+						return false
+					}
+				}
+
+				if len(events) < eventReplayBatch {
+					return true
+				}
+			}
+		}
+
+		if last < balance.Version && !catchUp() {
+			return
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev, ok := <-sub:
+				switch {
+				case !ok:
+					return
+				case ev.Version <= last:
+					// already sent from the change log
+				case ev.Version == last+1:
+					if !send(ev) {
+						return
+					}
+				default:
+					// events were missed while the hub was not listening
+					if !catchUp() {
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return balance, out, nil
+}
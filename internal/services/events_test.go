@@ -0,0 +1,197 @@
+package services_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+	"wallet/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// eventSource hands the publish function of a running hub to the test.
+type eventSource struct {
+	publish chan func(wallet.BalanceEvent)
+}
+
+func (s *eventSource) Listen(ctx context.Context, fn func(wallet.BalanceEvent)) error {
+	s.publish <- fn
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// startHub runs a hub and returns it with the function publishing its events.
+func startHub(t *testing.T, maxStreams, maxPerWallet int) (*services.EventHub, func(wallet.BalanceEvent)) {
+	t.Helper()
+
+	source := &eventSource{publish: make(chan func(wallet.BalanceEvent), 1)}
+	hub := services.NewEventHub(source, slog.Default(), maxStreams, maxPerWallet)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go hub.Run(ctx)
+
+	return hub, <-source.publish
+}
+
+func receive(t *testing.T, events <-chan wallet.BalanceEvent) wallet.BalanceEvent {
+	t.Helper()
+
+	select {
+	case ev, ok := <-events:
+		require.True(t, ok, "stream closed")
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return wallet.BalanceEvent{}
+	}
+}
+
+func TestWalletService_WatchBalance(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	event := func(version int64) wallet.BalanceEvent {
+		return wallet.BalanceEvent{WalletID: walletID, Version: version, Balance: version * 10, Delta: 10}
+	}
+
+	tests := []struct {
+		name        string
+		lastVersion *int64
+		replayed    []wallet.BalanceEvent
+		published   []wallet.BalanceEvent
+		gap         []wallet.BalanceEvent
+		expected    []int64
+	}{
+		{
+			name:      "new stream",
+			published: []wallet.BalanceEvent{event(6), event(7)},
+			expected:  []int64{6, 7},
+		},
+		{
+			name:        "resumed stream",
+			lastVersion: ptr(int64(3)),
+			replayed:    []wallet.BalanceEvent{event(4), event(5)},
+			published:   []wallet.BalanceEvent{event(5), event(6)},
+			expected:    []int64{4, 5, 6},
+		},
+		{
+			name:        "resumed ahead of the wallet",
+			lastVersion: ptr(int64(9)),
+			published:   []wallet.BalanceEvent{event(6)},
+			expected:    []int64{6},
+		},
+		{
+			name:      "missed events",
+			published: []wallet.BalanceEvent{event(6), event(9)},
+			gap:       []wallet.BalanceEvent{event(7), event(8), event(9)},
+			expected:  []int64{6, 7, 8, 9},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWalletStorage(ctrl)
+			cache := mocks.NewMockWalletCache(ctrl)
+			hub, publish := startHub(t, 10, 10)
+			walletService := services.NewWalletService(repo, cache, slog.Default(), services.WithEventHub(hub))
+			cache.EXPECT().Delete(gomock.Any(), walletID.String()).AnyTimes() // published events drop the cached balance
+
+			repo.EXPECT().
+				GetBalance(gomock.Any(), walletID).
+				Return(wallet.Balance{Total: 50, Version: 5}, nil)
+			if tt.replayed != nil {
+				repo.EXPECT().
+					BalanceEvents(gomock.Any(), walletID, *tt.lastVersion, gomock.Any()).
+					Return(tt.replayed, nil)
+			}
+			if tt.gap != nil {
+				repo.EXPECT().
+					BalanceEvents(gomock.Any(), walletID, int64(6), gomock.Any()).
+					Return(tt.gap, nil)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			balance, events, err := walletService.WatchBalance(ctx, walletID, tt.lastVersion)
+			require.NoError(t, err)
+			require.Equal(t, int64(5), balance.Version)
+
+			for _, ev := range tt.published {
+				publish(ev)
+			}
+
+			var versions []int64
+			for range tt.expected {
+				versions = append(versions, receive(t, events).Version)
+			}
+			require.Equal(t, tt.expected, versions)
+
+			cancel()
+			for range events {
+				// drained until the stream closes
+			}
+		})
+	}
+}
+
+func TestWalletService_WatchBalanceLimits(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+	hub, _ := startHub(t, 2, 1)
+	walletService := services.NewWalletService(repo, cache, slog.Default(), services.WithEventHub(hub))
+
+	walletID := uuid.New()
+	missingID := uuid.New()
+
+	repo.EXPECT().GetBalance(gomock.Any(), missingID).Return(wallet.Balance{}, wallet.ErrWalletNotFound)
+	repo.EXPECT().GetBalance(gomock.Any(), gomock.Any()).Return(wallet.Balance{}, nil).Times(2)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	// the stream of a missing wallet does not take a slot
+	_, _, err := walletService.WatchBalance(ctx, missingID, nil)
+	require.ErrorIs(t, err, wallet.ErrWalletNotFound)
+
+	_, _, err = walletService.WatchBalance(ctx, walletID, nil)
+	require.NoError(t, err)
+
+	_, _, err = walletService.WatchBalance(ctx, walletID, nil)
+	require.ErrorIs(t, err, wallet.ErrTooManyWatchers)
+
+	_, _, err = walletService.WatchBalance(ctx, uuid.New(), nil)
+	require.NoError(t, err)
+
+	_, _, err = walletService.WatchBalance(ctx, uuid.New(), nil)
+	require.ErrorIs(t, err, wallet.ErrTooManyWatchers)
+}
+
+func TestWalletService_EventsDropCachedBalance(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+
+	hub, publish := startHub(t, 10, 10)
+	services.NewWalletService(repo, cache, slog.Default(), services.WithEventHub(hub))
+
+	walletID := uuid.New()
+	cache.EXPECT().Delete(gomock.Any(), walletID.String())
+
+	// a change committed outside the service, e.g. by walletctl
+	publish(wallet.BalanceEvent{WalletID: walletID, Version: 2, Balance: 100, Delta: 100})
+}
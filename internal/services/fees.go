@@ -0,0 +1,162 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// FeeRule charges Flat plus PercentBps basis points of the operation amount, clamped to [Min, Max].
+type FeeRule struct {
+	Operation  wallet.Operation `json:"operationType"`
+	Tier       string           `json:"tier,omitempty"` // Tier limits the rule to wallets of the tier, any tier when empty
+	Flat       int64            `json:"flat,omitempty"`
+	PercentBps int64            `json:"percentBps,omitempty"`
+	Min        int64            `json:"min,omitempty"`
+	Max        int64            `json:"max,omitempty"` // Max caps the fee, no cap when zero
+}
+
+// FeeSchedule is the set of fee rules. Fees are computed in the currency of the charged
+// wallet and posted to the fee-income wallet of that currency: the one in FeeWallets, or
+// FeeWalletID for currencies not listed there.
+type FeeSchedule struct {
+	FeeWalletID uuid.UUID            `json:"feeWalletId"`
+	FeeWallets  map[string]uuid.UUID `json:"feeWallets,omitempty"`
+	Rules       []FeeRule            `json:"rules"`
+}
+
+// LoadFeeSchedule reads a JSON fee schedule from path.
+func LoadFeeSchedule(path string) (*FeeSchedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fee schedule: %w", err)
+	}
+
+	var schedule FeeSchedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("parse fee schedule: %w", err)
+	}
+
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+func (s *FeeSchedule) Validate() error {
+	if len(s.Rules) > 0 && s.FeeWalletID == uuid.Nil {
+		return errors.New("fee schedule: feeWalletId is required")
+	}
+
+	for currency, id := range s.FeeWallets {
+		if id == uuid.Nil {
+			return fmt.Errorf("fee schedule: fee wallet of %s is required", currency)
+		}
+	}
+
+	seen := make(map[FeeRule]bool)
+	for i, r := range s.Rules {
+		if r.Operation == "" {
+			return fmt.Errorf("fee schedule: rule %d: operationType is required", i)
+		}
+		if r.Flat < 0 || r.PercentBps < 0 || r.Min < 0 || r.Max < 0 {
+			return fmt.Errorf("fee schedule: rule %d: negative fee", i)
+		}
+		if r.PercentBps > 10000 {
+			return fmt.Errorf("fee schedule: rule %d: percentBps exceeds 100%%", i)
+		}
+		if r.Max > 0 && r.Min > r.Max {
+			return fmt.Errorf("fee schedule: rule %d: min exceeds max", i)
+		}
+
+		key := FeeRule{Operation: r.Operation, Tier: r.Tier}
+		if seen[key] {
+			return fmt.Errorf("fee schedule: rule %d: duplicate rule for %s/%q", i, r.Operation, r.Tier)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+// feeWallet returns the fee-income wallet of the currency.
+func (s *FeeSchedule) feeWallet(currency string) uuid.UUID {
+	if id, ok := s.FeeWallets[currency]; ok {
+		return id
+	}
+
+	return s.FeeWalletID
+}
+
+// isFeeWallet reports whether fees are posted to the wallet.
+func (s *FeeSchedule) isFeeWallet(walletID uuid.UUID) bool {
+	if walletID == s.FeeWalletID {
+		return true
+	}
+
+	for _, id := range s.FeeWallets {
+		if id == walletID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Charges reports whether any rule applies to op.
+func (s *FeeSchedule) Charges(op wallet.Operation) bool {
+	for _, r := range s.Rules {
+		if r.Operation == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Fee returns the fee for op on a wallet of the tier. A rule for the exact tier wins
+// over a rule for any tier; without a matching rule the operation is free.
+func (s *FeeSchedule) Fee(op wallet.Operation, tier string, amount int64) int64 {
+	var rule *FeeRule
+	for i, r := range s.Rules {
+		if r.Operation != op {
+			continue
+		}
+		if r.Tier == tier {
+			rule = &s.Rules[i]
+			break
+		}
+		if r.Tier == "" {
+			rule = &s.Rules[i]
+		}
+	}
+
+	if rule == nil {
+		return 0
+	}
+
+	return rule.fee(amount)
+}
+
+func (r FeeRule) fee(amount int64) int64 {
+	// amount * bps / 10000, rounded half up; computed in big.Int as the product may overflow
+	percent := new(big.Int).Mul(big.NewInt(amount), big.NewInt(r.PercentBps))
+	percent.Add(percent, big.NewInt(5000))
+	percent.Quo(percent, big.NewInt(10000))
+
+	fee := new(big.Int).Add(percent, big.NewInt(r.Flat))
+	if r.Max > 0 && fee.Cmp(big.NewInt(r.Max)) > 0 {
+		return r.Max
+	}
+	if fee.Cmp(big.NewInt(r.Min)) < 0 {
+		return r.Min
+	}
+
+	return fee.Int64()
+}
@@ -0,0 +1,159 @@
+package services_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeSchedule_Fee(t *testing.T) {
+	t.Parallel()
+
+	schedule := services.FeeSchedule{
+		FeeWalletID: uuid.New(),
+		Rules: []services.FeeRule{
+			{Operation: wallet.OperationWithdraw, Flat: 10, PercentBps: 150, Min: 20, Max: 500},
+			{Operation: wallet.OperationWithdraw, Tier: "premium", PercentBps: 50},
+			{Operation: wallet.OperationDeposit, Tier: "merchant", Flat: 5},
+		},
+	}
+	require.NoError(t, schedule.Validate())
+
+	tests := []struct {
+		name     string
+		op       wallet.Operation
+		tier     string
+		amount   int64
+		expected int64
+	}{
+		{
+			name:     "flat plus percentage",
+			op:       wallet.OperationWithdraw,
+			tier:     "standard",
+			amount:   10000,
+			expected: 160,
+		},
+		{
+			name:     "percentage rounds half up",
+			op:       wallet.OperationWithdraw,
+			tier:     "standard",
+			amount:   1900,
+			expected: 39, // 10 + 28.5
+		},
+		{
+			name:     "min applies",
+			op:       wallet.OperationWithdraw,
+			tier:     "standard",
+			amount:   100,
+			expected: 20,
+		},
+		{
+			name:     "max applies",
+			op:       wallet.OperationWithdraw,
+			tier:     "standard",
+			amount:   1_000_000,
+			expected: 500,
+		},
+		{
+			name:     "tier rule wins over any tier",
+			op:       wallet.OperationWithdraw,
+			tier:     "premium",
+			amount:   10000,
+			expected: 50,
+		},
+		{
+			name:     "tier rule does not match other tiers",
+			op:       wallet.OperationDeposit,
+			tier:     "standard",
+			amount:   10000,
+			expected: 0,
+		},
+		{
+			name:     "tier rule",
+			op:       wallet.OperationDeposit,
+			tier:     "merchant",
+			amount:   10000,
+			expected: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, schedule.Fee(tt.op, tt.tier, tt.amount))
+		})
+	}
+}
+
+func TestFeeSchedule_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		schedule services.FeeSchedule
+	}{
+		{
+			name: "missing fee wallet",
+			schedule: services.FeeSchedule{
+				Rules: []services.FeeRule{{Operation: wallet.OperationWithdraw, Flat: 1}},
+			},
+		},
+		{
+			name: "missing currency fee wallet",
+			schedule: services.FeeSchedule{
+				FeeWalletID: uuid.New(),
+				FeeWallets:  map[string]uuid.UUID{"EUR": uuid.Nil},
+			},
+		},
+		{
+			name: "min exceeds max",
+			schedule: services.FeeSchedule{
+				FeeWalletID: uuid.New(),
+				Rules:       []services.FeeRule{{Operation: wallet.OperationWithdraw, Min: 10, Max: 5}},
+			},
+		},
+		{
+			name: "duplicate rule",
+			schedule: services.FeeSchedule{
+				FeeWalletID: uuid.New(),
+				Rules: []services.FeeRule{
+					{Operation: wallet.OperationWithdraw, Flat: 1},
+					{Operation: wallet.OperationWithdraw, Flat: 2},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Error(t, tt.schedule.Validate())
+		})
+	}
+}
+
+func TestLoadFeeSchedule(t *testing.T) {
+	t.Parallel()
+
+	feeWalletID := uuid.New()
+	path := filepath.Join(t.TempDir(), "fees.json")
+	err := os.WriteFile(path, []byte(`{
+		"feeWalletId": "`+feeWalletID.String()+`",
+		"rules": [{"operationType": "WITHDRAW", "tier": "standard", "percentBps": 100, "min": 10}]
+	}`), 0o600)
+	require.NoError(t, err)
+
+	schedule, err := services.LoadFeeSchedule(path)
+	require.NoError(t, err)
+	require.Equal(t, feeWalletID, schedule.FeeWalletID)
+	require.Equal(t, []services.FeeRule{
+		{Operation: wallet.OperationWithdraw, Tier: "standard", PercentBps: 100, Min: 10},
+	}, schedule.Rules)
+}
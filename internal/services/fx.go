@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"wallet/internal/model/wallet"
+)
+
+// RateProvider supplies exchange rates for quotes.
+type RateProvider interface {
+	// Rate returns the price of one unit of from in to
+	Rate(ctx context.Context, from, to string) (*big.Rat, error)
+}
+
+// FileRateProvider serves fixed rates from a JSON file and is meant for local use:
+//
+//	{"USD": {"EUR": "0.92", "JPY": "151.30"}}
+//
+// A missing pair is derived from its inverse when that one is configured.
+type FileRateProvider struct {
+	rates map[string]map[string]*big.Rat
+}
+
+func NewFileRateProvider(path string) (*FileRateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rates: %w", err)
+	}
+
+	var raw map[string]map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse rates: %w", err)
+	}
+
+	rates := make(map[string]map[string]*big.Rat, len(raw))
+	for from, quotes := range raw {
+		rates[from] = make(map[string]*big.Rat, len(quotes))
+		for to, v := range quotes {
+			rate, ok := new(big.Rat).SetString(v)
+			if !ok || rate.Sign() <= 0 {
+				return nil, fmt.Errorf("parse rates: invalid %s/%s rate %q", from, to, v)
+			}
+			rates[from][to] = rate
+		}
+	}
+
+	return &FileRateProvider{rates: rates}, nil
+}
+
+func (p *FileRateProvider) Rate(_ context.Context, from, to string) (*big.Rat, error) {
+	if rate, ok := p.rates[from][to]; ok {
+		return new(big.Rat).Set(rate), nil
+	}
+
+	if rate, ok := p.rates[to][from]; ok {
+		return new(big.Rat).Inv(rate), nil
+	}
+
+	return nil, wallet.ErrRateNotFound
+}
+
+// minorUnitExponents lists ISO 4217 currencies whose minor unit is not 1/100.
+var minorUnitExponents = map[string]int{
+	"BHD": 3, "CLP": 0, "IQD": 3, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0,
+	"KWD": 3, "LYD": 3, "OMR": 3, "PYG": 0, "TND": 3, "UGX": 0, "VND": 0,
+}
+
+func minorUnitExponent(currency string) int {
+	if exp, ok := minorUnitExponents[currency]; ok {
+		return exp
+	}
+
+	return 2
+}
+
+// convert converts amount minor units of from into minor units of to, rounding down.
+func convert(amount int64, rate *big.Rat, from, to string) (int64, error) {
+	converted := new(big.Rat).Mul(big.NewRat(amount, 1), rate)
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(minorUnitExponent(to)-minorUnitExponent(from)))), nil)
+	if minorUnitExponent(to) > minorUnitExponent(from) {
+		converted.Mul(converted, new(big.Rat).SetInt(scale))
+	} else {
+		converted.Quo(converted, new(big.Rat).SetInt(scale))
+	}
+
+	result := new(big.Int).Quo(converted.Num(), converted.Denom())
+	if !result.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflows: %s", result)
+	}
+
+	if result.Sign() == 0 {
+		return 0, wallet.ErrAmountTooSmall
+	}
+
+	return result.Int64(), nil
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
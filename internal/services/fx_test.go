@@ -0,0 +1,38 @@
+package services_test
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRateProvider(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(path, []byte(`{"USD": {"EUR": "0.8", "JPY": "151.30"}}`), 0o600)
+	require.NoError(t, err)
+
+	provider, err := services.NewFileRateProvider(path)
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(t.Context(), "USD", "JPY")
+	require.NoError(t, err)
+	require.Zero(t, rate.Cmp(big.NewRat(15130, 100)))
+
+	rate, err = provider.Rate(t.Context(), "EUR", "USD")
+	require.NoError(t, err)
+	require.Zero(t, rate.Cmp(big.NewRat(5, 4)))
+
+	_, err = provider.Rate(t.Context(), "EUR", "JPY")
+	require.ErrorIs(t, err, wallet.ErrRateNotFound)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"USD": {"EUR": "-1"}}`), 0o600))
+	_, err = services.NewFileRateProvider(path)
+	require.Error(t, err)
+}
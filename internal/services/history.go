@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"time"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// BalanceAt returns the wallet balance as it was at the given time.
+func (ws *WalletService) BalanceAt(ctx context.Context, walletID uuid.UUID, at time.Time) (wallet.Balance, error) {
+	balance, err := ws.repo.BalanceAt(ctx, walletID, at)
+	if err != nil {
+		ws.log.Error("Error fetching balance history", "walletID", walletID, "at", at, "error", err)
+		return wallet.Balance{}, err
+	}
+
+	return balance, nil
+}
+
+// TakeSnapshot snapshots wallet balances at the start of the current UTC day, the latest
+// point no entry can be journaled before anymore. Re-running it on the same day does nothing.
+func (ws *WalletService) TakeSnapshot(ctx context.Context) (int64, error) {
+	at := time.Now().UTC().Truncate(24 * time.Hour)
+
+	rows, err := ws.repo.TakeSnapshot(ctx, at)
+	if err != nil {
+		ws.log.Error("Error taking balance snapshot", "at", at, "error", err)
+		return 0, err
+	}
+
+	if rows > 0 {
+		ws.log.Info("Balance snapshot taken", "at", at, "rows", rows)
+	}
+
+	return rows, nil
+}
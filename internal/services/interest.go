@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+	repo "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// accrualScale is the number of decimal places daily interest is kept with before rounding.
+const accrualScale = 18
+
+type Compounding string
+
+const (
+	CompoundDaily   Compounding = "DAILY"   // interest is credited every day
+	CompoundMonthly Compounding = "MONTHLY" // interest is credited on the last day of the month
+)
+
+type RoundingMode string
+
+const (
+	RoundDown     RoundingMode = "DOWN"
+	RoundHalfUp   RoundingMode = "HALF_UP"
+	RoundHalfEven RoundingMode = "HALF_EVEN"
+)
+
+// InterestProduct pays APR percent a year on the end-of-day balance of the main bucket,
+// 1/365 of it for every day. Interest is rounded when it is credited.
+type InterestProduct struct {
+	Name        string       `json:"name"`
+	APR         string       `json:"apr"` // APR is a decimal percentage, e.g. "4.5"
+	Compounding Compounding  `json:"compounding"`
+	Rounding    RoundingMode `json:"rounding"`
+}
+
+// InterestProducts is the set of interest products. Interest is paid out of the funding wallet.
+type InterestProducts struct {
+	FundingWalletID uuid.UUID         `json:"fundingWalletId"`
+	Products        []InterestProduct `json:"products"`
+}
+
+// LoadInterestProducts reads JSON interest products from path.
+func LoadInterestProducts(path string) (*InterestProducts, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read interest products: %w", err)
+	}
+
+	var products InterestProducts
+	if err := json.Unmarshal(data, &products); err != nil {
+		return nil, fmt.Errorf("parse interest products: %w", err)
+	}
+
+	if err := products.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &products, nil
+}
+
+func (p *InterestProducts) Validate() error {
+	if len(p.Products) > 0 && p.FundingWalletID == uuid.Nil {
+		return errors.New("interest products: fundingWalletId is required")
+	}
+
+	seen := make(map[string]bool)
+	for i, product := range p.Products {
+		if product.Name == "" {
+			return fmt.Errorf("interest products: product %d: name is required", i)
+		}
+		if seen[product.Name] {
+			return fmt.Errorf("interest products: product %d: duplicate product %q", i, product.Name)
+		}
+		seen[product.Name] = true
+
+		apr, ok := new(big.Rat).SetString(product.APR)
+		if !ok || apr.Sign() < 0 {
+			return fmt.Errorf("interest products: product %q: invalid apr %q", product.Name, product.APR)
+		}
+
+		switch product.Compounding {
+		case CompoundDaily, CompoundMonthly:
+		default:
+			return fmt.Errorf("interest products: product %q: invalid compounding %q", product.Name, product.Compounding)
+		}
+
+		switch product.Rounding {
+		case RoundDown, RoundHalfUp, RoundHalfEven:
+		default:
+			return fmt.Errorf("interest products: product %q: invalid rounding %q", product.Name, product.Rounding)
+		}
+	}
+
+	return nil
+}
+
+// Product returns the product with the given name.
+func (p *InterestProducts) Product(name string) (InterestProduct, bool) {
+	for _, product := range p.Products {
+		if product.Name == name {
+			return product, true
+		}
+	}
+
+	return InterestProduct{}, false
+}
+
+// DailyInterest returns the exact interest earned in a day on balance. Negative balances earn nothing.
+func (p InterestProduct) DailyInterest(balance int64) *big.Rat {
+	if balance <= 0 {
+		return new(big.Rat)
+	}
+
+	apr, _ := new(big.Rat).SetString(p.APR) // validated on load
+	interest := new(big.Rat).Mul(big.NewRat(balance, 1), apr)
+	return interest.Quo(interest, big.NewRat(100*365, 1))
+}
+
+// Round rounds a non-negative amount to a whole number of minor units.
+func (m RoundingMode) Round(amount *big.Rat) int64 {
+	q, r := new(big.Int).QuoRem(amount.Num(), amount.Denom(), new(big.Int))
+
+	// compare the remainder with half of the denominator
+	half := new(big.Int).Lsh(r, 1).Cmp(amount.Denom())
+	switch {
+	case m == RoundHalfUp && half >= 0,
+		m == RoundHalfEven && (half > 0 || half == 0 && q.Bit(0) == 1):
+		q.Add(q, big.NewInt(1))
+	}
+
+	return q.Int64()
+}
+
+// AccrueInterest accrues the interest of every wallet enrolled in a product for the days
+// up to the UTC day of through and returns the number of days accrued. Wallets catch up
+// day by day from the day after their last accrual, so days missed while the job was not
+// running are accrued in order; wallets that never accrued start on the day of through.
+// A wallet accrues at most once per day, so the job is safe to re-run.
+func (ws *WalletService) AccrueInterest(ctx context.Context, through time.Time) (int, error) {
+	if ws.interest == nil {
+		return 0, nil
+	}
+
+	last := through.UTC().Truncate(24 * time.Hour)
+
+	accounts, err := ws.repo.InterestAccounts(ctx)
+	if err != nil {
+		ws.log.Error("Error listing interest accounts", "error", err)
+		return 0, err
+	}
+
+	var (
+		total int
+		errs  []error
+	)
+	for _, account := range accounts {
+		product, ok := ws.interest.Product(account.Product)
+		if !ok {
+			ws.log.Warn("Unknown interest product", "walletID", account.WalletID, "product", account.Product)
+			continue
+		}
+
+		day := last
+		if account.LastAccrued != nil {
+			day = account.LastAccrued.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+		}
+
+		// a failed day stops the wallet, as later days depend on its balance
+		for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+			accrued, err := ws.accrue(ctx, account.WalletID, product, day)
+			if err != nil {
+				ws.log.Error("Error accruing interest", "walletID", account.WalletID, "date", day, "error", err)
+				errs = append(errs, err)
+				break
+			}
+
+			if accrued {
+				total++
+			}
+		}
+	}
+
+	return total, errors.Join(errs...)
+}
+
+// accrue records the interest earned by the wallet on day and credits it when the
+// compounding period ends. It returns false when the day was already accrued.
+func (ws *WalletService) accrue(ctx context.Context, walletID uuid.UUID, product InterestProduct, day time.Time) (bool, error) {
+	end := day.AddDate(0, 0, 1)
+
+	var accrual wallet.Accrual
+	accrued := false
+
+	err := ws.repo.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+		accrued = false
+
+		balance, err := tx.BucketBalanceAt(ctx, walletID, wallet.BucketMain, end)
+		if err != nil {
+			return err
+		}
+
+		amount, _ := new(big.Rat).SetString(product.DailyInterest(balance).FloatString(accrualScale))
+		accrual = wallet.Accrual{WalletID: walletID, Date: day, Product: product.Name, Balance: balance, Amount: amount}
+
+		switch {
+		case product.Compounding == CompoundDaily:
+			accrual.Posted = product.Rounding.Round(amount)
+		case end.Day() == 1:
+			monthStart := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+			earlier, err := tx.AccruedInterest(ctx, walletID, monthStart, day)
+			if err != nil {
+				return err
+			}
+			accrual.Posted = product.Rounding.Round(new(big.Rat).Add(earlier, amount))
+		}
+
+		recorded, err := tx.RecordAccrual(ctx, accrual)
+		if err != nil || !recorded {
+			return err
+		}
+		accrued = true
+
+		if accrual.Posted == 0 {
+			return nil
+		}
+
+		// credited at the end of the day, so that it counts towards the next day's balance
+		if err := tx.PostInterest(ctx, walletID, ws.interest.FundingWalletID, accrual.Posted, end); err != nil {
+			return err
+		}
+
+		op := wallet.OperationRecord{
+			ID:        uuid.New(),
+			WalletID:  walletID,
+			Type:      wallet.OperationInterest,
+			Amount:    accrual.Posted,
+			Metadata:  wallet.Metadata{"date": day.Format(time.DateOnly)},
+			CreatedAt: end,
+		}
+		return tx.RecordOperation(ctx, op)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if accrued && accrual.Posted > 0 {
+		ws.cache.Delete(ctx, walletID.String())
+		ws.cache.Delete(ctx, ws.interest.FundingWalletID.String())
+		ws.log.Info("Interest credited", "walletID", walletID, "date", day, "amount", accrual.Posted)
+	}
+
+	return accrued, nil
+}
@@ -0,0 +1,299 @@
+package services_test
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	repoModel "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+	"wallet/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRoundingMode_Round(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		amount   *big.Rat
+		mode     services.RoundingMode
+		expected int64
+	}{
+		{amount: big.NewRat(25, 10), mode: services.RoundDown, expected: 2},
+		{amount: big.NewRat(25, 10), mode: services.RoundHalfUp, expected: 3},
+		{amount: big.NewRat(25, 10), mode: services.RoundHalfEven, expected: 2},
+		{amount: big.NewRat(35, 10), mode: services.RoundHalfEven, expected: 4},
+		{amount: big.NewRat(26, 10), mode: services.RoundHalfEven, expected: 3},
+		{amount: big.NewRat(24, 10), mode: services.RoundHalfUp, expected: 2},
+		{amount: big.NewRat(29, 10), mode: services.RoundDown, expected: 2},
+		{amount: big.NewRat(7, 1), mode: services.RoundHalfUp, expected: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode)+" "+tt.amount.FloatString(1), func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tt.expected, tt.mode.Round(tt.amount))
+		})
+	}
+}
+
+func TestInterestProduct_DailyInterest(t *testing.T) {
+	t.Parallel()
+
+	product := services.InterestProduct{Name: "savings", APR: "3.65"}
+
+	require.Zero(t, product.DailyInterest(1_000_000).Cmp(big.NewRat(100, 1)))
+	require.Zero(t, product.DailyInterest(-500).Sign())
+	require.Zero(t, product.DailyInterest(1).Cmp(big.NewRat(1, 10000)))
+}
+
+func TestLoadInterestProducts(t *testing.T) {
+	t.Parallel()
+
+	fundingWalletID := uuid.New()
+	path := filepath.Join(t.TempDir(), "interest.json")
+	err := os.WriteFile(path, []byte(`{
+		"fundingWalletId": "`+fundingWalletID.String()+`",
+		"products": [{"name": "savings", "apr": "4.5", "compounding": "MONTHLY", "rounding": "HALF_EVEN"}]
+	}`), 0o600)
+	require.NoError(t, err)
+
+	products, err := services.LoadInterestProducts(path)
+	require.NoError(t, err)
+	require.Equal(t, fundingWalletID, products.FundingWalletID)
+
+	product, ok := products.Product("savings")
+	require.True(t, ok)
+	require.Equal(t, services.InterestProduct{Name: "savings", APR: "4.5", Compounding: services.CompoundMonthly, Rounding: services.RoundHalfEven}, product)
+
+	for _, invalid := range []services.InterestProducts{
+		{Products: []services.InterestProduct{{Name: "savings", APR: "4.5", Compounding: services.CompoundDaily, Rounding: services.RoundDown}}},
+		{FundingWalletID: fundingWalletID, Products: []services.InterestProduct{{Name: "savings", APR: "-1", Compounding: services.CompoundDaily, Rounding: services.RoundDown}}},
+		{FundingWalletID: fundingWalletID, Products: []services.InterestProduct{{Name: "savings", APR: "4.5", Compounding: "YEARLY", Rounding: services.RoundDown}}},
+		{FundingWalletID: fundingWalletID, Products: []services.InterestProduct{{Name: "savings", APR: "4.5", Compounding: services.CompoundDaily, Rounding: "UP"}}},
+	} {
+		require.Error(t, invalid.Validate())
+	}
+}
+
+func TestWalletService_AccrueInterest(t *testing.T) {
+	t.Parallel()
+
+	fundingWalletID := uuid.New()
+	walletID := uuid.New()
+
+	tests := []struct {
+		name           string
+		compounding    services.Compounding
+		date           time.Time
+		earlier        *big.Rat
+		recorded       bool
+		expectedPosted int64
+		expectedCount  int
+	}{
+		{
+			name:           "daily",
+			compounding:    services.CompoundDaily,
+			date:           time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC),
+			recorded:       true,
+			expectedPosted: 100,
+			expectedCount:  1,
+		},
+		{
+			name:          "monthly, within the month",
+			compounding:   services.CompoundMonthly,
+			date:          time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+			recorded:      true,
+			expectedCount: 1,
+		},
+		{
+			name:           "monthly, last day of the month",
+			compounding:    services.CompoundMonthly,
+			date:           time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+			earlier:        big.NewRat(30005, 10),
+			recorded:       true,
+			expectedPosted: 3101,
+			expectedCount:  1,
+		},
+		{
+			name:           "already accrued",
+			compounding:    services.CompoundDaily,
+			date:           time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+			expectedPosted: 100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWalletStorage(ctrl)
+			cache := mocks.NewMockWalletCache(ctrl)
+			tx := mocks.NewMockTx(ctrl)
+
+			products := &services.InterestProducts{
+				FundingWalletID: fundingWalletID,
+				Products: []services.InterestProduct{
+					{Name: "savings", APR: "3.65", Compounding: tt.compounding, Rounding: services.RoundHalfUp},
+				},
+			}
+			service := services.NewWalletService(repo, cache, slog.Default(), services.WithInterestProducts(products))
+
+			day := tt.date.Truncate(24 * time.Hour)
+			end := day.AddDate(0, 0, 1)
+
+			repo.EXPECT().
+				InterestAccounts(gomock.Any()).
+				Return([]wallet.InterestAccount{{WalletID: walletID, Product: "savings"}, {WalletID: uuid.New(), Product: "retired"}}, nil)
+			repo.EXPECT().
+				WithinTx(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+					return fn(ctx, tx)
+				})
+
+			tx.EXPECT().
+				BucketBalanceAt(gomock.Any(), walletID, wallet.BucketMain, end).
+				Return(int64(1_000_000), nil)
+			if tt.earlier != nil {
+				tx.EXPECT().
+					AccruedInterest(gomock.Any(), walletID, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), day).
+					Return(tt.earlier, nil)
+			}
+			tx.EXPECT().
+				RecordAccrual(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, a wallet.Accrual) (bool, error) {
+					require.Equal(t, walletID, a.WalletID)
+					require.Equal(t, day, a.Date)
+					require.Equal(t, int64(1_000_000), a.Balance)
+					require.Zero(t, a.Amount.Cmp(big.NewRat(100, 1)))
+					require.Equal(t, tt.expectedPosted, a.Posted)
+					return tt.recorded, nil
+				})
+
+			if tt.recorded && tt.expectedPosted > 0 {
+				tx.EXPECT().
+					PostInterest(gomock.Any(), walletID, fundingWalletID, tt.expectedPosted, end).
+					Return(nil)
+				tx.EXPECT().
+					RecordOperation(gomock.Any(), gomock.Cond(func(op wallet.OperationRecord) bool {
+						return op.WalletID == walletID && op.Type == wallet.OperationInterest &&
+							op.Amount == tt.expectedPosted && op.CreatedAt.Equal(end)
+					}))
+				cache.EXPECT().Delete(gomock.Any(), walletID.String())
+				cache.EXPECT().Delete(gomock.Any(), fundingWalletID.String())
+			}
+
+			count, err := service.AccrueInterest(t.Context(), tt.date)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedCount, count)
+		})
+	}
+}
+
+func TestWalletService_AccrueInterest_CatchUp(t *testing.T) {
+	t.Parallel()
+
+	fundingWalletID := uuid.New()
+	walletID := uuid.New()
+	lastAccrued := time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)
+	through := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		postError     error // postError fails the second day
+		expectedDays  []int
+		expectedCount int
+	}{
+		{
+			name:          "accrues every missed day in order",
+			expectedDays:  []int{8, 9, 10},
+			expectedCount: 3,
+		},
+		{
+			name:          "stops at the first failed day",
+			postError:     wallet.ErrNotEnoughMoney,
+			expectedDays:  []int{8, 9},
+			expectedCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWalletStorage(ctrl)
+			cache := mocks.NewMockWalletCache(ctrl)
+			tx := mocks.NewMockTx(ctrl)
+
+			products := &services.InterestProducts{
+				FundingWalletID: fundingWalletID,
+				Products: []services.InterestProduct{
+					{Name: "savings", APR: "3.65", Compounding: services.CompoundDaily, Rounding: services.RoundHalfUp},
+				},
+			}
+			service := services.NewWalletService(repo, cache, slog.Default(), services.WithInterestProducts(products))
+
+			repo.EXPECT().
+				InterestAccounts(gomock.Any()).
+				Return([]wallet.InterestAccount{{WalletID: walletID, Product: "savings", LastAccrued: &lastAccrued}}, nil)
+			repo.EXPECT().
+				WithinTx(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+					return fn(ctx, tx)
+				}).
+				Times(len(tt.expectedDays))
+
+			var calls []any
+			for i, d := range tt.expectedDays {
+				day := time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC)
+				end := day.AddDate(0, 0, 1)
+
+				var postError error
+				if i == 1 {
+					postError = tt.postError
+				}
+
+				calls = append(calls,
+					tx.EXPECT().
+						BucketBalanceAt(gomock.Any(), walletID, wallet.BucketMain, end).
+						Return(int64(1_000_000), nil),
+					tx.EXPECT().
+						RecordAccrual(gomock.Any(), gomock.Cond(func(a wallet.Accrual) bool {
+							return a.Date.Equal(day) && a.Posted == 100
+						})).
+						Return(true, nil),
+					tx.EXPECT().
+						PostInterest(gomock.Any(), walletID, fundingWalletID, int64(100), end).
+						Return(postError))
+
+				if postError == nil {
+					calls = append(calls, tx.EXPECT().
+						RecordOperation(gomock.Any(), gomock.Cond(func(op wallet.OperationRecord) bool {
+							return op.Type == wallet.OperationInterest && op.Metadata["date"] == day.Format(time.DateOnly)
+						})))
+					cache.EXPECT().Delete(gomock.Any(), walletID.String())
+					cache.EXPECT().Delete(gomock.Any(), fundingWalletID.String())
+				}
+			}
+			gomock.InOrder(calls...)
+
+			count, err := service.AccrueInterest(t.Context(), through)
+			if tt.postError != nil {
+				require.ErrorIs(t, err, tt.postError)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectedCount, count)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"wallet/internal/model/wallet"
+)
+
+// ListWallets returns a page of wallets matching the filter, oldest first.
+func (ws *WalletService) ListWallets(ctx context.Context, filter wallet.WalletFilter) (wallet.WalletPage, error) {
+	limit := filter.Limit
+
+	// one more wallet tells whether there is a next page
+	filter.Limit++
+	wallets, err := ws.repo.ListWallets(ctx, filter)
+	if err != nil {
+		ws.log.Error("Error listing wallets", "error", err)
+		return wallet.WalletPage{}, err
+	}
+
+	page := wallet.WalletPage{Wallets: wallets}
+	if len(wallets) > limit {
+		page.Wallets = wallets[:limit]
+		last := page.Wallets[limit-1]
+		page.Next = &wallet.WalletCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	return page, nil
+}
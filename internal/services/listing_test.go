@@ -0,0 +1,65 @@
+package services_test
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+	"wallet/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWalletService_ListWallets(t *testing.T) {
+	t.Parallel()
+
+	wallets := make([]wallet.Wallet, 3)
+	for i := range wallets {
+		wallets[i] = wallet.Wallet{ID: uuid.New(), CreatedAt: time.Date(2026, 1, i+1, 0, 0, 0, 0, time.UTC)}
+	}
+
+	tests := []struct {
+		name         string
+		limit        int
+		stored       []wallet.Wallet
+		expectedPage wallet.WalletPage
+	}{
+		{
+			name:   "more wallets follow",
+			limit:  2,
+			stored: wallets,
+			expectedPage: wallet.WalletPage{
+				Wallets: wallets[:2],
+				Next:    &wallet.WalletCursor{CreatedAt: wallets[1].CreatedAt, ID: wallets[1].ID},
+			},
+		},
+		{
+			name:         "last page",
+			limit:        3,
+			stored:       wallets,
+			expectedPage: wallet.WalletPage{Wallets: wallets},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWalletStorage(ctrl)
+			cache := mocks.NewMockWalletCache(ctrl)
+			service := services.NewWalletService(repo, cache, slog.Default())
+
+			repo.EXPECT().
+				ListWallets(gomock.Any(), wallet.WalletFilter{Limit: tt.limit + 1}).
+				Return(tt.stored, nil)
+
+			page, err := service.ListWallets(t.Context(), wallet.WalletFilter{Limit: tt.limit})
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedPage, page)
+		})
+	}
+}
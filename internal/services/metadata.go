@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// SetMetadata replaces the metadata of the wallet.
+func (ws *WalletService) SetMetadata(ctx context.Context, walletID uuid.UUID, metadata wallet.Metadata) error {
+	if err := ws.repo.SetMetadata(ctx, walletID, metadata); err != nil {
+		ws.log.Error("Error setting wallet metadata", "walletID", walletID, "error", err)
+		return err
+	}
+
+	// metadata is cached together with the balance
+	ws.cache.Delete(ctx, walletID.String())
+
+	return nil
+}
+
+// Operations returns the deposits and withdrawals of the wallet matching the filter, newest first.
+func (ws *WalletService) Operations(ctx context.Context, walletID uuid.UUID, filter wallet.OperationFilter) ([]wallet.OperationRecord, error) {
+	ops, err := ws.repo.Operations(ctx, walletID, filter)
+	if err != nil {
+		ws.log.Error("Error listing wallet operations", "walletID", walletID, "error", err)
+		return nil, err
+	}
+
+	return ops, nil
+}
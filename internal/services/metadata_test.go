@@ -0,0 +1,61 @@
+package services_test
+
+import (
+	"log/slog"
+	"testing"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+	"wallet/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWalletService_SetMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		storageError  error
+		expectedError error
+	}{
+		{
+			name: "metadata replaced",
+		},
+		{
+			name:          "wallet not found",
+			storageError:  wallet.ErrWalletNotFound,
+			expectedError: wallet.ErrWalletNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWalletStorage(ctrl)
+			cache := mocks.NewMockWalletCache(ctrl)
+			service := services.NewWalletService(repo, cache, slog.Default())
+
+			walletID := uuid.New()
+			metadata := wallet.Metadata{"customerId": "c-42"}
+
+			repo.EXPECT().
+				SetMetadata(gomock.Any(), walletID, metadata).
+				Return(tt.storageError)
+			if tt.storageError == nil {
+				cache.EXPECT().
+					Delete(gomock.Any(), walletID.String())
+			}
+
+			err := service.SetMetadata(t.Context(), walletID, metadata)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
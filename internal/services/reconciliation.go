@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"time"
+	"wallet/internal/metrics"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// Reconcile checks the stored balance of every wallet and its buckets against the sum
+// of their journal entries, logs every discrepancy and exports the number of mismatched wallets.
+func (ws *WalletService) Reconcile(ctx context.Context) (wallet.Reconciliation, error) {
+	checkedAt := time.Now()
+
+	discrepancies, err := ws.repo.Discrepancies(ctx)
+	if err != nil {
+		ws.log.Error("Error reconciling balances", "error", err)
+		return wallet.Reconciliation{}, err
+	}
+
+	mismatched := make(map[uuid.UUID]struct{})
+	for _, d := range discrepancies {
+		mismatched[d.WalletID] = struct{}{}
+		ws.log.Error("Balance does not match journal", "walletID", d.WalletID, "bucket", d.Bucket, "balance", d.Balance, "journal", d.Journal)
+	}
+
+	metrics.ReconciliationMismatchedWallets.Set(float64(len(mismatched)))
+	metrics.ReconciliationLastRun.Set(float64(checkedAt.Unix()))
+
+	return wallet.Reconciliation{
+		CheckedAt:         checkedAt,
+		MismatchedWallets: len(mismatched),
+		Discrepancies:     discrepancies,
+	}, nil
+}
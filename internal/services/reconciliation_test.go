@@ -0,0 +1,62 @@
+package services_test
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+	"wallet/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWalletService_Reconcile(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+
+	service := services.NewWalletService(repo, cache, slog.Default())
+
+	first, second := uuid.New(), uuid.New()
+	discrepancies := []wallet.Discrepancy{
+		{WalletID: first, Balance: 1000, Journal: 900},
+		{WalletID: first, Bucket: wallet.BucketMain, Balance: 1000, Journal: 900},
+		{WalletID: second, Bucket: "bonus", Balance: 50, Journal: 0},
+	}
+
+	repo.EXPECT().
+		Discrepancies(gomock.Any()).
+		Return(discrepancies, nil)
+
+	report, err := service.Reconcile(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 2, report.MismatchedWallets)
+	require.Equal(t, discrepancies, report.Discrepancies)
+	require.False(t, report.CheckedAt.IsZero())
+}
+
+func TestWalletService_Reconcile_Error(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+
+	service := services.NewWalletService(repo, cache, slog.Default())
+
+	repo.EXPECT().
+		Discrepancies(gomock.Any()).
+		Return(nil, errors.New("db error"))
+
+	_, err := service.Reconcile(t.Context())
+	require.Error(t, err)
+}
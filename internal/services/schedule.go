@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+	repo "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+)
+
+var errUnknownOperation = errors.New("unknown operation")
+
+// CreateSchedule stores a schedule. One-off schedules run at sch.NextRunAt, recurring
+// ones on every occurrence of sch.Recurrence, starting with the next one. Scheduled
+// transfers cannot convert currencies, as no quote would be valid when they run.
+func (ws *WalletService) CreateSchedule(ctx context.Context, sch wallet.Schedule) (wallet.Schedule, error) {
+	now := time.Now()
+
+	if sch.Operation == wallet.OperationTransfer && sch.ToWalletID != nil {
+		if err := ws.sameCurrency(ctx, sch.WalletID, *sch.ToWalletID); err != nil {
+			ws.log.Error("Error creating schedule", "walletID", sch.WalletID, "operation", sch.Operation, "error", err)
+			return wallet.Schedule{}, err
+		}
+	}
+
+	if sch.Recurrence != "" {
+		r, err := ParseRecurrence(sch.Recurrence)
+		if err != nil {
+			return wallet.Schedule{}, err
+		}
+
+		next := r.Next(now)
+		if next.IsZero() {
+			return wallet.Schedule{}, fmt.Errorf("%w: never fires", wallet.ErrInvalidRecurrence)
+		}
+		sch.NextRunAt = &next
+	}
+
+	sch.ID = uuid.New()
+	sch.Status = wallet.ScheduleActive
+	sch.CreatedAt = now
+
+	if err := ws.repo.CreateSchedule(ctx, sch); err != nil {
+		ws.log.Error("Error creating schedule", "walletID", sch.WalletID, "operation", sch.Operation, "error", err)
+		return wallet.Schedule{}, err
+	}
+
+	ws.log.Info("Schedule created", "scheduleID", sch.ID, "walletID", sch.WalletID, "operation", sch.Operation, "nextRunAt", sch.NextRunAt)
+	return sch, nil
+}
+
+// sameCurrency checks that the wallets exist and hold the same currency.
+func (ws *WalletService) sameCurrency(ctx context.Context, fromID, toID uuid.UUID) error {
+	return ws.repo.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+		currencies, err := tx.LockWallets(ctx, fromID, toID)
+		if err != nil {
+			return err
+		}
+
+		if currencies[fromID] != currencies[toID] {
+			return fmt.Errorf("%w: scheduled transfers cannot convert %s to %s",
+				wallet.ErrCurrencyMismatch, currencies[fromID], currencies[toID])
+		}
+
+		return nil
+	})
+}
+
+func (ws *WalletService) ListSchedules(ctx context.Context, walletID uuid.UUID) ([]wallet.Schedule, error) {
+	schedules, err := ws.repo.ListSchedules(ctx, walletID)
+	if err != nil {
+		ws.log.Error("Error listing schedules", "walletID", walletID, "error", err)
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (ws *WalletService) CancelSchedule(ctx context.Context, scheduleID uuid.UUID) error {
+	if err := ws.repo.CancelSchedule(ctx, scheduleID); err != nil {
+		ws.log.Error("Error cancelling schedule", "scheduleID", scheduleID, "error", err)
+		return err
+	}
+
+	ws.log.Info("Schedule cancelled", "scheduleID", scheduleID)
+	return nil
+}
+
+// RunDueSchedules runs every due occurrence and returns the number of occurrences run.
+// Occurrences missed while nothing was running are not replayed: a late schedule runs
+// once and moves on to its next occurrence after now.
+func (ws *WalletService) RunDueSchedules(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		ran, err := ws.runNextSchedule(ctx)
+		if err != nil {
+			ws.log.Error("Error running schedules", "error", err)
+			return total, err
+		}
+
+		if !ran {
+			return total, nil
+		}
+		total++
+	}
+}
+
+// runNextSchedule runs the occurrence that is due the longest. The operation, the run
+// record and the schedule update commit together, so an occurrence is applied at most once.
+// An occurrence whose operation fails is recorded as failed and the schedule moves on, so
+// that one failing schedule does not hold up the others.
+func (ws *WalletService) runNextSchedule(ctx context.Context) (bool, error) {
+	var (
+		sch   *wallet.Schedule
+		fee   int64
+		opErr error
+	)
+
+	err := ws.repo.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+		var err error
+		opErr = nil
+		sch, err = tx.NextDueSchedule(ctx)
+		if err != nil || sch == nil {
+			return err
+		}
+
+		recorded, err := ws.finishOccurrence(ctx, tx, *sch, wallet.ScheduleRun{Status: wallet.RunSucceeded})
+		if err != nil || !recorded {
+			return err
+		}
+
+		fee, opErr = ws.execute(ctx, tx, *sch)
+		return opErr
+	})
+	if sch == nil {
+		return false, err
+	}
+
+	if err != nil {
+		if opErr == nil {
+			return false, err
+		}
+
+		ws.log.Warn("Scheduled operation failed", "scheduleID", sch.ID, "walletID", sch.WalletID, "operation", sch.Operation, "error", err)
+
+		failure := wallet.ScheduleRun{Status: wallet.RunFailed, Error: err.Error()}
+		return true, ws.repo.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+			_, err := ws.finishOccurrence(ctx, tx, *sch, failure)
+			return err
+		})
+	}
+
+	ws.cache.Delete(ctx, sch.WalletID.String())
+	if sch.ToWalletID != nil {
+		ws.cache.Delete(ctx, sch.ToWalletID.String())
+	}
+	ws.feeCharged(ctx, fee)
+	ws.log.Info("Scheduled operation completed", "scheduleID", sch.ID, "walletID", sch.WalletID, "operation", sch.Operation, "amount", sch.Amount)
+
+	return true, nil
+}
+
+// finishOccurrence records the run of the occurrence and moves the schedule on. It returns
+// false when the occurrence had already been recorded.
+func (ws *WalletService) finishOccurrence(ctx context.Context, tx repo.Tx, sch wallet.Schedule, run wallet.ScheduleRun) (bool, error) {
+	run.ScheduleID = sch.ID
+	run.RunAt = *sch.NextRunAt
+
+	recorded, err := tx.RecordRun(ctx, run)
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.AdvanceSchedule(ctx, sch.ID, run.RunAt, nextRun(sch, time.Now())); err != nil {
+		return false, err
+	}
+
+	return recorded, nil
+}
+
+// execute runs the operation of the schedule and returns the fee it charged.
+func (ws *WalletService) execute(ctx context.Context, tx repo.Tx, sch wallet.Schedule) (int64, error) {
+	switch sch.Operation {
+	case wallet.OperationDeposit:
+		receipt, err := ws.deposit(ctx, tx, sch.WalletID, wallet.Credit{Bucket: wallet.BucketMain, Amount: sch.Amount}, scheduleMetadata(sch), nil)
+		return receipt.Fee, err
+	case wallet.OperationWithdraw:
+		receipt, err := ws.withdraw(ctx, tx, sch.WalletID, sch.Amount, scheduleMetadata(sch), nil)
+		return receipt.Fee, err
+	case wallet.OperationTransfer:
+		if sch.ToWalletID == nil {
+			return 0, fmt.Errorf("%w: transfer without destination", errUnknownOperation)
+		}
+		transfer, err := ws.transfer(ctx, tx, sch.WalletID, *sch.ToWalletID, sch.Amount, nil)
+		return transfer.Fee, err
+	default:
+		return 0, fmt.Errorf("%w: %s", errUnknownOperation, sch.Operation)
+	}
+}
+
+// nextRun returns the occurrence following the current one, skipping occurrences
+// before now, or nil when the schedule is done.
+func nextRun(sch wallet.Schedule, now time.Time) *time.Time {
+	if sch.Recurrence == "" {
+		return nil
+	}
+
+	r, err := ParseRecurrence(sch.Recurrence)
+	if err != nil {
+		return nil
+	}
+
+	from := *sch.NextRunAt
+	if now.After(from) {
+		from = now
+	}
+
+	next := r.Next(from)
+	if next.IsZero() {
+		return nil
+	}
+
+	return &next
+}
+
+// scheduleMetadata tags operations run by a schedule with its id.
+func scheduleMetadata(sch wallet.Schedule) wallet.Metadata {
+	return wallet.Metadata{"scheduleId": sch.ID.String()}
+}
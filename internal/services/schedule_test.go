@@ -0,0 +1,276 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+	repoModel "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+	"wallet/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWalletService_CreateSchedule(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+	service := services.NewWalletService(repo, cache, slog.Default())
+
+	walletID := uuid.New()
+
+	repo.EXPECT().
+		CreateSchedule(gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	sch, err := service.CreateSchedule(t.Context(), wallet.Schedule{
+		Operation:  wallet.OperationDeposit,
+		WalletID:   walletID,
+		Amount:     100,
+		Recurrence: "0 9 * * *",
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, uuid.Nil, sch.ID)
+	require.Equal(t, wallet.ScheduleActive, sch.Status)
+	require.NotNil(t, sch.NextRunAt)
+	require.Equal(t, 9, sch.NextRunAt.Hour())
+	require.True(t, sch.NextRunAt.After(time.Now()))
+
+	_, err = service.CreateSchedule(t.Context(), wallet.Schedule{
+		Operation:  wallet.OperationDeposit,
+		WalletID:   walletID,
+		Amount:     100,
+		Recurrence: "0 0 31 2 *",
+	})
+	require.ErrorIs(t, err, wallet.ErrInvalidRecurrence)
+}
+
+func TestWalletService_CreateSchedule_Transfer(t *testing.T) {
+	t.Parallel()
+
+	walletID, toWalletID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name          string
+		toCurrency    string
+		expectedError error
+	}{
+		{
+			name:       "same currency",
+			toCurrency: "USD",
+		},
+		{
+			name:          "cross-currency",
+			toCurrency:    "EUR",
+			expectedError: wallet.ErrCurrencyMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWalletStorage(ctrl)
+			cache := mocks.NewMockWalletCache(ctrl)
+			service := services.NewWalletService(repo, cache, slog.Default())
+
+			tx := mocks.NewMockTx(ctrl)
+			repo.EXPECT().
+				WithinTx(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+					return fn(ctx, tx)
+				})
+			tx.EXPECT().
+				LockWallets(gomock.Any(), walletID, toWalletID).
+				Return(map[uuid.UUID]string{walletID: "USD", toWalletID: tt.toCurrency}, nil)
+
+			if tt.expectedError == nil {
+				repo.EXPECT().
+					CreateSchedule(gomock.Any(), gomock.Any()).
+					Return(nil)
+			}
+
+			_, err := service.CreateSchedule(t.Context(), wallet.Schedule{
+				Operation:  wallet.OperationTransfer,
+				WalletID:   walletID,
+				ToWalletID: &toWalletID,
+				Amount:     100,
+				Recurrence: "0 9 * * *",
+			})
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestWalletService_RunDueSchedules(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+	toWalletID := uuid.New()
+	runAt := time.Now().Add(-time.Minute).Truncate(time.Minute)
+	errStorage := errors.New("connection reset")
+
+	tests := []struct {
+		name     string
+		schedule wallet.Schedule
+		recorded bool
+		setupTx  func(tx *mocks.MockTx)
+		failure  error
+	}{
+		{
+			name:     "one-off deposit",
+			schedule: wallet.Schedule{Operation: wallet.OperationDeposit, WalletID: walletID, Amount: 100},
+			recorded: true,
+			setupTx: func(tx *mocks.MockTx) {
+				tx.EXPECT().
+					Deposit(gomock.Any(), walletID, wallet.Credit{Bucket: wallet.BucketMain, Amount: 100}).
+					Return(int64(100), nil)
+				tx.EXPECT().
+					RecordOperation(gomock.Any(), gomock.Cond(func(op wallet.OperationRecord) bool {
+						return op.Type == wallet.OperationDeposit && op.Amount == 100 && op.Metadata["scheduleId"] != ""
+					}))
+			},
+		},
+		{
+			name:     "recurring transfer",
+			schedule: wallet.Schedule{Operation: wallet.OperationTransfer, WalletID: walletID, ToWalletID: &toWalletID, Amount: 50, Recurrence: "* * * * *"},
+			recorded: true,
+			setupTx: func(tx *mocks.MockTx) {
+				tx.EXPECT().
+					LockWallets(gomock.Any(), walletID, toWalletID).
+					Return(map[uuid.UUID]string{walletID: "USD", toWalletID: "USD"}, nil)
+				tx.EXPECT().
+					Transfer(gomock.Any(), gomock.Any(), services.DefaultWithdrawalOrder).
+					Return(int64(10), nil)
+				tx.EXPECT().
+					RecordOperation(gomock.Any(), gomock.Any()).
+					Times(2)
+			},
+		},
+		{
+			name:     "occurrence already run",
+			schedule: wallet.Schedule{Operation: wallet.OperationWithdraw, WalletID: walletID, Amount: 100},
+			setupTx:  func(tx *mocks.MockTx) {},
+		},
+		{
+			name:     "not enough money",
+			schedule: wallet.Schedule{Operation: wallet.OperationWithdraw, WalletID: walletID, Amount: 100},
+			recorded: true,
+			setupTx: func(tx *mocks.MockTx) {
+				tx.EXPECT().
+					Withdraw(gomock.Any(), walletID, int64(100), services.DefaultWithdrawalOrder).
+					Return(int64(-20), nil)
+			},
+			failure: wallet.ErrNotEnoughMoney,
+		},
+		{
+			name:     "storage error",
+			schedule: wallet.Schedule{Operation: wallet.OperationWithdraw, WalletID: walletID, Amount: 100},
+			recorded: true,
+			setupTx: func(tx *mocks.MockTx) {
+				tx.EXPECT().
+					Withdraw(gomock.Any(), walletID, int64(100), services.DefaultWithdrawalOrder).
+					Return(int64(0), errStorage)
+			},
+			failure: errStorage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWalletStorage(ctrl)
+			cache := mocks.NewMockWalletCache(ctrl)
+			service := services.NewWalletService(repo, cache, slog.Default())
+
+			sch := tt.schedule
+			sch.ID = uuid.New()
+			sch.NextRunAt = &runAt
+
+			due := mocks.NewMockTx(ctrl)
+			idle := mocks.NewMockTx(ctrl)
+			txs := []repoModel.Tx{due, idle}
+			if tt.failure != nil {
+				txs = []repoModel.Tx{due, mocks.NewMockTx(ctrl), idle}
+			}
+
+			repo.EXPECT().
+				WithinTx(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+					tx := txs[0]
+					txs = txs[1:]
+					return fn(ctx, tx)
+				}).
+				Times(len(txs))
+
+			due.EXPECT().NextDueSchedule(gomock.Any()).Return(&sch, nil)
+			due.EXPECT().
+				RecordRun(gomock.Any(), wallet.ScheduleRun{ScheduleID: sch.ID, RunAt: runAt, Status: wallet.RunSucceeded}).
+				Return(tt.recorded, nil)
+			due.EXPECT().
+				AdvanceSchedule(gomock.Any(), sch.ID, runAt, gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ uuid.UUID, _ time.Time, next *time.Time) error {
+					if sch.Recurrence == "" {
+						require.Nil(t, next)
+					} else {
+						require.True(t, next.After(time.Now()))
+					}
+					return nil
+				})
+			tt.setupTx(due)
+
+			if tt.failure != nil {
+				retry := txs[1].(*mocks.MockTx)
+				retry.EXPECT().
+					RecordRun(gomock.Any(), wallet.ScheduleRun{ScheduleID: sch.ID, RunAt: runAt, Status: wallet.RunFailed, Error: tt.failure.Error()}).
+					Return(true, nil)
+				retry.EXPECT().
+					AdvanceSchedule(gomock.Any(), sch.ID, runAt, nil).
+					Return(nil)
+			} else {
+				cache.EXPECT().Delete(gomock.Any(), walletID.String())
+				if sch.ToWalletID != nil {
+					cache.EXPECT().Delete(gomock.Any(), toWalletID.String())
+				}
+			}
+
+			idle.EXPECT().NextDueSchedule(gomock.Any()).Return(nil, nil)
+
+			ran, err := service.RunDueSchedules(t.Context())
+			require.NoError(t, err)
+			require.Equal(t, 1, ran)
+		})
+	}
+}
+
+func TestWalletService_RunDueSchedules_TransientError(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+	service := services.NewWalletService(repo, cache, slog.Default())
+
+	repo.EXPECT().
+		WithinTx(gomock.Any(), gomock.Any()).
+		Return(wallet.ErrTooManyConflicts)
+
+	ran, err := service.RunDueSchedules(t.Context())
+	require.ErrorIs(t, err, wallet.ErrTooManyConflicts)
+	require.Zero(t, ran)
+}
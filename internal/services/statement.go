@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"time"
+	"wallet/internal/model/wallet"
+
+	"github.com/google/uuid"
+)
+
+// Statement writes the wallet statement for [from, to) to w as it is read: the balance at
+// from, every journal entry of the period with the running balance, and the balance at to.
+func (ws *WalletService) Statement(ctx context.Context, walletID uuid.UUID, from, to time.Time, w wallet.StatementWriter) error {
+	var balance int64
+
+	err := ws.repo.Statement(ctx, walletID, from, to,
+		func(opening int64) error {
+			balance = opening
+			return w.Opening(opening)
+		},
+		func(e wallet.Entry) error {
+			balance += e.Amount
+			return w.Line(wallet.StatementLine{Entry: e, Balance: balance})
+		},
+	)
+	if err != nil {
+		ws.log.Error("Error reading statement", "walletID", walletID, "error", err)
+		return err
+	}
+
+	return w.Closing(balance)
+}
@@ -0,0 +1,82 @@
+package services_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+	"wallet/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// recordingStatement keeps everything written to it.
+type recordingStatement struct {
+	opening, closing int64
+	lines            []wallet.StatementLine
+}
+
+func (s *recordingStatement) Opening(balance int64) error {
+	s.opening = balance
+	return nil
+}
+
+func (s *recordingStatement) Line(line wallet.StatementLine) error {
+	s.lines = append(s.lines, line)
+	return nil
+}
+
+func (s *recordingStatement) Closing(balance int64) error {
+	s.closing = balance
+	return nil
+}
+
+func TestWalletService_Statement(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+
+	service := services.NewWalletService(repo, cache, slog.Default())
+
+	walletID := uuid.New()
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	entries := []wallet.Entry{
+		{ID: 1, Type: wallet.EntryDeposit, Bucket: wallet.BucketMain, Amount: 100},
+		{ID: 2, Type: wallet.EntryWithdraw, Bucket: wallet.BucketMain, Amount: -30},
+		{ID: 3, Type: wallet.EntryFee, Bucket: wallet.BucketMain, Amount: -5},
+	}
+
+	repo.EXPECT().
+		Statement(gomock.Any(), walletID, from, to, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, _, _ time.Time, opening func(int64) error, entry func(wallet.Entry) error) error {
+			if err := opening(500); err != nil {
+				return err
+			}
+			for _, e := range entries {
+				if err := entry(e); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	statement := &recordingStatement{}
+	require.NoError(t, service.Statement(t.Context(), walletID, from, to, statement))
+
+	require.Equal(t, int64(500), statement.opening)
+	require.Equal(t, []wallet.StatementLine{
+		{Entry: entries[0], Balance: 600},
+		{Entry: entries[1], Balance: 570},
+		{Entry: entries[2], Balance: 565},
+	}, statement.lines)
+	require.Equal(t, int64(565), statement.closing)
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"math/big"
+	"time"
+	repo "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+)
+
+// Quote locks the current exchange rate between two currencies for the configured TTL.
+func (ws *WalletService) Quote(ctx context.Context, from, to string) (wallet.Quote, error) {
+	if ws.rates == nil {
+		return wallet.Quote{}, wallet.ErrRateNotFound
+	}
+
+	rate, err := ws.rates.Rate(ctx, from, to)
+	if err != nil {
+		ws.log.Error("Error fetching exchange rate", "from", from, "to", to, "error", err)
+		return wallet.Quote{}, err
+	}
+
+	quote := wallet.Quote{
+		ID:           uuid.New(),
+		FromCurrency: from,
+		ToCurrency:   to,
+		Rate:         rate,
+		ExpiresAt:    time.Now().Add(ws.quoteTTL),
+	}
+	if err := ws.repo.SaveQuote(ctx, quote); err != nil {
+		ws.log.Error("Error saving quote", "from", from, "to", to, "error", err)
+		return wallet.Quote{}, err
+	}
+
+	return quote, nil
+}
+
+// Transfer moves amount from one wallet to another. Transfers between wallets of
+// different currencies need a quote for exactly that currency pair; the amount is
+// converted at the quoted rate, rounding down, and the quote is used up.
+func (ws *WalletService) Transfer(ctx context.Context, fromID, toID uuid.UUID, amount int64, quoteID *uuid.UUID) (wallet.Transfer, error) {
+	if fromID == toID {
+		return wallet.Transfer{}, wallet.ErrSameWallet
+	}
+
+	var transfer wallet.Transfer
+
+	err := ws.repo.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+		var err error
+		transfer, err = ws.transfer(ctx, tx, fromID, toID, amount, quoteID)
+		return err
+	})
+	if err != nil {
+		ws.log.Error("Error during transfer", "from", fromID, "to", toID, "amount", amount, "error", err)
+		return wallet.Transfer{}, err
+	}
+
+	ws.cache.Delete(ctx, fromID.String())
+	ws.cache.Delete(ctx, toID.String())
+	ws.feeCharged(ctx, transfer.Fee)
+	ws.log.Info("Transfer completed", "transferID", transfer.ID, "from", fromID, "to", toID,
+		"debit", transfer.DebitAmount, "credit", transfer.CreditAmount, "rate", transfer.Rate.FloatString(6))
+	return transfer, nil
+}
+
+func (ws *WalletService) transfer(ctx context.Context, tx repo.Tx, fromID, toID uuid.UUID, amount int64, quoteID *uuid.UUID) (wallet.Transfer, error) {
+	currencies, err := tx.LockWallets(ctx, fromID, toID)
+	if err != nil {
+		return wallet.Transfer{}, err
+	}
+
+	transfer := wallet.Transfer{
+		ID:           uuid.New(),
+		FromWalletID: fromID,
+		ToWalletID:   toID,
+		FromCurrency: currencies[fromID],
+		ToCurrency:   currencies[toID],
+		DebitAmount:  amount,
+	}
+
+	if err := ws.applyRate(ctx, tx, &transfer, quoteID); err != nil {
+		return wallet.Transfer{}, err
+	}
+
+	balance, err := tx.Transfer(ctx, transfer, ws.withdrawalOrder)
+	if err != nil {
+		return wallet.Transfer{}, err
+	}
+
+	if balance < 0 {
+		ws.log.Error("Insufficient funds", "walletID", fromID, "amount", amount, "balance", balance)
+		return wallet.Transfer{}, wallet.ErrNotEnoughMoney
+	}
+
+	transfer.Fee, err = ws.chargeFee(ctx, tx, fromID, wallet.OperationTransfer, amount)
+	if err != nil {
+		return wallet.Transfer{}, err
+	}
+
+	return transfer, recordTransfer(ctx, tx, transfer)
+}
+
+// recordTransfer records both sides of the transfer in the history of their wallets.
+func recordTransfer(ctx context.Context, tx repo.Tx, t wallet.Transfer) error {
+	now := time.Now().UTC()
+
+	out := wallet.OperationRecord{
+		ID:        t.ID,
+		WalletID:  t.FromWalletID,
+		Type:      wallet.OperationTransferOut,
+		Amount:    t.DebitAmount,
+		Fee:       t.Fee,
+		Metadata:  wallet.Metadata{"transferId": t.ID.String(), "toWalletId": t.ToWalletID.String()},
+		CreatedAt: now,
+	}
+	if err := tx.RecordOperation(ctx, out); err != nil {
+		return err
+	}
+
+	in := wallet.OperationRecord{
+		ID:        uuid.New(),
+		WalletID:  t.ToWalletID,
+		Type:      wallet.OperationTransferIn,
+		Amount:    t.CreditAmount,
+		Metadata:  wallet.Metadata{"transferId": t.ID.String(), "fromWalletId": t.FromWalletID.String()},
+		CreatedAt: now,
+	}
+	return tx.RecordOperation(ctx, in)
+}
+
+// applyRate sets the rate and the credited amount of the transfer.
+func (ws *WalletService) applyRate(ctx context.Context, tx repo.Tx, t *wallet.Transfer, quoteID *uuid.UUID) error {
+	if t.FromCurrency == t.ToCurrency {
+		if quoteID != nil {
+			return wallet.ErrCurrencyMismatch
+		}
+		t.Rate = big.NewRat(1, 1)
+		t.CreditAmount = t.DebitAmount
+		return nil
+	}
+
+	if quoteID == nil {
+		return wallet.ErrQuoteRequired
+	}
+
+	quote, err := tx.UseQuote(ctx, *quoteID)
+	if err != nil {
+		return err
+	}
+
+	if quote.FromCurrency != t.FromCurrency || quote.ToCurrency != t.ToCurrency {
+		return wallet.ErrCurrencyMismatch
+	}
+
+	t.QuoteID = quoteID
+	t.Rate = quote.Rate
+	t.CreditAmount, err = convert(t.DebitAmount, quote.Rate, t.FromCurrency, t.ToCurrency)
+	return err
+}
@@ -0,0 +1,223 @@
+package services_test
+
+import (
+	"context"
+	"log/slog"
+	"math/big"
+	"testing"
+	"time"
+	repoModel "wallet/internal/model/repository"
+	"wallet/internal/model/wallet"
+	"wallet/internal/services"
+	"wallet/internal/services/mocks"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWalletService_Transfer(t *testing.T) {
+	t.Parallel()
+
+	fromID, toID := uuid.New(), uuid.New()
+	quoteID := uuid.New()
+
+	tests := []struct {
+		name           string
+		fromCurrency   string
+		toCurrency     string
+		quoteID        *uuid.UUID
+		quote          *wallet.Quote
+		amount         int64
+		expectedCredit int64
+		expectedError  error
+	}{
+		{
+			name:           "same currency",
+			fromCurrency:   "USD",
+			toCurrency:     "USD",
+			amount:         1000,
+			expectedCredit: 1000,
+		},
+		{
+			name:           "converted and rounded down",
+			fromCurrency:   "USD",
+			toCurrency:     "EUR",
+			quoteID:        &quoteID,
+			quote:          &wallet.Quote{ID: quoteID, FromCurrency: "USD", ToCurrency: "EUR", Rate: big.NewRat(92, 100)},
+			amount:         1001,
+			expectedCredit: 920,
+		},
+		{
+			name:           "minor units differ",
+			fromCurrency:   "USD",
+			toCurrency:     "JPY",
+			quoteID:        &quoteID,
+			quote:          &wallet.Quote{ID: quoteID, FromCurrency: "USD", ToCurrency: "JPY", Rate: big.NewRat(15130, 100)},
+			amount:         250,
+			expectedCredit: 378,
+		},
+		{
+			name:          "quote required",
+			fromCurrency:  "USD",
+			toCurrency:    "EUR",
+			amount:        1000,
+			expectedError: wallet.ErrQuoteRequired,
+		},
+		{
+			name:          "quote for another pair",
+			fromCurrency:  "USD",
+			toCurrency:    "EUR",
+			quoteID:       &quoteID,
+			quote:         &wallet.Quote{ID: quoteID, FromCurrency: "USD", ToCurrency: "GBP", Rate: big.NewRat(79, 100)},
+			amount:        1000,
+			expectedError: wallet.ErrCurrencyMismatch,
+		},
+		{
+			name:          "quote with same currency",
+			fromCurrency:  "USD",
+			toCurrency:    "USD",
+			quoteID:       &quoteID,
+			amount:        1000,
+			expectedError: wallet.ErrCurrencyMismatch,
+		},
+		{
+			name:          "converted amount is zero",
+			fromCurrency:  "JPY",
+			toCurrency:    "USD",
+			quoteID:       &quoteID,
+			quote:         &wallet.Quote{ID: quoteID, FromCurrency: "JPY", ToCurrency: "USD", Rate: big.NewRat(66, 10000)},
+			amount:        1,
+			expectedError: wallet.ErrAmountTooSmall,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWalletStorage(ctrl)
+			cache := mocks.NewMockWalletCache(ctrl)
+			tx := mocks.NewMockTx(ctrl)
+			service := services.NewWalletService(repo, cache, slog.Default())
+
+			repo.EXPECT().
+				WithinTx(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+					return fn(ctx, tx)
+				})
+			tx.EXPECT().
+				LockWallets(gomock.Any(), fromID, toID).
+				Return(map[uuid.UUID]string{fromID: tt.fromCurrency, toID: tt.toCurrency}, nil)
+			if tt.quote != nil {
+				tx.EXPECT().
+					UseQuote(gomock.Any(), quoteID).
+					Return(*tt.quote, nil)
+			}
+
+			if tt.expectedError == nil {
+				tx.EXPECT().
+					Transfer(gomock.Any(), gomock.Any(), services.DefaultWithdrawalOrder).
+					DoAndReturn(func(_ context.Context, transfer wallet.Transfer, _ []string) (int64, error) {
+						require.Equal(t, tt.amount, transfer.DebitAmount)
+						require.Equal(t, tt.expectedCredit, transfer.CreditAmount)
+						return 0, nil
+					})
+				tx.EXPECT().
+					RecordOperation(gomock.Any(), gomock.Cond(func(op wallet.OperationRecord) bool {
+						return op.WalletID == fromID && op.Type == wallet.OperationTransferOut && op.Amount == tt.amount
+					}))
+				tx.EXPECT().
+					RecordOperation(gomock.Any(), gomock.Cond(func(op wallet.OperationRecord) bool {
+						return op.WalletID == toID && op.Type == wallet.OperationTransferIn && op.Amount == tt.expectedCredit &&
+							op.Metadata["fromWalletId"] == fromID.String()
+					}))
+				cache.EXPECT().Delete(gomock.Any(), fromID.String())
+				cache.EXPECT().Delete(gomock.Any(), toID.String())
+			}
+
+			transfer, err := service.Transfer(t.Context(), fromID, toID, tt.amount, tt.quoteID)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.fromCurrency, transfer.FromCurrency)
+			require.Equal(t, tt.toCurrency, transfer.ToCurrency)
+			require.Equal(t, tt.expectedCredit, transfer.CreditAmount)
+		})
+	}
+}
+
+func TestWalletService_Transfer_NotEnoughMoney(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+	tx := mocks.NewMockTx(ctrl)
+	service := services.NewWalletService(repo, cache, slog.Default())
+
+	fromID, toID := uuid.New(), uuid.New()
+
+	repo.EXPECT().
+		WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+			return fn(ctx, tx)
+		})
+	tx.EXPECT().
+		LockWallets(gomock.Any(), fromID, toID).
+		Return(map[uuid.UUID]string{fromID: "USD", toID: "USD"}, nil)
+	tx.EXPECT().
+		Transfer(gomock.Any(), gomock.Any(), services.DefaultWithdrawalOrder).
+		Return(int64(-1), nil)
+
+	_, err := service.Transfer(t.Context(), fromID, toID, 100, nil)
+	require.ErrorIs(t, err, wallet.ErrNotEnoughMoney)
+
+	_, err = service.Transfer(t.Context(), fromID, fromID, 100, nil)
+	require.ErrorIs(t, err, wallet.ErrSameWallet)
+}
+
+func TestWalletService_Quote(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+
+	_, err := services.NewWalletService(repo, cache, slog.Default()).Quote(t.Context(), "USD", "EUR")
+	require.ErrorIs(t, err, wallet.ErrRateNotFound)
+
+	rates := staticRates{"USD/EUR": big.NewRat(92, 100)}
+	service := services.NewWalletService(repo, cache, slog.Default(), services.WithRateProvider(rates, 30*time.Second))
+
+	repo.EXPECT().
+		SaveQuote(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, q wallet.Quote) error {
+			require.Equal(t, "USD", q.FromCurrency)
+			require.Equal(t, "EUR", q.ToCurrency)
+			require.Zero(t, q.Rate.Cmp(big.NewRat(92, 100)))
+			require.WithinDuration(t, time.Now().Add(30*time.Second), q.ExpiresAt, time.Second)
+			return nil
+		})
+
+	quote, err := service.Quote(t.Context(), "USD", "EUR")
+	require.NoError(t, err)
+	require.NotEqual(t, uuid.Nil, quote.ID)
+
+	_, err = service.Quote(t.Context(), "USD", "GBP")
+	require.ErrorIs(t, err, wallet.ErrRateNotFound)
+}
+
+type staticRates map[string]*big.Rat
+
+func (r staticRates) Rate(_ context.Context, from, to string) (*big.Rat, error) {
+	if rate, ok := r[from+"/"+to]; ok {
+		return rate, nil
+	}
+
+	return nil, wallet.ErrRateNotFound
+}
@@ -2,99 +2,441 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
 	"log/slog"
+	"time"
+	repo "wallet/internal/model/repository"
 	"wallet/internal/model/wallet"
 )
 
+// expiryBatchSize bounds the number of credits expired in one transaction.
+const expiryBatchSize = 100
+
+// DefaultSpendingOrder spends promotional credit before real money on fees.
+var DefaultSpendingOrder = []string{"bonus", "cashback", wallet.BucketMain}
+
+// DefaultWithdrawalOrder withdraws and transfers real money only: promotional credit cannot
+// leave the wallet.
+var DefaultWithdrawalOrder = []string{wallet.BucketMain}
+
 type WalletStorage interface {
-	BeginTx(ctx context.Context, opts pgx.TxOptions) (pgx.Tx, error)                          // BeginTx starts a new database transaction
-	Deposit(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, amount int64) (int64, error)  // Deposit returns updated balance
-	Withdraw(ctx context.Context, tx pgx.Tx, walletID uuid.UUID, amount int64) (int64, error) // Withdraw returns updated balance
-	GetBalance(ctx context.Context, walletID uuid.UUID) (int64, error)                        // GetBalance returns balance
+	repo.UnitOfWork                                                             // WithinTx runs operations in a single transaction
+	GetBalance(ctx context.Context, walletID uuid.UUID) (wallet.Balance, error) // GetBalance returns balance with its bucket breakdown
+	SaveQuote(ctx context.Context, quote wallet.Quote) error                    // SaveQuote stores an exchange rate quote
+	CreateSchedule(ctx context.Context, schedule wallet.Schedule) error
+	ListSchedules(ctx context.Context, walletID uuid.UUID) ([]wallet.Schedule, error)
+	CancelSchedule(ctx context.Context, scheduleID uuid.UUID) error
+	InterestAccounts(ctx context.Context) ([]wallet.InterestAccount, error)
+	BalanceAt(ctx context.Context, walletID uuid.UUID, at time.Time) (wallet.Balance, error) // BalanceAt returns balance from journal entries before at
+	TakeSnapshot(ctx context.Context, at time.Time) (int64, error)                           // TakeSnapshot stores balances at the given time
+	Discrepancies(ctx context.Context) ([]wallet.Discrepancy, error)                         // Discrepancies returns balances that differ from the journal
+	SetMetadata(ctx context.Context, walletID uuid.UUID, metadata wallet.Metadata) error     // SetMetadata replaces the wallet metadata
+	Operations(ctx context.Context, walletID uuid.UUID, filter wallet.OperationFilter) ([]wallet.OperationRecord, error)
+	ListWallets(ctx context.Context, filter wallet.WalletFilter) ([]wallet.Wallet, error)
+	CreateWallet(ctx context.Context, w wallet.Wallet) (wallet.Wallet, error)
+	SetStatus(ctx context.Context, walletID uuid.UUID, status wallet.Status) error
+	AdjustmentRequests(ctx context.Context, status wallet.AdjustmentStatus, limit int) ([]wallet.AdjustmentRequest, error)
+	// AdjustmentRequest returns the request with its audit trail
+	AdjustmentRequest(ctx context.Context, requestID uuid.UUID) (wallet.AdjustmentRequest, []wallet.AdjustmentEvent, error)
+	// BalanceEvents returns the balance changes of the wallet after afterVersion, oldest first
+	BalanceEvents(ctx context.Context, walletID uuid.UUID, afterVersion int64, limit int) ([]wallet.BalanceEvent, error)
+	// Statement reads the balance at from, then the journal entries in [from, to)
+	Statement(ctx context.Context, walletID uuid.UUID, from, to time.Time, opening func(balance int64) error, entry func(e wallet.Entry) error) error
 }
 
 type WalletCache interface {
-	Get(ctx context.Context, key string) (int64, bool)
-	Set(ctx context.Context, key string, balance int64)
+	Get(ctx context.Context, key string) (wallet.Balance, bool)
+	Set(ctx context.Context, key string, balance wallet.Balance)
 	Delete(ctx context.Context, key string)
 }
 
 type WalletService struct {
-	log   *slog.Logger
-	repo  WalletStorage
-	cache WalletCache
+	log             *slog.Logger
+	repo            WalletStorage
+	cache           WalletCache
+	batcher         *DepositBatcher
+	spendingOrder   []string
+	withdrawalOrder []string
+	fees            *FeeSchedule
+	rates           RateProvider
+	quoteTTL        time.Duration
+	interest        *InterestProducts
+	events          *EventHub
+}
+
+type Option func(*WalletService)
+
+// WithDepositBatcher routes deposits into hot wallets through b.
+func WithDepositBatcher(b *DepositBatcher) Option {
+	return func(ws *WalletService) {
+		ws.batcher = b
+	}
+}
+
+// WithSpendingOrder overrides DefaultSpendingOrder, the order in which fees spend buckets.
+func WithSpendingOrder(order []string) Option {
+	return func(ws *WalletService) {
+		ws.spendingOrder = order
+	}
+}
+
+// WithWithdrawalOrder overrides DefaultWithdrawalOrder, the buckets withdrawals and transfers
+// spend, in the order they are spent. Buckets missing from order cannot be withdrawn.
+func WithWithdrawalOrder(order []string) Option {
+	return func(ws *WalletService) {
+		ws.withdrawalOrder = order
+	}
+}
+
+// WithFeeSchedule charges fees from the schedule on every operation it has rules for.
+func WithFeeSchedule(s *FeeSchedule) Option {
+	return func(ws *WalletService) {
+		ws.fees = s
+	}
 }
 
-func NewWalletService(repo WalletStorage, cache WalletCache, log *slog.Logger) *WalletService {
-	return &WalletService{
-		repo:  repo,
-		cache: cache,
-		log:   log,
+// WithRateProvider enables quotes for transfers between currencies. Quotes are valid for ttl.
+func WithRateProvider(p RateProvider, ttl time.Duration) Option {
+	return func(ws *WalletService) {
+		ws.rates = p
+		ws.quoteTTL = ttl
 	}
 }
 
-func (ws *WalletService) Deposit(ctx context.Context, walletID uuid.UUID, amount int64) error {
-	tx, err := ws.repo.BeginTx(ctx, pgx.TxOptions{
-		IsoLevel: pgx.RepeatableRead,
+// WithInterestProducts enables interest accrual for wallets enrolled in the products.
+func WithInterestProducts(p *InterestProducts) Option {
+	return func(ws *WalletService) {
+		ws.interest = p
+	}
+}
+
+// WithEventHub enables balance event streams served from the hub.
+func WithEventHub(h *EventHub) Option {
+	return func(ws *WalletService) {
+		ws.events = h
+	}
+}
+
+func NewWalletService(repo WalletStorage, cache WalletCache, log *slog.Logger, opts ...Option) *WalletService {
+	ws := &WalletService{
+		repo:            repo,
+		cache:           cache,
+		log:             log,
+		spendingOrder:   DefaultSpendingOrder,
+		withdrawalOrder: DefaultWithdrawalOrder,
+	}
+	for _, opt := range opts {
+		opt(ws)
+	}
+
+	if ws.events != nil {
+		// balance changes committed by other processes, such as walletctl, drop cached balances too
+		ws.events.observe(func(ev wallet.BalanceEvent) {
+			ws.cache.Delete(context.Background(), ev.WalletID.String())
+		})
+	}
+
+	return ws
+}
+
+// Deposit credits a bucket of the wallet and records the deposit with its metadata. With an
+// expectedVersion, it fails with ErrVersionMismatch unless the wallet is still at that version.
+func (ws *WalletService) Deposit(ctx context.Context, walletID uuid.UUID, credit wallet.Credit, metadata wallet.Metadata, expectedVersion *int64) (wallet.Receipt, error) {
+	if ws.batchable(walletID, credit, expectedVersion) {
+		return ws.depositHot(ctx, walletID, credit.Amount, metadata)
+	}
+
+	var receipt wallet.Receipt
+
+	err := ws.repo.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+		var err error
+		receipt, err = ws.deposit(ctx, tx, walletID, credit, metadata, expectedVersion)
+		return err
 	})
 	if err != nil {
-		ws.log.Error("Error starting transaction", "walletID", walletID, "amount", amount, "error", err)
-		return err
+		ws.log.Error("Error during deposit", "walletID", walletID, "bucket", credit.Bucket, "amount", credit.Amount, "error", err)
+		return wallet.Receipt{}, err
+	}
+
+	ws.cache.Delete(ctx, walletID.String())
+	ws.feeCharged(ctx, receipt.Fee)
+
+	return receipt, nil
+}
+
+func (ws *WalletService) deposit(ctx context.Context, tx repo.Tx, walletID uuid.UUID, credit wallet.Credit, metadata wallet.Metadata, expectedVersion *int64) (wallet.Receipt, error) {
+	if err := checkVersion(ctx, tx, walletID, expectedVersion); err != nil {
+		return wallet.Receipt{}, err
 	}
-	defer tx.Rollback(ctx)
 
-	balance, err := ws.repo.Deposit(ctx, tx, walletID, amount)
+	balance, err := tx.Deposit(ctx, walletID, credit)
 	if err != nil {
-		ws.log.Error("Error during deposit", "walletID", walletID, "amount", amount, "error", err)
-		return err
+		return wallet.Receipt{}, err
 	}
 
-	err = tx.Commit(ctx)
+	fee, err := ws.chargeFee(ctx, tx, walletID, wallet.OperationDeposit, credit.Amount)
 	if err != nil {
-		ws.log.Error("Error committing transaction", "walletID", walletID, "amount", amount, "error", err)
-		return err
+		return wallet.Receipt{}, err
 	}
 
-	ws.cache.Set(ctx, walletID.String(), balance)
+	op := wallet.OperationRecord{
+		ID:        uuid.New(),
+		WalletID:  walletID,
+		Type:      wallet.OperationDeposit,
+		Amount:    credit.Amount,
+		Fee:       fee,
+		Metadata:  metadata,
+		CreatedAt: time.Now().UTC(),
+	}
+	return receipt(op, balance-fee), tx.RecordOperation(ctx, op)
+}
+
+// batchable reports whether the deposit can go through the batcher. Batches only
+// credit the main bucket, never charge fees and cannot check the wallet version.
+func (ws *WalletService) batchable(walletID uuid.UUID, credit wallet.Credit, expectedVersion *int64) bool {
+	if ws.batcher == nil || credit.Bucket != wallet.BucketMain || credit.ExpiresAt != nil || expectedVersion != nil {
+		return false
+	}
 
-	return nil
+	if ws.fees != nil && ws.fees.Charges(wallet.OperationDeposit) {
+		return false
+	}
+
+	return ws.batcher.IsHot(walletID)
 }
 
-func (ws *WalletService) Withdraw(ctx context.Context, walletID uuid.UUID, amount int64) error {
-	tx, err := ws.repo.BeginTx(ctx, pgx.TxOptions{
-		IsoLevel: pgx.RepeatableRead,
-	})
+// depositHot deposits into the main bucket through the batcher.
+func (ws *WalletService) depositHot(ctx context.Context, walletID uuid.UUID, amount int64, metadata wallet.Metadata) (wallet.Receipt, error) {
+	op := wallet.OperationRecord{
+		ID:        uuid.New(),
+		WalletID:  walletID,
+		Type:      wallet.OperationDeposit,
+		Amount:    amount,
+		Metadata:  metadata,
+		CreatedAt: time.Now().UTC(),
+	}
+	balance, err := ws.batcher.Deposit(ctx, op)
 	if err != nil {
-		ws.log.Error("Error starting transaction", "walletID", walletID, "amount", amount, "error", err)
-		return err
+		ws.log.Error("Error during batched deposit", "walletID", walletID, "amount", amount, "error", err)
+		return wallet.Receipt{}, err
 	}
-	defer tx.Rollback(ctx)
 
-	balance, err := ws.repo.Withdraw(ctx, tx, walletID, amount)
+	ws.cache.Delete(ctx, walletID.String())
+
+	return receipt(op, balance), nil
+}
+
+// Withdraw debits the wallet buckets in withdrawal order and records the withdrawal with
+// its metadata; buckets missing from the order, promotional credit by default, are not
+// withdrawable. With an expectedVersion, it fails with ErrVersionMismatch unless the wallet
+// is still at that version.
+func (ws *WalletService) Withdraw(ctx context.Context, walletID uuid.UUID, amount int64, metadata wallet.Metadata, expectedVersion *int64) (wallet.Receipt, error) {
+	var receipt wallet.Receipt
+
+	err := ws.repo.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+		var err error
+		receipt, err = ws.withdraw(ctx, tx, walletID, amount, metadata, expectedVersion)
+		return err
+	})
 	if err != nil {
 		ws.log.Error("Error during withdrawal", "walletID", walletID, "amount", amount, "error", err)
-		return err
+		return wallet.Receipt{}, err
+	}
+
+	ws.cache.Delete(ctx, walletID.String())
+	ws.feeCharged(ctx, receipt.Fee)
+	ws.log.Info("Withdrawal completed", "walletID", walletID, "amount", amount, "fee", receipt.Fee, "newBalance", receipt.Balance)
+	return receipt, nil
+}
+
+func (ws *WalletService) withdraw(ctx context.Context, tx repo.Tx, walletID uuid.UUID, amount int64, metadata wallet.Metadata, expectedVersion *int64) (wallet.Receipt, error) {
+	if err := checkVersion(ctx, tx, walletID, expectedVersion); err != nil {
+		return wallet.Receipt{}, err
+	}
+
+	balance, err := tx.Withdraw(ctx, walletID, amount, ws.withdrawalOrder)
+	if err != nil {
+		return wallet.Receipt{}, err
 	}
 
 	if balance < 0 {
 		ws.log.Error("Insufficient funds", "walletID", walletID, "amount", amount, "balance", balance)
-		return wallet.ErrNotEnoughMoney
+		return wallet.Receipt{}, wallet.ErrNotEnoughMoney
 	}
 
-	err = tx.Commit(ctx)
+	fee, err := ws.chargeFee(ctx, tx, walletID, wallet.OperationWithdraw, amount)
 	if err != nil {
-		ws.log.Error("Error committing transaction", "walletID", walletID, "amount", amount, "error", err)
-		return err
+		return wallet.Receipt{}, err
 	}
 
-	ws.cache.Set(ctx, walletID.String(), balance)
-	ws.log.Info("Withdrawal completed", "walletID", walletID, "amount", amount, "newBalance", balance)
-	return nil
+	op := wallet.OperationRecord{
+		ID:        uuid.New(),
+		WalletID:  walletID,
+		Type:      wallet.OperationWithdraw,
+		Amount:    amount,
+		Fee:       fee,
+		Metadata:  metadata,
+		CreatedAt: time.Now().UTC(),
+	}
+	return receipt(op, balance-fee), tx.RecordOperation(ctx, op)
+}
+
+// checkVersion fails with ErrVersionMismatch when the wallet is not at expectedVersion, if
+// one is given. The wallet stays locked until tx ends, so the version cannot change after
+// the check.
+func checkVersion(ctx context.Context, tx repo.Tx, walletID uuid.UUID, expectedVersion *int64) error {
+	if expectedVersion == nil {
+		return nil
+	}
+
+	return tx.CheckVersion(ctx, walletID, *expectedVersion)
+}
+
+// receipt describes the recorded operation, leaving the wallet with balance.
+func receipt(op wallet.OperationRecord, balance int64) wallet.Receipt {
+	return wallet.Receipt{
+		TransactionID: op.ID,
+		Balance:       balance,
+		Fee:           op.Fee,
+		CreatedAt:     op.CreatedAt,
+	}
 }
 
-func (ws *WalletService) GetBalance(ctx context.Context, walletID uuid.UUID) (int64, error) {
+// chargeFee charges the fee the schedule configures for op, if any, and returns it.
+func (ws *WalletService) chargeFee(ctx context.Context, tx repo.Tx, walletID uuid.UUID, op wallet.Operation, amount int64) (int64, error) {
+	if ws.fees == nil || !ws.fees.Charges(op) || ws.fees.isFeeWallet(walletID) {
+		return 0, nil
+	}
+
+	tier, err := tx.Tier(ctx, walletID)
+	if err != nil {
+		return 0, err
+	}
+
+	fee := ws.fees.Fee(op, tier, amount)
+	if fee == 0 {
+		return 0, nil
+	}
+
+	feeWalletID, err := ws.feeWallet(ctx, tx, walletID)
+	if err != nil {
+		return 0, err
+	}
+
+	balance, err := tx.ChargeFee(ctx, walletID, feeWalletID, fee, ws.spendingOrder)
+	if err != nil {
+		return 0, err
+	}
+
+	if balance < 0 {
+		ws.log.Error("Insufficient funds for fee", "walletID", walletID, "operation", op, "fee", fee, "balance", balance)
+		return 0, wallet.ErrNotEnoughMoney
+	}
+
+	// the charged wallet records the fee with its operation, the fee wallet records the income
+	income := wallet.OperationRecord{
+		ID:       uuid.New(),
+		WalletID: feeWalletID,
+		Type:     wallet.OperationFee,
+		Amount:   fee,
+		Metadata: wallet.Metadata{"walletId": walletID.String(), "operationType": string(op)},
+	}
+	return fee, tx.RecordOperation(ctx, income)
+}
+
+// feeWallet returns the fee-income wallet in the currency of the charged wallet. Fees are
+// posted as computed, without conversion, so a fee wallet of another currency is rejected.
+// A missing or misconfigured fee wallet is the operator's problem, not the client's: it is
+// logged and returned as an internal error that does not name the fee wallet to the client.
+func (ws *WalletService) feeWallet(ctx context.Context, tx repo.Tx, walletID uuid.UUID) (uuid.UUID, error) {
+	currencies, err := tx.LockWallets(ctx, walletID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	currency := currencies[walletID]
+	feeWalletID := ws.fees.feeWallet(currency)
+
+	feeCurrencies, err := tx.LockWallets(ctx, feeWalletID)
+	if err != nil {
+		if errors.Is(err, wallet.ErrWalletNotFound) {
+			ws.log.Error("Fee wallet does not exist", "walletID", walletID, "currency", currency, "feeWalletID", feeWalletID)
+			return uuid.Nil, fmt.Errorf("fee wallet %s does not exist", feeWalletID)
+		}
+		return uuid.Nil, err
+	}
+
+	if feeCurrencies[feeWalletID] != currency {
+		ws.log.Error("Fee wallet currency mismatch", "walletID", walletID, "currency", currency,
+			"feeWalletID", feeWalletID, "feeCurrency", feeCurrencies[feeWalletID])
+		return uuid.Nil, fmt.Errorf("no fee wallet in %s", currency)
+	}
+
+	return feeWalletID, nil
+}
+
+// feeCharged drops the cached balances of the fee-income wallets after a committed operation
+// charged a fee, as the operation only drops the balances of the wallets it names.
+func (ws *WalletService) feeCharged(ctx context.Context, fee int64) {
+	if fee == 0 {
+		return
+	}
+
+	ws.cache.Delete(ctx, ws.fees.FeeWalletID.String())
+	for _, id := range ws.fees.FeeWallets {
+		ws.cache.Delete(ctx, id.String())
+	}
+}
+
+// ExpireDue takes every credit that reached its deadline off its wallet and
+// returns the number of expired credits.
+func (ws *WalletService) ExpireDue(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		var expired []wallet.Expiry
+
+		err := ws.repo.WithinTx(ctx, func(ctx context.Context, tx repo.Tx) error {
+			var err error
+			expired, err = tx.ExpireLots(ctx, expiryBatchSize)
+			if err != nil {
+				return err
+			}
+
+			for _, e := range expired {
+				op := wallet.OperationRecord{
+					ID:       uuid.New(),
+					WalletID: e.WalletID,
+					Type:     wallet.OperationExpire,
+					Amount:   e.Amount,
+					Metadata: wallet.Metadata{"bucket": e.Bucket},
+				}
+				if err := tx.RecordOperation(ctx, op); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			ws.log.Error("Error expiring credits", "error", err)
+			return total, err
+		}
+
+		for _, e := range expired {
+			ws.cache.Delete(ctx, e.WalletID.String())
+			ws.log.Info("Credit expired", "walletID", e.WalletID, "bucket", e.Bucket, "amount", e.Amount)
+		}
+
+		total += len(expired)
+		if len(expired) < expiryBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (ws *WalletService) GetBalance(ctx context.Context, walletID uuid.UUID) (wallet.Balance, error) {
 	balance, ok := ws.cache.Get(ctx, walletID.String())
 	if ok {
 		return balance, nil
@@ -103,7 +445,7 @@ func (ws *WalletService) GetBalance(ctx context.Context, walletID uuid.UUID) (in
 	balance, err := ws.repo.GetBalance(ctx, walletID)
 	if err != nil {
 		ws.log.Error("Error fetching balance from DB", "walletID", walletID, "error", err)
-		return 0, err
+		return wallet.Balance{}, err
 	}
 
 	ws.cache.Set(ctx, walletID.String(), balance)
@@ -1,7 +1,9 @@
 package services_test
 
 import (
+	"context"
 	"errors"
+	repoModel "wallet/internal/model/repository"
 	"wallet/internal/model/wallet"
 
 	"go.uber.org/mock/gomock"
@@ -17,7 +19,7 @@ import (
 
 //go:generate mockgen -destination=mocks/mock_walletstorage.go -package=mocks wallet/internal/services WalletStorage
 //go:generate mockgen -destination=mocks/mock_walletcache.go -package=mocks wallet/internal/services WalletCache
-//go:generate mockgen -destination=mocks/mock_tx.go -package=mocks github.com/jackc/pgx/v5 Tx
+//go:generate mockgen -destination=mocks/mock_tx.go -package=mocks wallet/internal/model/repository Tx
 
 func TestWalletService_Deposit_Success(t *testing.T) {
 	t.Parallel()
@@ -38,25 +40,33 @@ func TestWalletService_Deposit_Success(t *testing.T) {
 	tx := mocks.NewMockTx(ctrl)
 
 	repo.EXPECT().
-		BeginTx(gomock.Any(), gomock.Any()).
-		Return(tx, nil)
+		WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+			return fn(ctx, tx)
+		})
 
-	repo.EXPECT().
-		Deposit(gomock.Any(), tx, walletID, amount).
+	tx.EXPECT().
+		Deposit(gomock.Any(), walletID, wallet.Credit{Bucket: wallet.BucketMain, Amount: amount}).
 		Return(updatedBalance, nil)
 
+	metadata := wallet.Metadata{"customerId": "c-42"}
+	var recorded wallet.OperationRecord
 	tx.EXPECT().
-		Commit(gomock.Any()).
-		Return(nil)
+		RecordOperation(gomock.Any(), gomock.Cond(func(op wallet.OperationRecord) bool {
+			return op.ID != uuid.Nil && op.WalletID == walletID && op.Type == wallet.OperationDeposit &&
+				op.Amount == amount && op.Metadata["customerId"] == "c-42" && !op.CreatedAt.IsZero()
+		})).
+		DoAndReturn(func(_ context.Context, op wallet.OperationRecord) error {
+			recorded = op
+			return nil
+		})
 
 	cache.EXPECT().
-		Set(gomock.Any(), walletID.String(), updatedBalance)
-
-	tx.EXPECT().
-		Rollback(gomock.Any()).AnyTimes()
+		Delete(gomock.Any(), walletID.String())
 
-	err := service.Deposit(t.Context(), walletID, amount)
+	receipt, err := service.Deposit(t.Context(), walletID, wallet.Credit{Bucket: wallet.BucketMain, Amount: amount}, metadata, nil)
 	require.NoError(t, err)
+	require.Equal(t, wallet.Receipt{TransactionID: recorded.ID, Balance: updatedBalance, CreatedAt: recorded.CreatedAt}, receipt)
 }
 
 func TestWalletService_Deposit_DepositError(t *testing.T) {
@@ -77,17 +87,16 @@ func TestWalletService_Deposit_DepositError(t *testing.T) {
 	tx := mocks.NewMockTx(ctrl)
 
 	repo.EXPECT().
-		BeginTx(gomock.Any(), gomock.Any()).
-		Return(tx, nil)
-
-	repo.EXPECT().
-		Deposit(gomock.Any(), tx, walletID, amount).
-		Return(int64(0), errors.New("deposit error"))
+		WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+			return fn(ctx, tx)
+		})
 
 	tx.EXPECT().
-		Rollback(gomock.Any())
+		Deposit(gomock.Any(), walletID, wallet.Credit{Bucket: wallet.BucketMain, Amount: amount}).
+		Return(int64(0), errors.New("deposit error"))
 
-	err := service.Deposit(t.Context(), walletID, amount)
+	_, err := service.Deposit(t.Context(), walletID, wallet.Credit{Bucket: wallet.BucketMain, Amount: amount}, nil, nil)
 	require.Error(t, err)
 }
 
@@ -142,36 +151,131 @@ func TestWalletService_Withdraw(t *testing.T) {
 
 			tx := mocks.NewMockTx(ctrl)
 			repo.EXPECT().
-				BeginTx(gomock.Any(), gomock.Any()).
-				Return(tx, nil)
+				WithinTx(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+					if err := fn(ctx, tx); err != nil {
+						return err
+					}
+					return tt.commitError
+				})
 
-			repo.EXPECT().
-				Withdraw(gomock.Any(), tx, walletID, amount).
+			tx.EXPECT().
+				Withdraw(gomock.Any(), walletID, amount, services.DefaultWithdrawalOrder).
 				Return(tt.withdrawReturn, tt.withdrawError)
 
-			if tt.withdrawError == nil && tt.withdrawReturn >= 0 && tt.commitError == nil {
+			if tt.withdrawError == nil && tt.withdrawReturn >= 0 {
 				tx.EXPECT().
-					Commit(gomock.Any()).
-					Return(nil)
+					RecordOperation(gomock.Any(), gomock.Any())
+			}
 
+			if tt.withdrawError == nil && tt.withdrawReturn >= 0 && tt.commitError == nil {
 				cache.EXPECT().
-					Set(gomock.Any(), walletID.String(), tt.withdrawReturn)
-			} else if tt.withdrawError == nil && tt.withdrawReturn >= 0 {
-				tx.EXPECT().
-					Commit(gomock.Any()).
-					Return(tt.commitError)
+					Delete(gomock.Any(), walletID.String())
 			}
 
-			tx.EXPECT().
-				Rollback(gomock.Any()).AnyTimes()
-
-			err := service.Withdraw(t.Context(), walletID, amount)
+			receipt, err := service.Withdraw(t.Context(), walletID, amount, nil, nil)
 
 			if tt.expectError != nil {
 				require.Error(t, err)
 				require.Equal(t, tt.expectError.Error(), err.Error())
 			} else {
 				require.NoError(t, err)
+				require.Equal(t, tt.withdrawReturn, receipt.Balance)
+				require.NotEqual(t, uuid.Nil, receipt.TransactionID)
+			}
+		})
+	}
+}
+
+func TestWalletService_Withdraw_WithdrawalOrder(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+	tx := mocks.NewMockTx(ctrl)
+
+	order := []string{"cashback", wallet.BucketMain}
+	service := services.NewWalletService(repo, cache, slog.Default(), services.WithWithdrawalOrder(order))
+
+	walletID := uuid.New()
+
+	repo.EXPECT().
+		WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+			return fn(ctx, tx)
+		})
+	tx.EXPECT().
+		Withdraw(gomock.Any(), walletID, int64(50), order).
+		Return(int64(150), nil)
+	tx.EXPECT().
+		RecordOperation(gomock.Any(), gomock.Any())
+	cache.EXPECT().
+		Delete(gomock.Any(), walletID.String())
+
+	receipt, err := service.Withdraw(t.Context(), walletID, 50, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(150), receipt.Balance)
+}
+
+func TestWalletService_Withdraw_ExpectedVersion(t *testing.T) {
+	t.Parallel()
+
+	walletID := uuid.New()
+
+	tests := []struct {
+		name         string
+		versionError error
+	}{
+		{
+			name: "unchanged wallet",
+		},
+		{
+			name:         "changed wallet",
+			versionError: wallet.ErrVersionMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockWalletStorage(ctrl)
+			cache := mocks.NewMockWalletCache(ctrl)
+			tx := mocks.NewMockTx(ctrl)
+			service := services.NewWalletService(repo, cache, slog.Default())
+
+			repo.EXPECT().
+				WithinTx(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+					return fn(ctx, tx)
+				})
+
+			// the version is checked before the wallet is debited
+			check := tx.EXPECT().
+				CheckVersion(gomock.Any(), walletID, int64(7)).
+				Return(tt.versionError)
+
+			if tt.versionError == nil {
+				withdraw := tx.EXPECT().
+					Withdraw(gomock.Any(), walletID, int64(50), services.DefaultWithdrawalOrder).
+					Return(int64(150), nil)
+				gomock.InOrder(check, withdraw)
+				tx.EXPECT().RecordOperation(gomock.Any(), gomock.Any())
+				cache.EXPECT().Delete(gomock.Any(), walletID.String())
+			}
+
+			version := int64(7)
+			receipt, err := service.Withdraw(t.Context(), walletID, 50, nil, &version)
+
+			if tt.versionError != nil {
+				require.ErrorIs(t, err, tt.versionError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, int64(150), receipt.Balance)
 			}
 		})
 	}
@@ -194,24 +298,24 @@ func TestWalletService_GetBalance(t *testing.T) {
 	tests := []struct {
 		name            string
 		cacheHit        bool
-		cacheBalance    int64
-		dbBalance       int64
+		cacheBalance    wallet.Balance
+		dbBalance       wallet.Balance
 		dbError         error
-		expectedBalance int64
+		expectedBalance wallet.Balance
 		expectError     bool
 	}{
 		{
 			name:            "balance from cache",
 			cacheHit:        true,
-			cacheBalance:    500,
-			expectedBalance: 500,
+			cacheBalance:    wallet.Balance{Total: 500, Buckets: map[string]int64{wallet.BucketMain: 500}},
+			expectedBalance: wallet.Balance{Total: 500, Buckets: map[string]int64{wallet.BucketMain: 500}},
 			expectError:     false,
 		},
 		{
 			name:            "balance from db",
 			cacheHit:        false,
-			dbBalance:       300,
-			expectedBalance: 300,
+			dbBalance:       wallet.Balance{Total: 300, Buckets: map[string]int64{wallet.BucketMain: 200, "bonus": 100}},
+			expectedBalance: wallet.Balance{Total: 300, Buckets: map[string]int64{wallet.BucketMain: 200, "bonus": 100}},
 			expectError:     false,
 		},
 		{
@@ -252,3 +356,178 @@ func TestWalletService_GetBalance(t *testing.T) {
 		})
 	}
 }
+
+func TestWalletService_ExpireDue(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+	logger := slog.Default()
+
+	service := services.NewWalletService(repo, cache, logger)
+
+	walletID := uuid.New()
+	tx := mocks.NewMockTx(ctrl)
+
+	repo.EXPECT().
+		WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+			return fn(ctx, tx)
+		})
+
+	tx.EXPECT().
+		ExpireLots(gomock.Any(), gomock.Any()).
+		Return([]wallet.Expiry{{WalletID: walletID, Bucket: "bonus", Amount: 40}}, nil)
+	tx.EXPECT().
+		RecordOperation(gomock.Any(), gomock.Cond(func(op wallet.OperationRecord) bool {
+			return op.WalletID == walletID && op.Type == wallet.OperationExpire && op.Amount == 40 && op.Metadata["bucket"] == "bonus"
+		}))
+
+	cache.EXPECT().
+		Delete(gomock.Any(), walletID.String())
+
+	expired, err := service.ExpireDue(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, 1, expired)
+}
+
+func TestWalletService_Withdraw_Fee(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWalletStorage(ctrl)
+	cache := mocks.NewMockWalletCache(ctrl)
+	logger := slog.Default()
+
+	eurFeeWalletID := uuid.New()
+	fees := &services.FeeSchedule{
+		FeeWalletID: uuid.New(),
+		FeeWallets:  map[string]uuid.UUID{"EUR": eurFeeWalletID},
+		Rules:       []services.FeeRule{{Operation: wallet.OperationWithdraw, Flat: 5}},
+	}
+	service := services.NewWalletService(repo, cache, logger, services.WithFeeSchedule(fees))
+
+	walletID := uuid.New()
+
+	tests := []struct {
+		name          string
+		currency      string
+		feeWalletID   uuid.UUID
+		feeCurrency   string
+		feeWalletErr  error
+		feeBalance    int64
+		expectedFee   int64
+		expectedError error
+		internalError bool // internalError expects an error no client-facing error matches
+	}{
+		{
+			name:        "fee charged",
+			currency:    "USD",
+			feeWalletID: fees.FeeWalletID,
+			feeCurrency: "USD",
+			feeBalance:  95,
+			expectedFee: 5,
+		},
+		{
+			name:        "fee posted to the fee wallet of the currency",
+			currency:    "EUR",
+			feeWalletID: eurFeeWalletID,
+			feeCurrency: "EUR",
+			feeBalance:  95,
+			expectedFee: 5,
+		},
+		{
+			name:          "no fee wallet in the currency",
+			currency:      "GBP",
+			feeWalletID:   fees.FeeWalletID,
+			feeCurrency:   "USD",
+			internalError: true,
+		},
+		{
+			name:          "fee wallet does not exist",
+			currency:      "EUR",
+			feeWalletID:   eurFeeWalletID,
+			feeWalletErr:  wallet.ErrWalletNotFound,
+			internalError: true,
+		},
+		{
+			name:          "not enough money for fee",
+			currency:      "USD",
+			feeWalletID:   fees.FeeWalletID,
+			feeCurrency:   "USD",
+			feeBalance:    -2,
+			expectedError: wallet.ErrNotEnoughMoney,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tx := mocks.NewMockTx(ctrl)
+			repo.EXPECT().
+				WithinTx(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, fn repoModel.TxFunc) error {
+					return fn(ctx, tx)
+				})
+
+			tx.EXPECT().
+				Withdraw(gomock.Any(), walletID, int64(100), services.DefaultWithdrawalOrder).
+				Return(tt.feeBalance+5, nil)
+			tx.EXPECT().
+				Tier(gomock.Any(), walletID).
+				Return("standard", nil)
+			tx.EXPECT().
+				LockWallets(gomock.Any(), walletID).
+				Return(map[uuid.UUID]string{walletID: tt.currency}, nil)
+			tx.EXPECT().
+				LockWallets(gomock.Any(), tt.feeWalletID).
+				Return(map[uuid.UUID]string{tt.feeWalletID: tt.feeCurrency}, tt.feeWalletErr)
+
+			if tt.feeCurrency == tt.currency {
+				tx.EXPECT().
+					ChargeFee(gomock.Any(), walletID, tt.feeWalletID, int64(5), services.DefaultSpendingOrder).
+					Return(tt.feeBalance, nil)
+			}
+
+			if tt.expectedError == nil && !tt.internalError {
+				tx.EXPECT().
+					RecordOperation(gomock.Any(), gomock.Cond(func(op wallet.OperationRecord) bool {
+						return op.Type == wallet.OperationFee && op.WalletID == tt.feeWalletID && op.Amount == tt.expectedFee
+					}))
+				tx.EXPECT().
+					RecordOperation(gomock.Any(), gomock.Cond(func(op wallet.OperationRecord) bool {
+						return op.Type == wallet.OperationWithdraw && op.Amount == 100 && op.Fee == tt.expectedFee
+					}))
+				cache.EXPECT().
+					Delete(gomock.Any(), walletID.String())
+				// the fee-income wallets are credited too
+				cache.EXPECT().
+					Delete(gomock.Any(), fees.FeeWalletID.String())
+				cache.EXPECT().
+					Delete(gomock.Any(), eurFeeWalletID.String())
+			}
+
+			receipt, err := service.Withdraw(t.Context(), walletID, 100, nil, nil)
+			switch {
+			case tt.internalError:
+				require.Error(t, err)
+				require.NotErrorIs(t, err, wallet.ErrCurrencyMismatch)
+				require.NotErrorIs(t, err, wallet.ErrWalletNotFound)
+				return
+			case tt.expectedError != nil:
+				require.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedFee, receipt.Fee)
+			require.Equal(t, tt.feeBalance, receipt.Balance)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets
+    ADD COLUMN hot BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE wallet_entries (
+    id         BIGSERIAL PRIMARY KEY,
+    wallet_id  UUID        NOT NULL REFERENCES wallets (id),
+    type       TEXT        NOT NULL,
+    amount     BIGINT      NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX wallet_entries_wallet_id_created_at_idx ON wallet_entries (wallet_id, created_at);
+
+-- balances that existed before the journal are carried over as opening entries
+INSERT INTO wallet_entries (wallet_id, type, amount)
+SELECT id, 'OPENING', balance
+FROM wallets
+WHERE balance <> 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE wallet_entries;
+
+ALTER TABLE wallets
+    DROP COLUMN hot;
+-- +goose StatementEnd
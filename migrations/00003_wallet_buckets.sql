@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE wallet_buckets (
+    wallet_id UUID   NOT NULL REFERENCES wallets (id),
+    name      TEXT   NOT NULL,
+    balance   BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (wallet_id, name)
+);
+
+INSERT INTO wallet_buckets (wallet_id, name, balance)
+SELECT id, 'main', balance
+FROM wallets
+WHERE balance <> 0;
+
+ALTER TABLE wallet_entries
+    ADD COLUMN bucket TEXT NOT NULL DEFAULT 'main';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE wallet_entries
+    DROP COLUMN bucket;
+
+DROP TABLE wallet_buckets;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- expiring credits; their unspent remainder is still part of the bucket balance
+CREATE TABLE wallet_lots (
+    id         BIGSERIAL PRIMARY KEY,
+    wallet_id  UUID        NOT NULL REFERENCES wallets (id),
+    bucket     TEXT        NOT NULL,
+    remaining  BIGINT      NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX wallet_lots_wallet_id_idx ON wallet_lots (wallet_id, expires_at) WHERE remaining > 0;
+CREATE INDEX wallet_lots_expires_at_idx ON wallet_lots (expires_at) WHERE remaining > 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE wallet_lots;
+-- +goose StatementEnd
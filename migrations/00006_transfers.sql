@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+CREATE TABLE fx_quotes (
+    id            UUID PRIMARY KEY,
+    from_currency CHAR(3)     NOT NULL,
+    to_currency   CHAR(3)     NOT NULL,
+    rate          NUMERIC     NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    used_at       TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE transfers (
+    id             UUID PRIMARY KEY,
+    from_wallet_id UUID        NOT NULL REFERENCES wallets (id),
+    to_wallet_id   UUID        NOT NULL REFERENCES wallets (id),
+    from_currency  CHAR(3)     NOT NULL,
+    to_currency    CHAR(3)     NOT NULL,
+    quote_id       UUID REFERENCES fx_quotes (id),
+    rate           NUMERIC     NOT NULL,
+    debit_amount   BIGINT      NOT NULL,
+    credit_amount  BIGINT      NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX transfers_from_wallet_id_idx ON transfers (from_wallet_id, created_at);
+CREATE INDEX transfers_to_wallet_id_idx ON transfers (to_wallet_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE transfers;
+DROP TABLE fx_quotes;
+
+ALTER TABLE wallets
+    DROP COLUMN currency;
+-- +goose StatementEnd
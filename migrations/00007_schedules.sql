@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE schedules (
+    id             UUID PRIMARY KEY,
+    operation_type TEXT        NOT NULL,
+    wallet_id      UUID        NOT NULL REFERENCES wallets (id),
+    to_wallet_id   UUID REFERENCES wallets (id),
+    amount         BIGINT      NOT NULL CHECK (amount > 0),
+    recurrence     TEXT        NOT NULL DEFAULT '',
+    next_run_at    TIMESTAMPTZ,
+    status         TEXT        NOT NULL DEFAULT 'ACTIVE',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX schedules_due_idx ON schedules (next_run_at) WHERE status = 'ACTIVE';
+CREATE INDEX schedules_wallet_id_idx ON schedules (wallet_id, created_at);
+
+CREATE TABLE schedule_runs (
+    schedule_id UUID        NOT NULL REFERENCES schedules (id),
+    run_at      TIMESTAMPTZ NOT NULL,
+    status      TEXT        NOT NULL,
+    error       TEXT        NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (schedule_id, run_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE schedule_runs;
+DROP TABLE schedules;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets
+    ADD COLUMN interest_product TEXT;
+
+CREATE TABLE interest_accruals (
+    wallet_id     UUID    NOT NULL REFERENCES wallets (id),
+    accrual_date  DATE    NOT NULL,
+    product       TEXT    NOT NULL,
+    balance       BIGINT  NOT NULL,
+    amount        NUMERIC NOT NULL,
+    posted_amount BIGINT  NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (wallet_id, accrual_date)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE interest_accruals;
+
+ALTER TABLE wallets
+    DROP COLUMN interest_product;
+-- +goose StatementEnd
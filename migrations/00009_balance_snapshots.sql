@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE wallet_balance_snapshots (
+    wallet_id UUID        NOT NULL REFERENCES wallets (id),
+    taken_at  TIMESTAMPTZ NOT NULL,
+    bucket    TEXT        NOT NULL,
+    balance   BIGINT      NOT NULL,
+    PRIMARY KEY (wallet_id, taken_at, bucket)
+);
+
+CREATE INDEX wallet_balance_snapshots_taken_at_idx ON wallet_balance_snapshots (taken_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE wallet_balance_snapshots;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets
+    ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX wallets_metadata_idx ON wallets USING GIN (metadata jsonb_path_ops);
+
+-- deposits and withdrawals as requested, with the references attached to them
+CREATE TABLE wallet_operations (
+    id         UUID PRIMARY KEY,
+    wallet_id  UUID        NOT NULL REFERENCES wallets (id),
+    type       TEXT        NOT NULL,
+    amount     BIGINT      NOT NULL,
+    fee        BIGINT      NOT NULL DEFAULT 0,
+    metadata   JSONB       NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX wallet_operations_wallet_id_created_at_idx ON wallet_operations (wallet_id, created_at, id);
+CREATE INDEX wallet_operations_metadata_idx ON wallet_operations USING GIN (metadata jsonb_path_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE wallet_operations;
+
+ALTER TABLE wallets
+    DROP COLUMN metadata;
+-- +goose StatementEnd
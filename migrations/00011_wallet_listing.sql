@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets
+    ADD COLUMN owner_id   TEXT,
+    ADD COLUMN status     TEXT        NOT NULL DEFAULT 'ACTIVE',
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX wallets_created_at_id_idx ON wallets (created_at, id);
+CREATE INDEX wallets_owner_id_idx ON wallets (owner_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE wallets
+    DROP COLUMN created_at,
+    DROP COLUMN status,
+    DROP COLUMN owner_id;
+-- +goose StatementEnd
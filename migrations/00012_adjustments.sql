@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE adjustments (
+    id         UUID PRIMARY KEY,
+    wallet_id  UUID        NOT NULL REFERENCES wallets (id),
+    bucket     TEXT        NOT NULL,
+    amount     BIGINT      NOT NULL,
+    reason     TEXT        NOT NULL,
+    created_by TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX adjustments_wallet_id_idx ON adjustments (wallet_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE adjustments;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE adjustment_requests (
+    id           UUID PRIMARY KEY,
+    wallet_id    UUID        NOT NULL REFERENCES wallets (id),
+    bucket       TEXT        NOT NULL,
+    amount       BIGINT      NOT NULL,
+    reason       TEXT        NOT NULL,
+    status       TEXT        NOT NULL DEFAULT 'PENDING',
+    requested_by TEXT        NOT NULL,
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    decided_by   TEXT,
+    decided_at   TIMESTAMPTZ,
+    note         TEXT        NOT NULL DEFAULT '',
+    CHECK (decided_by IS NULL OR decided_by <> requested_by)
+);
+
+CREATE INDEX adjustment_requests_status_idx ON adjustment_requests (status, requested_at);
+
+CREATE TABLE adjustment_events (
+    id         BIGSERIAL PRIMARY KEY,
+    request_id UUID        NOT NULL REFERENCES adjustment_requests (id),
+    action     TEXT        NOT NULL,
+    principal  TEXT        NOT NULL,
+    note       TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX adjustment_events_request_id_idx ON adjustment_events (request_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE adjustment_events;
+DROP TABLE adjustment_requests;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE audit_log (
+    seq         BIGINT PRIMARY KEY,
+    created_at  TIMESTAMPTZ NOT NULL,
+    principal   TEXT        NOT NULL,
+    remote_addr TEXT        NOT NULL,
+    request_id  TEXT        NOT NULL,
+    operation   TEXT        NOT NULL,
+    wallet_id   UUID,
+    resource    TEXT        NOT NULL DEFAULT '',
+    amount      BIGINT,
+    status      INT         NOT NULL,
+    prev_hash   TEXT        NOT NULL,
+    hash        TEXT        NOT NULL
+);
+
+CREATE INDEX audit_log_wallet_id_idx ON audit_log (wallet_id, seq);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_append_only();
+-- +goose StatementEnd
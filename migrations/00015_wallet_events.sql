@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE wallets
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
+
+-- every change of a wallet balance, numbered per wallet by the version it produced
+CREATE TABLE wallet_events (
+    wallet_id  UUID        NOT NULL REFERENCES wallets (id),
+    version    BIGINT      NOT NULL,
+    balance    BIGINT      NOT NULL,
+    delta      BIGINT      NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (wallet_id, version)
+);
+
+-- the row lock taken by the update orders versions of a wallet by commit;
+-- listeners are notified when the transaction commits
+CREATE FUNCTION wallet_balance_changed() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+
+    INSERT INTO wallet_events (wallet_id, version, balance, delta)
+    VALUES (NEW.id, NEW.version, NEW.balance, NEW.balance - OLD.balance);
+
+    PERFORM pg_notify('wallet_events', json_build_object(
+        'walletId', NEW.id,
+        'version', NEW.version,
+        'balance', NEW.balance,
+        'delta', NEW.balance - OLD.balance,
+        'createdAt', now()
+    )::text);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER wallets_balance_changed
+    BEFORE UPDATE OF balance ON wallets
+    FOR EACH ROW
+    WHEN (OLD.balance IS DISTINCT FROM NEW.balance)
+    EXECUTE FUNCTION wallet_balance_changed();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER wallets_balance_changed ON wallets;
+DROP FUNCTION wallet_balance_changed();
+DROP TABLE wallet_events;
+
+ALTER TABLE wallets
+    DROP COLUMN version;
+-- +goose StatementEnd
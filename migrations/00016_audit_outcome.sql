@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- records written before are left without an outcome, their hashes do not cover it
+ALTER TABLE audit_log
+    ADD COLUMN outcome TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE audit_log
+    DROP COLUMN outcome;
+-- +goose StatementEnd
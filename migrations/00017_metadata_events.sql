@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- metadata is part of the versioned wallet, so its changes are events too, with a delta of 0
+DROP TRIGGER wallets_balance_changed ON wallets;
+
+CREATE TRIGGER wallets_balance_changed
+    BEFORE UPDATE OF balance, metadata ON wallets
+    FOR EACH ROW
+    WHEN (OLD.balance IS DISTINCT FROM NEW.balance OR OLD.metadata IS DISTINCT FROM NEW.metadata)
+    EXECUTE FUNCTION wallet_balance_changed();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER wallets_balance_changed ON wallets;
+
+CREATE TRIGGER wallets_balance_changed
+    BEFORE UPDATE OF balance ON wallets
+    FOR EACH ROW
+    WHEN (OLD.balance IS DISTINCT FROM NEW.balance)
+    EXECUTE FUNCTION wallet_balance_changed();
+-- +goose StatementEnd